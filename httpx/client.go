@@ -225,12 +225,34 @@ func (c *Client) do(req *http.Request, statusAsError bool) (*http.Response, erro
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	cancel := context.CancelFunc(func() {})
 	if dl, ok := earliestDeadline(ctx, c.timeout, requestTimeout(ctx)); ok {
-		ctx2, cancel := withEarlierDeadline(ctx, dl)
-		defer cancel()
-		ctx = ctx2
+		ctx, cancel = withEarlierDeadline(ctx, dl)
 	}
-	req = req.Clone(ctx)
+
+	resp, err := c.doAttempts(ctx, req.Clone(ctx), statusAsError)
+	if resp != nil && resp.Body != nil {
+		// The deadline must outlive Do: callers read the body (possibly a long stream) afterwards.
+		resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	} else {
+		cancel()
+	}
+	return resp, err
+}
+
+// cancelOnCloseBody releases the per-request deadline once the caller closes the body.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (c *Client) doAttempts(ctx context.Context, req *http.Request, statusAsError bool) (*http.Response, error) {
 
 	maxAttempts := c.retry.MaxAttempts
 	if maxAttempts <= 0 {
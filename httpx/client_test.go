@@ -202,3 +202,35 @@ func TestRequestTimeoutOption(t *testing.T) {
 		t.Fatalf("expected timeout error")
 	}
 }
+
+func TestDo_BodyReadableAfterReturnWithTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte(strings.Repeat("a", 64<<10)))
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(WithBaseURL(srv.URL), WithTimeout(2*time.Second))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	req, err := c.NewRequest(context.Background(), http.MethodGet, "/")
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	resp, err := c.DoStatus(req)
+	if err != nil {
+		t.Fatalf("DoStatus: %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	if len(b) != 64<<10 {
+		t.Fatalf("expected %d bytes, got %d", 64<<10, len(b))
+	}
+}
@@ -0,0 +1,210 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// Accumulate 读取 stream 直到结束，将全部事件累积为完整的 ChatResponse，并关闭 stream。
+//
+// 出错时返回已累积的部分响应以及错误。
+func Accumulate(stream Stream) (schema.ChatResponse, error) {
+	defer stream.Close()
+
+	var acc Accumulator
+	for {
+		ev, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return acc.Response(), nil
+		}
+		if err != nil {
+			return acc.Response(), err
+		}
+		acc.Add(ev)
+	}
+}
+
+// Accumulator 将流式事件逐个累积为完整的 ChatResponse，零值可直接使用。
+//
+// 适用于需要边消费事件边构建最终响应的场景：
+//
+//	var acc llm.Accumulator
+//	for {
+//	    ev, err := stream.Recv()
+//	    ...
+//	    fmt.Print(ev.Delta)
+//	    acc.Add(ev)
+//	}
+//	resp := acc.Response()
+//
+// 累积规则：
+//   - Delta / Reasoning 按 ChoiceIndex 拼接，ReasoningBlock 按顺序追加到 Message.ReasoningBlocks
+//   - 工具调用片段按 ToolCall.Index 合并（无 Index 时按 ID 合并，ID 也为空时并入该 choice 的最后一个调用），
+//     ID / Type / Name / Signature 取首个非空值，Arguments 拼接
+//   - FinishReason 取该 choice 最后一个非空值，Usage 取最后一个非空值（兼容仅含 usage 的结尾事件），Model 取首个非空值
+//   - ExtraFields 按事件顺序合并，后出现的键覆盖先出现的键
+//   - 事件带有 Raw 时，ChatResponse.Raw 为去除相邻重复后的原始数据块组成的 JSON 数组
+type Accumulator struct {
+	choices []*accChoice
+	usage   *schema.Usage
+	model   string
+	extra   map[string]any
+	raws    []json.RawMessage
+}
+
+type accChoice struct {
+	index        int
+	text         bytes.Buffer
+	reasoning    bytes.Buffer
+	blocks       []schema.ReasoningBlock
+	calls        []*accToolCall
+	finishReason schema.FinishReason
+}
+
+type accToolCall struct {
+	index *int
+	call  schema.ToolCall
+	args  bytes.Buffer
+}
+
+// Add 累积一个流式事件
+func (a *Accumulator) Add(ev schema.StreamEvent) {
+	if len(ev.Raw) > 0 && (len(a.raws) == 0 || !bytes.Equal(a.raws[len(a.raws)-1], ev.Raw)) {
+		a.raws = append(a.raws, ev.Raw)
+	}
+	if ev.Usage != nil {
+		u := *ev.Usage
+		a.usage = &u
+	}
+	if a.model == "" {
+		a.model = ev.Model
+	}
+	if len(ev.ExtraFields) > 0 {
+		if a.extra == nil {
+			a.extra = make(map[string]any, len(ev.ExtraFields))
+		}
+		maps.Copy(a.extra, ev.ExtraFields)
+	}
+
+	if ev.Delta == "" && ev.Reasoning == "" && len(ev.ToolCalls) == 0 && ev.FinishReason == nil && ev.ReasoningBlock == nil {
+		return
+	}
+
+	c := a.choice(ev.ChoiceIndex)
+	c.text.WriteString(ev.Delta)
+	c.reasoning.WriteString(ev.Reasoning)
+	if ev.ReasoningBlock != nil {
+		c.blocks = append(c.blocks, *ev.ReasoningBlock)
+	}
+	for _, tc := range ev.ToolCalls {
+		c.addToolCall(tc)
+	}
+	if ev.FinishReason != nil && *ev.FinishReason != "" {
+		c.finishReason = *ev.FinishReason
+	}
+}
+
+func (a *Accumulator) choice(index int) *accChoice {
+	for _, c := range a.choices {
+		if c.index == index {
+			return c
+		}
+	}
+	c := &accChoice{index: index}
+	a.choices = append(a.choices, c)
+	return c
+}
+
+func (c *accChoice) addToolCall(tc schema.ToolCall) {
+	t := c.findToolCall(tc)
+	if t == nil {
+		t = &accToolCall{index: tc.Index}
+		c.calls = append(c.calls, t)
+	}
+
+	if t.call.ID == "" {
+		t.call.ID = tc.ID
+	}
+	if t.call.Type == "" {
+		t.call.Type = tc.Type
+	}
+	if t.call.Function.Name == "" {
+		t.call.Function.Name = tc.Function.Name
+	}
+	if t.call.Signature == "" {
+		t.call.Signature = tc.Signature
+	}
+	t.args.WriteString(tc.Function.Arguments)
+}
+
+func (c *accChoice) findToolCall(tc schema.ToolCall) *accToolCall {
+	switch {
+	case tc.Index != nil:
+		for _, t := range c.calls {
+			if t.index != nil && *t.index == *tc.Index {
+				return t
+			}
+		}
+	case tc.ID != "":
+		for _, t := range c.calls {
+			if t.call.ID == tc.ID {
+				return t
+			}
+		}
+	case len(c.calls) > 0:
+		return c.calls[len(c.calls)-1]
+	}
+	return nil
+}
+
+// Response 返回当前已累积内容构成的 ChatResponse，可多次调用。
+//
+// Choices 按 ChoiceIndex 升序排列，工具调用按首次出现的顺序排列，且不再携带 Index。
+func (a *Accumulator) Response() schema.ChatResponse {
+	out := schema.ChatResponse{
+		Model:       a.model,
+		Choices:     make([]schema.Choice, 0, len(a.choices)),
+		ExtraFields: maps.Clone(a.extra),
+	}
+	if a.usage != nil {
+		out.Usage = *a.usage
+	}
+
+	for _, c := range a.choices {
+		msg := schema.Message{
+			Role:             schema.RoleAssistant,
+			ReasoningContent: c.reasoning.String(),
+			ReasoningBlocks:  slices.Clone(c.blocks),
+		}
+		if c.text.Len() > 0 {
+			msg.Content = []schema.ContentPart{schema.TextContent{Text: c.text.String()}}
+		}
+		for _, t := range c.calls {
+			call := t.call
+			if call.Type == "" {
+				call.Type = schema.ToolCallTypeFunction
+			}
+			call.Function.Arguments = t.args.String()
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		out.Choices = append(out.Choices, schema.Choice{
+			Index:        c.index,
+			Message:      msg,
+			FinishReason: c.finishReason,
+		})
+	}
+	slices.SortStableFunc(out.Choices, func(x, y schema.Choice) int { return x.Index - y.Index })
+
+	if len(a.raws) > 0 {
+		if raw, err := json.Marshal(a.raws); err == nil {
+			out.Raw = raw
+		}
+	}
+	return out
+}
@@ -0,0 +1,318 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lgc202/go-kit/llm"
+	anthropic "github.com/lgc202/go-kit/llm/provider/anthropic/chat"
+	deepseek "github.com/lgc202/go-kit/llm/provider/deepseek/chat"
+	gemini "github.com/lgc202/go-kit/llm/provider/gemini/chat"
+	ollama "github.com/lgc202/go-kit/llm/provider/ollama/chat"
+	openai "github.com/lgc202/go-kit/llm/provider/openai/chat"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// fixtureServer 返回以 testdata/stream 下录制的流式响应作为响应体的测试服务
+func fixtureServer(t *testing.T, name, contentType string) string {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", "stream", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func accumulate(t *testing.T, model llm.ChatModel, opts ...llm.ChatOption) schema.ChatResponse {
+	t.Helper()
+
+	stream, err := model.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Hi")}, opts...)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	resp, err := llm.Accumulate(stream)
+	if err != nil {
+		t.Fatalf("Accumulate() error = %v", err)
+	}
+	return resp
+}
+
+func TestAccumulate_OpenAIToolCalls(t *testing.T) {
+	t.Parallel()
+
+	baseURL := fixtureServer(t, "openai_tool_calls.sse", "text/event-stream")
+	client, err := openai.New(openai.Config{BaseConfig: openai.BaseConfig{BaseURL: baseURL, APIKey: "k"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := accumulate(t, client, llm.WithModel("gpt-4o-mini"), llm.WithKeepRaw(true))
+	if len(resp.Choices) != 1 {
+		t.Fatalf("len(Choices) = %d, want 1", len(resp.Choices))
+	}
+	c := resp.Choices[0]
+	if c.FinishReason != schema.FinishReasonToolCalls {
+		t.Errorf("FinishReason = %q", c.FinishReason)
+	}
+	want := []schema.ToolCall{
+		{ID: "call_weather", Type: schema.ToolCallTypeFunction, Function: schema.ToolFunction{Name: "get_weather", Arguments: `{"city":"Beijing"}`}},
+		{ID: "call_time", Type: schema.ToolCallTypeFunction, Function: schema.ToolFunction{Name: "get_time", Arguments: `{"tz":"Asia/Shanghai"}`}},
+	}
+	if len(c.Message.ToolCalls) != len(want) {
+		t.Fatalf("ToolCalls = %+v", c.Message.ToolCalls)
+	}
+	for i, tc := range c.Message.ToolCalls {
+		if tc.ID != want[i].ID || tc.Type != want[i].Type || tc.Function != want[i].Function {
+			t.Errorf("ToolCalls[%d] = %+v, want %+v", i, tc, want[i])
+		}
+	}
+
+	// 仅含 usage 的结尾 chunk
+	if resp.Usage.TotalTokens != 123 || resp.Usage.PromptTokens != 82 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+
+	var raws []json.RawMessage
+	if err := json.Unmarshal(resp.Raw, &raws); err != nil {
+		t.Fatalf("Raw is not a JSON array: %v", err)
+	}
+	if len(raws) != 6 {
+		t.Errorf("len(Raw) = %d, want 6 chunks", len(raws))
+	}
+}
+
+func TestAccumulate_OpenAIMultipleChoices(t *testing.T) {
+	t.Parallel()
+
+	baseURL := fixtureServer(t, "openai_multi_choice.sse", "text/event-stream")
+	client, err := openai.New(openai.Config{BaseConfig: openai.BaseConfig{BaseURL: baseURL, APIKey: "k"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := accumulate(t, client, llm.WithModel("gpt-4o-mini"), llm.WithN(2))
+	if len(resp.Choices) != 2 {
+		t.Fatalf("len(Choices) = %d, want 2", len(resp.Choices))
+	}
+	tests := []struct {
+		text   string
+		reason schema.FinishReason
+	}{
+		{"Hello there", schema.FinishReasonStop},
+		{"Hi!", schema.FinishReasonLength},
+	}
+	for i, tt := range tests {
+		c := resp.Choices[i]
+		if c.Index != i || c.Message.Text() != tt.text || c.FinishReason != tt.reason {
+			t.Errorf("Choices[%d] = {Index: %d, Text: %q, FinishReason: %q}", i, c.Index, c.Message.Text(), c.FinishReason)
+		}
+		if c.Message.Role != schema.RoleAssistant {
+			t.Errorf("Choices[%d].Role = %q", i, c.Message.Role)
+		}
+	}
+	if resp.Raw != nil {
+		t.Errorf("Raw = %s, want nil without WithKeepRaw", resp.Raw)
+	}
+}
+
+func TestAccumulate_DeepSeekReasoning(t *testing.T) {
+	t.Parallel()
+
+	baseURL := fixtureServer(t, "deepseek_reasoning.sse", "text/event-stream")
+	client, err := deepseek.New(deepseek.Config{BaseConfig: deepseek.BaseConfig{BaseURL: baseURL, APIKey: "k"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := accumulate(t, client, llm.WithModel("deepseek-reasoner"))
+	c := resp.Choices[0]
+	if c.Message.ReasoningContent != "1+1 equals 2." || c.Message.Text() != "2" {
+		t.Errorf("Reasoning = %q, Text = %q", c.Message.ReasoningContent, c.Message.Text())
+	}
+	if c.FinishReason != schema.FinishReasonStop {
+		t.Errorf("FinishReason = %q", c.FinishReason)
+	}
+	if resp.Usage.CompletionTokensDetails == nil || resp.Usage.CompletionTokensDetails.ReasoningTokens != 18 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestAccumulate_AnthropicToolUse(t *testing.T) {
+	t.Parallel()
+
+	baseURL := fixtureServer(t, "anthropic_tool_use.sse", "text/event-stream")
+	client, err := anthropic.New(anthropic.Config{BaseConfig: anthropic.BaseConfig{BaseURL: baseURL, APIKey: "k"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := accumulate(t, client, llm.WithModel("claude-sonnet-4-5"), llm.WithMaxTokens(1024))
+	c := resp.Choices[0]
+	if c.Message.Text() != "Let me check the weather." {
+		t.Errorf("Text = %q", c.Message.Text())
+	}
+	if c.FinishReason != schema.FinishReasonToolCalls {
+		t.Errorf("FinishReason = %q", c.FinishReason)
+	}
+	if len(c.Message.ToolCalls) != 1 {
+		t.Fatalf("ToolCalls = %+v", c.Message.ToolCalls)
+	}
+	tc := c.Message.ToolCalls[0]
+	if tc.ID != "toolu_01" || tc.Function.Name != "get_weather" || tc.Function.Arguments != `{"city": "San Francisco"}` {
+		t.Errorf("ToolCall = %+v", tc)
+	}
+	if resp.Usage.PromptTokens != 472 || resp.Usage.CompletionTokens != 89 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestAccumulate_Gemini(t *testing.T) {
+	t.Parallel()
+
+	baseURL := fixtureServer(t, "gemini_text.sse", "text/event-stream")
+	client, err := gemini.New(gemini.Config{BaseConfig: gemini.BaseConfig{BaseURL: baseURL, APIKey: "k"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := accumulate(t, client, llm.WithModel("gemini-2.5-flash"))
+	c := resp.Choices[0]
+	if c.Message.Text() != "Go is a compiled language." {
+		t.Errorf("Text = %q", c.Message.Text())
+	}
+	if len(c.Message.ToolCalls) != 1 || c.Message.ToolCalls[0].Function.Name != "lookup" || c.Message.ToolCalls[0].Function.Arguments != `{"q":"go"}` {
+		t.Errorf("ToolCalls = %+v", c.Message.ToolCalls)
+	}
+	if c.FinishReason != schema.FinishReasonToolCalls {
+		t.Errorf("FinishReason = %q", c.FinishReason)
+	}
+	if resp.Usage.TotalTokens != 17 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestAccumulate_Ollama(t *testing.T) {
+	t.Parallel()
+
+	baseURL := fixtureServer(t, "ollama_chat.ndjson", "application/x-ndjson")
+	client, err := ollama.New(ollama.Config{BaseConfig: ollama.BaseConfig{BaseURL: baseURL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := accumulate(t, client, llm.WithModel("qwen3"))
+	c := resp.Choices[0]
+	if c.Message.ReasoningContent != "Need weather." || c.Message.Text() != "Checking" {
+		t.Errorf("Reasoning = %q, Text = %q", c.Message.ReasoningContent, c.Message.Text())
+	}
+	if len(c.Message.ToolCalls) != 1 || c.Message.ToolCalls[0].Function.Arguments != `{"city":"Tokyo"}` {
+		t.Errorf("ToolCalls = %+v", c.Message.ToolCalls)
+	}
+	if resp.Usage.PromptTokens != 26 || resp.Usage.CompletionTokens != 282 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+	if resp.Model != "qwen3" {
+		t.Errorf("Model = %q", resp.Model)
+	}
+}
+
+type errStream struct {
+	events []schema.StreamEvent
+	err    error
+	closed bool
+}
+
+func (s *errStream) Recv() (schema.StreamEvent, error) {
+	if len(s.events) == 0 {
+		return schema.StreamEvent{}, s.err
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func (s *errStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestAccumulate_ErrorReturnsPartial(t *testing.T) {
+	t.Parallel()
+
+	wantErr := errors.New("connection reset")
+	s := &errStream{
+		events: []schema.StreamEvent{{Type: schema.StreamEventDelta, Delta: "partial"}},
+		err:    wantErr,
+	}
+	resp, err := llm.Accumulate(s)
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("err = %v, want %v", err, wantErr)
+	}
+	if resp.Choices[0].Message.Text() != "partial" {
+		t.Errorf("Text = %q", resp.Choices[0].Message.Text())
+	}
+	if !s.closed {
+		t.Error("stream not closed")
+	}
+
+	s = &errStream{err: io.EOF}
+	resp, err = llm.Accumulate(s)
+	if err != nil || len(resp.Choices) != 0 {
+		t.Errorf("empty stream: resp = %+v, err = %v", resp, err)
+	}
+}
+
+func TestResponseStream_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	want := schema.ChatResponse{
+		Model: "claude-sonnet-4",
+		Choices: []schema.Choice{{
+			Message: schema.Message{
+				Role:             schema.RoleAssistant,
+				Content:          []schema.ContentPart{schema.TextContent{Text: "你好，世界"}},
+				ReasoningContent: "think",
+				ReasoningBlocks:  []schema.ReasoningBlock{{Text: "think", Signature: "sig"}},
+				ToolCalls: []schema.ToolCall{{
+					ID: "call_1", Type: schema.ToolCallTypeFunction,
+					Function: schema.ToolFunction{Name: "f", Arguments: `{"x":1}`},
+				}},
+			},
+			FinishReason: schema.FinishReasonToolCalls,
+		}},
+		Usage:       schema.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+		ExtraFields: map[string]any{"k": "v"},
+	}
+
+	events := llm.ResponseEvents(want, 2)
+	// reasoning 3 段 + 文本 3 段 + 推理块 + 工具调用 + done
+	if len(events) != 9 || events[0].ExtraFields["k"] != "v" || events[8].Usage == nil || events[8].Model != want.Model {
+		t.Fatalf("events = %+v", events)
+	}
+
+	got, err := llm.Accumulate(llm.ResponseStream(want, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Choices, want.Choices) || got.Usage != want.Usage || got.Model != want.Model || got.ExtraFields["k"] != "v" {
+		t.Errorf("got = %+v\nwant %+v", got, want)
+	}
+
+	if events := llm.ResponseEvents(schema.ChatResponse{}, 0); len(events) != 1 || events[0].Type != schema.StreamEventDone {
+		t.Errorf("empty response events = %+v", events)
+	}
+}
@@ -0,0 +1,244 @@
+// Package agent 实现基于工具调用的多轮对话循环：
+// 调用模型 → 执行模型请求的工具 → 追加工具结果 → 再次调用模型，直到模型不再请求工具。
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"sync"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// DefaultMaxIterations 未设置 Config.MaxIterations 时的最大模型调用次数
+const DefaultMaxIterations = 10
+
+// ErrMaxIterations 达到最大模型调用次数时模型仍在请求工具
+var ErrMaxIterations = errors.New("agent: max iterations exceeded")
+
+// ToolErrorHandler 将工具执行错误转换为发送给模型的工具结果。
+// 返回非 nil 错误时终止循环。
+type ToolErrorHandler func(call schema.ToolCall, err error) (string, error)
+
+type Config struct {
+	// Model 必填
+	Model llm.ChatModel
+
+	// Tools 可供模型调用的工具，必填
+	Tools *Registry
+
+	// MaxIterations 最大模型调用次数，<= 0 时使用 DefaultMaxIterations
+	MaxIterations int
+
+	// Parallelism 同一轮中并发执行的工具调用数上限，<= 0 表示不限制
+	Parallelism int
+
+	// OnToolError 工具执行出错（包括参数解码失败、工具不存在、工具 panic）时的处理方式，
+	// 默认将错误信息作为工具结果返回给模型，由模型决定如何继续
+	OnToolError ToolErrorHandler
+
+	// ValidateArguments 调用工具前按工具的参数 schema 校验模型生成的参数，
+	// 不合法时以 *schema.ValidationError 交给 OnToolError，默认处理方式将其 ToolResult 返回给模型
+	ValidateArguments bool
+
+	// ChatOptions 每次调用模型时使用的默认选项，工具定义会自动追加
+	ChatOptions []llm.ChatOption
+}
+
+// Agent 工具调用循环，可并发使用
+type Agent struct {
+	model       llm.ChatModel
+	tools       *Registry
+	maxIter     int
+	parallelism int
+	onToolError ToolErrorHandler
+	validate    bool
+	opts        []llm.ChatOption
+}
+
+// Result 一次运行的结果
+type Result struct {
+	// Messages 完整对话记录：输入消息、每轮 assistant 消息及工具结果消息
+	Messages []schema.Message
+
+	// Response 最后一次模型调用的响应
+	Response schema.ChatResponse
+
+	// Iterations 模型调用次数
+	Iterations int
+
+	// Usage 全部模型调用的 token 用量之和
+	Usage schema.Usage
+}
+
+// Text 返回最后一条 assistant 消息的文本
+func (r Result) Text() string {
+	if len(r.Response.Choices) == 0 {
+		return ""
+	}
+	return r.Response.Choices[0].Message.Text()
+}
+
+func New(cfg Config) (*Agent, error) {
+	if cfg.Model == nil {
+		return nil, fmt.Errorf("agent: model required")
+	}
+	if cfg.Tools == nil {
+		return nil, fmt.Errorf("agent: tools required")
+	}
+
+	a := &Agent{
+		model:       cfg.Model,
+		tools:       cfg.Tools,
+		maxIter:     cfg.MaxIterations,
+		parallelism: cfg.Parallelism,
+		onToolError: cfg.OnToolError,
+		validate:    cfg.ValidateArguments,
+		opts:        slices.Clone(cfg.ChatOptions),
+	}
+	if a.maxIter <= 0 {
+		a.maxIter = DefaultMaxIterations
+	}
+	if a.onToolError == nil {
+		a.onToolError = defaultToolError
+	}
+	return a, nil
+}
+
+func defaultToolError(_ schema.ToolCall, err error) (string, error) {
+	var ve *schema.ValidationError
+	if errors.As(err, &ve) {
+		return ve.ToolResult(), nil
+	}
+	return "error: " + err.Error(), nil
+}
+
+// Run 使用 Chat 运行工具调用循环。
+//
+// 达到最大调用次数时返回 ErrMaxIterations，此时 Result 中仍包含已产生的对话记录。
+func (a *Agent) Run(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (Result, error) {
+	return a.run(ctx, messages, opts, func(ctx context.Context, msgs []schema.Message, opts []llm.ChatOption) (schema.ChatResponse, error) {
+		return a.model.Chat(ctx, msgs, opts...)
+	})
+}
+
+// RunStream 使用 ChatStream 运行工具调用循环，每个流式事件都会传给 onEvent，
+// 每轮事件累积为完整响应（见 llm.Accumulator）后再执行工具。onEvent 返回错误时终止循环。
+func (a *Agent) RunStream(ctx context.Context, messages []schema.Message, onEvent func(schema.StreamEvent) error, opts ...llm.ChatOption) (Result, error) {
+	return a.run(ctx, messages, opts, func(ctx context.Context, msgs []schema.Message, opts []llm.ChatOption) (schema.ChatResponse, error) {
+		stream, err := a.model.ChatStream(ctx, msgs, opts...)
+		if err != nil {
+			return schema.ChatResponse{}, err
+		}
+
+		var acc llm.Accumulator
+		for ev, err := range llm.Events(ctx, stream) {
+			if err != nil {
+				return acc.Response(), err
+			}
+			if onEvent != nil {
+				if err := onEvent(ev); err != nil {
+					return acc.Response(), err
+				}
+			}
+			acc.Add(ev)
+		}
+		return acc.Response(), nil
+	})
+}
+
+type chatFunc func(ctx context.Context, messages []schema.Message, opts []llm.ChatOption) (schema.ChatResponse, error)
+
+func (a *Agent) run(ctx context.Context, messages []schema.Message, opts []llm.ChatOption, chat chatFunc) (Result, error) {
+	res := Result{Messages: slices.Clone(messages)}
+	callOpts := slices.Concat(a.opts, []llm.ChatOption{llm.WithTools(a.tools.Definitions()...)}, opts)
+
+	for res.Iterations < a.maxIter {
+		resp, err := chat(ctx, res.Messages, callOpts)
+		res.Iterations++
+		if err != nil {
+			return res, err
+		}
+		res.Response = resp
+		res.Usage = res.Usage.Add(resp.Usage)
+
+		if len(resp.Choices) == 0 {
+			return res, fmt.Errorf("agent: empty response")
+		}
+		msg := resp.Choices[0].Message
+		if msg.Role == "" {
+			msg.Role = schema.RoleAssistant
+		}
+		res.Messages = append(res.Messages, msg)
+		if len(msg.ToolCalls) == 0 {
+			return res, nil
+		}
+
+		results, err := a.callTools(ctx, msg.ToolCalls)
+		if err != nil {
+			return res, err
+		}
+		res.Messages = append(res.Messages, results...)
+	}
+
+	return res, fmt.Errorf("%w (%d)", ErrMaxIterations, a.maxIter)
+}
+
+// callTools 执行一轮中的全部工具调用，结果消息顺序与 calls 一致
+func (a *Agent) callTools(ctx context.Context, calls []schema.ToolCall) ([]schema.Message, error) {
+	limit := a.parallelism
+	if limit <= 0 || limit > len(calls) {
+		limit = len(calls)
+	}
+
+	results := make([]schema.Message, len(calls))
+	errs := make([]error, len(calls))
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			out, err := a.callTool(ctx, call)
+			if err != nil {
+				out, err = a.onToolError(call, err)
+			}
+			results[i] = schema.ToolResultMessage(call.ID, out)
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// callTool 执行单个工具调用，工具 panic 时转换为 *PanicError
+func (a *Agent) callTool(ctx context.Context, call schema.ToolCall) (out string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			out, err = "", &PanicError{Tool: call.Function.Name, Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	if a.validate {
+		if t, ok := a.tools.Lookup(call.Function.Name); ok {
+			if err := call.ValidateAgainst(t.Definition()); err != nil {
+				return "", err
+			}
+		}
+	}
+	return a.tools.Call(ctx, call)
+}
@@ -0,0 +1,405 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// scriptedModel 依次返回 responses，并记录每次调用收到的消息与选项
+type scriptedModel struct {
+	mu        sync.Mutex
+	responses []schema.ChatResponse
+	calls     [][]schema.Message
+	tools     [][]schema.Tool
+}
+
+func (m *scriptedModel) next(messages []schema.Message, opts []llm.ChatOption) (schema.ChatResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, messages)
+	m.tools = append(m.tools, llm.ApplyChatOptions(opts...).Tools)
+	if len(m.responses) == 0 {
+		return schema.ChatResponse{}, errors.New("no more responses")
+	}
+	resp := m.responses[0]
+	m.responses = m.responses[1:]
+	return resp, nil
+}
+
+func (m *scriptedModel) Chat(_ context.Context, messages []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+	return m.next(messages, opts)
+}
+
+func (m *scriptedModel) ChatStream(_ context.Context, messages []schema.Message, opts ...llm.ChatOption) (llm.Stream, error) {
+	resp, err := m.next(messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	// 将完整响应拆成逐字符的文本片段与逐个工具调用片段
+	var events []schema.StreamEvent
+	c := resp.Choices[0]
+	for _, r := range c.Message.Text() {
+		events = append(events, schema.StreamEvent{Type: schema.StreamEventDelta, Delta: string(r)})
+	}
+	for i, tc := range c.Message.ToolCalls {
+		idx := i
+		args := tc.Function.Arguments
+		head := tc
+		head.Index = &idx
+		head.Function.Arguments = args[:len(args)/2]
+		tail := schema.ToolCall{Index: &idx, Function: schema.ToolFunction{Arguments: args[len(args)/2:]}}
+		events = append(events,
+			schema.StreamEvent{Type: schema.StreamEventDelta, ToolCalls: []schema.ToolCall{head}},
+			schema.StreamEvent{Type: schema.StreamEventDelta, ToolCalls: []schema.ToolCall{tail}},
+		)
+	}
+	fr := c.FinishReason
+	events = append(events, schema.StreamEvent{Type: schema.StreamEventDone, FinishReason: &fr, Usage: &resp.Usage})
+	return &eventStream{events: events}, nil
+}
+
+type eventStream struct {
+	events []schema.StreamEvent
+}
+
+func (s *eventStream) Recv() (schema.StreamEvent, error) {
+	if len(s.events) == 0 {
+		return schema.StreamEvent{}, io.EOF
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func (s *eventStream) Close() error { return nil }
+
+func toolCallResponse(calls ...schema.ToolCall) schema.ChatResponse {
+	return schema.ChatResponse{
+		Choices: []schema.Choice{{
+			Message:      schema.Message{Role: schema.RoleAssistant, ToolCalls: calls},
+			FinishReason: schema.FinishReasonToolCalls,
+		}},
+		Usage: schema.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}
+}
+
+func textResponse(text string) schema.ChatResponse {
+	return schema.ChatResponse{
+		Choices: []schema.Choice{{
+			Message:      schema.AssistantMessage(text),
+			FinishReason: schema.FinishReasonStop,
+		}},
+		Usage: schema.Usage{PromptTokens: 20, CompletionTokens: 3, TotalTokens: 23},
+	}
+}
+
+func call(id, name, args string) schema.ToolCall {
+	return schema.ToolCall{ID: id, Type: schema.ToolCallTypeFunction, Function: schema.ToolFunction{Name: name, Arguments: args}}
+}
+
+type weatherArgs struct {
+	City string `json:"city" description:"城市名称"`
+	Unit string `json:"unit,omitempty"`
+}
+
+type weather struct {
+	City string `json:"city"`
+	Temp int    `json:"temp"`
+}
+
+func weatherTool(t *testing.T) Tool {
+	t.Helper()
+	tool, err := Func("get_weather", "查询天气", func(_ context.Context, args weatherArgs) (weather, error) {
+		if args.City == "" {
+			return weather{}, errors.New("city required")
+		}
+		return weather{City: args.City, Temp: 22}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tool
+}
+
+func TestFunc_Definition(t *testing.T) {
+	t.Parallel()
+
+	def := weatherTool(t).Definition()
+	if def.Type != schema.ToolTypeFunction || def.Function.Name != "get_weather" || def.Function.Description != "查询天气" {
+		t.Errorf("Definition() = %+v", def)
+	}
+
+	var params struct {
+		Type       string                    `json:"type"`
+		Properties map[string]map[string]any `json:"properties"`
+		Required   []string                  `json:"required"`
+	}
+	if err := json.Unmarshal(def.Function.Parameters, &params); err != nil {
+		t.Fatal(err)
+	}
+	if params.Type != "object" || params.Properties["city"]["type"] != "string" || params.Properties["city"]["description"] != "城市名称" {
+		t.Errorf("parameters = %s", def.Function.Parameters)
+	}
+	if len(params.Required) != 1 || params.Required[0] != "city" {
+		t.Errorf("required = %v, want [city]", params.Required)
+	}
+
+	if _, err := Func("bad", "", func(context.Context, int) (string, error) { return "", nil }); err == nil {
+		t.Error("Func() with non-struct args should fail")
+	}
+}
+
+func TestAgent_Run(t *testing.T) {
+	t.Parallel()
+
+	model := &scriptedModel{responses: []schema.ChatResponse{
+		toolCallResponse(
+			call("c1", "get_weather", `{"city":"北京"}`),
+			call("c2", "get_weather", `{"city":"上海"}`),
+		),
+		textResponse("北京和上海都是 22°C"),
+	}}
+	reg, err := NewRegistry(weatherTool(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(Config{Model: model, Tools: reg})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := a.Run(context.Background(), []schema.Message{schema.UserMessage("天气？")})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if res.Text() != "北京和上海都是 22°C" || res.Iterations != 2 {
+		t.Errorf("Text = %q, Iterations = %d", res.Text(), res.Iterations)
+	}
+	if res.Usage.TotalTokens != 38 {
+		t.Errorf("Usage = %+v", res.Usage)
+	}
+
+	// user, assistant(tool_calls), tool, tool, assistant
+	roles := make([]schema.Role, len(res.Messages))
+	for i, m := range res.Messages {
+		roles[i] = m.Role
+	}
+	want := []schema.Role{schema.RoleUser, schema.RoleAssistant, schema.RoleTool, schema.RoleTool, schema.RoleAssistant}
+	if len(roles) != len(want) {
+		t.Fatalf("roles = %v, want %v", roles, want)
+	}
+	for i := range want {
+		if roles[i] != want[i] {
+			t.Fatalf("roles = %v, want %v", roles, want)
+		}
+	}
+	if res.Messages[2].ToolCallID != "c1" || res.Messages[2].Text() != `{"city":"北京","temp":22}` {
+		t.Errorf("tool result = %+v", res.Messages[2])
+	}
+	if res.Messages[3].ToolCallID != "c2" {
+		t.Errorf("tool result order: %+v", res.Messages[3])
+	}
+
+	if len(model.calls[1]) != 4 {
+		t.Errorf("second call got %d messages, want 4", len(model.calls[1]))
+	}
+	if len(model.tools[0]) != 1 || model.tools[0][0].Function.Name != "get_weather" {
+		t.Errorf("tools = %+v", model.tools[0])
+	}
+}
+
+func TestAgent_RunStream(t *testing.T) {
+	t.Parallel()
+
+	model := &scriptedModel{responses: []schema.ChatResponse{
+		toolCallResponse(call("c1", "get_weather", `{"city":"深圳"}`)),
+		textResponse("28°C"),
+	}}
+	reg, _ := NewRegistry(weatherTool(t))
+	a, _ := New(Config{Model: model, Tools: reg})
+
+	var deltas strings.Builder
+	res, err := a.RunStream(context.Background(), []schema.Message{schema.UserMessage("深圳？")}, func(ev schema.StreamEvent) error {
+		deltas.WriteString(ev.Delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunStream() error = %v", err)
+	}
+	if deltas.String() != "28°C" || res.Text() != "28°C" {
+		t.Errorf("deltas = %q, Text = %q", deltas.String(), res.Text())
+	}
+	if got := res.Messages[1].ToolCalls; len(got) != 1 || got[0].ID != "c1" || got[0].Function.Arguments != `{"city":"深圳"}` {
+		t.Errorf("accumulated tool calls = %+v", got)
+	}
+	if res.Messages[2].Text() != `{"city":"深圳","temp":22}` {
+		t.Errorf("tool result = %q", res.Messages[2].Text())
+	}
+}
+
+func TestAgent_ToolErrors(t *testing.T) {
+	t.Parallel()
+
+	model := &scriptedModel{responses: []schema.ChatResponse{
+		toolCallResponse(
+			call("c1", "get_weather", `{}`),
+			call("c2", "nope", `{}`),
+			call("c3", "get_weather", `{bad json`),
+		),
+		textResponse("done"),
+	}}
+	reg, _ := NewRegistry(weatherTool(t))
+	a, _ := New(Config{Model: model, Tools: reg})
+
+	res, err := a.Run(context.Background(), []schema.Message{schema.UserMessage("?")})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for i, want := range []string{"city required", `unknown tool "nope"`, "invalid arguments"} {
+		if got := res.Messages[2+i].Text(); !strings.Contains(got, want) {
+			t.Errorf("tool result %d = %q, want to contain %q", i, got, want)
+		}
+	}
+
+	// OnToolError 返回错误时终止
+	stop := errors.New("stop")
+	model = &scriptedModel{responses: []schema.ChatResponse{toolCallResponse(call("c1", "nope", `{}`))}}
+	a, _ = New(Config{Model: model, Tools: reg, OnToolError: func(schema.ToolCall, error) (string, error) { return "", stop }})
+	if _, err := a.Run(context.Background(), nil); !errors.Is(err, stop) {
+		t.Errorf("err = %v, want %v", err, stop)
+	}
+}
+
+func TestAgent_ToolPanic(t *testing.T) {
+	t.Parallel()
+
+	boom, err := Func("boom", "总是 panic", func(context.Context, struct{}) (string, error) {
+		panic("kaboom")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg, _ := NewRegistry(boom, weatherTool(t))
+	model := &scriptedModel{responses: []schema.ChatResponse{
+		toolCallResponse(call("c1", "boom", `{}`), call("c2", "get_weather", `{"city":"Paris"}`)),
+		textResponse("done"),
+	}}
+
+	var got error
+	a, _ := New(Config{Model: model, Tools: reg, OnToolError: func(c schema.ToolCall, err error) (string, error) {
+		got = err
+		return "error: " + err.Error(), nil
+	}})
+	res, err := a.Run(context.Background(), []schema.Message{schema.UserMessage("?")})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	var pe *PanicError
+	if !errors.As(got, &pe) || pe.Tool != "boom" || pe.Value != "kaboom" || len(pe.Stack) == 0 {
+		t.Errorf("OnToolError err = %#v", got)
+	}
+	if text := res.Messages[2].Text(); text != `error: agent: tool "boom" panicked: kaboom` {
+		t.Errorf("tool result = %q", text)
+	}
+	if text := res.Messages[3].Text(); !strings.Contains(text, "Paris") {
+		t.Errorf("other tool result = %q", text)
+	}
+}
+
+func TestAgent_ValidateArguments(t *testing.T) {
+	t.Parallel()
+
+	model := &scriptedModel{responses: []schema.ChatResponse{
+		toolCallResponse(call("c1", "get_weather", `{"city":1}`)),
+		textResponse("ok"),
+	}}
+	tools, _ := NewRegistry(weatherTool(t))
+	a, _ := New(Config{Model: model, Tools: tools, ValidateArguments: true})
+
+	res, err := a.Run(context.Background(), []schema.Message{schema.UserMessage("hi")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"error":"arguments do not match the tool's parameters schema","errors":[{"path":"$.city","message":"expected string, got integer"}]}`
+	if got := res.Messages[2].Text(); got != want {
+		t.Errorf("tool result = %s, want %s", got, want)
+	}
+}
+
+func TestAgent_MaxIterations(t *testing.T) {
+	t.Parallel()
+
+	model := &scriptedModel{responses: []schema.ChatResponse{
+		toolCallResponse(call("c1", "get_weather", `{"city":"a"}`)),
+		toolCallResponse(call("c2", "get_weather", `{"city":"b"}`)),
+		toolCallResponse(call("c3", "get_weather", `{"city":"c"}`)),
+	}}
+	reg, _ := NewRegistry(weatherTool(t))
+	a, _ := New(Config{Model: model, Tools: reg, MaxIterations: 2})
+
+	res, err := a.Run(context.Background(), []schema.Message{schema.UserMessage("?")})
+	if !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("err = %v, want ErrMaxIterations", err)
+	}
+	if res.Iterations != 2 || len(res.Messages) != 5 {
+		t.Errorf("Iterations = %d, len(Messages) = %d", res.Iterations, len(res.Messages))
+	}
+}
+
+func TestAgent_Parallelism(t *testing.T) {
+	t.Parallel()
+
+	var running, peak atomic.Int32
+	slow := MustFunc("slow", "", func(context.Context, struct{}) (string, error) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		return "ok", nil
+	})
+
+	calls := make([]schema.ToolCall, 6)
+	for i := range calls {
+		calls[i] = call(string(rune('a'+i)), "slow", `{}`)
+	}
+	model := &scriptedModel{responses: []schema.ChatResponse{toolCallResponse(calls...), textResponse("done")}}
+	reg, _ := NewRegistry(slow)
+	a, _ := New(Config{Model: model, Tools: reg, Parallelism: 2})
+
+	res, err := a.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := peak.Load(); p != 2 {
+		t.Errorf("peak concurrency = %d, want 2", p)
+	}
+	for i, c := range calls {
+		if res.Messages[1+i].ToolCallID != c.ID {
+			t.Errorf("result %d ToolCallID = %q, want %q", i, res.Messages[1+i].ToolCallID, c.ID)
+		}
+	}
+}
+
+func TestRegistry_Duplicate(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewRegistry(weatherTool(t), weatherTool(t)); err == nil {
+		t.Error("NewRegistry() with duplicate names should fail")
+	}
+}
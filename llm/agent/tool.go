@@ -0,0 +1,186 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// Tool 可被 Agent 调用的工具
+type Tool interface {
+	// Definition 返回发送给模型的工具定义
+	Definition() schema.Tool
+	// Call 以模型生成的 JSON 参数调用工具，返回作为工具结果消息内容的字符串
+	Call(ctx context.Context, arguments string) (string, error)
+}
+
+// Func 将形如 func(ctx, Args) (Result, error) 的 Go 函数绑定为工具。
+//
+// Args 须为结构体（或结构体指针）或 map，参数 JSON Schema 由 schema.NewFunctionToolFor 反射生成，
+// 支持 description、enum、min / max 等结构体标签。
+// Result 为 string 时原样作为工具结果，否则编码为 JSON。
+func Func[Args, Result any](name, description string, fn func(ctx context.Context, args Args) (Result, error)) (Tool, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("agent: tool %q: function is nil", name)
+	}
+	def, err := schema.NewFunctionToolFor[Args](name, description)
+	if err != nil {
+		return nil, fmt.Errorf("agent: tool %q: %w", name, err)
+	}
+	return &funcTool[Args, Result]{def: def, fn: fn}, nil
+}
+
+// MustFunc 同 Func，出错时 panic，适用于包级变量初始化
+func MustFunc[Args, Result any](name, description string, fn func(ctx context.Context, args Args) (Result, error)) Tool {
+	t, err := Func(name, description, fn)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+type funcTool[Args, Result any] struct {
+	def schema.Tool
+	fn  func(context.Context, Args) (Result, error)
+}
+
+func (t *funcTool[Args, Result]) Definition() schema.Tool { return t.def }
+
+func (t *funcTool[Args, Result]) Call(ctx context.Context, arguments string) (string, error) {
+	var args Args
+	if s := strings.TrimSpace(arguments); s != "" && s != "null" {
+		if err := json.Unmarshal([]byte(s), &args); err != nil {
+			return "", &ArgumentsError{Tool: t.def.Function.Name, Arguments: arguments, Err: err}
+		}
+	}
+
+	res, err := t.fn(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	if s, ok := any(res).(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(res)
+	if err != nil {
+		return "", fmt.Errorf("agent: tool %q: marshal result: %w", t.def.Function.Name, err)
+	}
+	return string(b), nil
+}
+
+// ArgumentsError 模型生成的工具参数无法解码
+type ArgumentsError struct {
+	Tool      string
+	Arguments string
+	Err       error
+}
+
+func (e *ArgumentsError) Error() string {
+	return fmt.Sprintf("agent: tool %q: invalid arguments: %v", e.Tool, e.Err)
+}
+
+func (e *ArgumentsError) Unwrap() error { return e.Err }
+
+// UnknownToolError 模型调用了未注册的工具
+type UnknownToolError struct {
+	Name string
+}
+
+func (e *UnknownToolError) Error() string {
+	return fmt.Sprintf("agent: unknown tool %q", e.Name)
+}
+
+// PanicError 工具执行时发生 panic，与其他工具错误一样交给 OnToolError 处理
+type PanicError struct {
+	Tool  string
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("agent: tool %q panicked: %v", e.Tool, e.Value)
+}
+
+// Registry 按名称管理工具，可并发使用
+type Registry struct {
+	mu    sync.RWMutex
+	tools map[string]Tool
+	order []string
+}
+
+// NewRegistry 创建注册表并注册 tools
+func NewRegistry(tools ...Tool) (*Registry, error) {
+	r := &Registry{}
+	if err := r.Register(tools...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Register 注册工具，名称为空或重复时返回错误
+func (r *Registry) Register(tools ...Tool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tools == nil {
+		r.tools = make(map[string]Tool, len(tools))
+	}
+	for _, t := range tools {
+		if t == nil {
+			return fmt.Errorf("agent: nil tool")
+		}
+		name := t.Definition().Function.Name
+		if name == "" {
+			return fmt.Errorf("agent: tool name required")
+		}
+		if _, dup := r.tools[name]; dup {
+			return fmt.Errorf("agent: tool %q registered twice", name)
+		}
+		r.tools[name] = t
+		r.order = append(r.order, name)
+	}
+	return nil
+}
+
+// Lookup 按名称查找工具
+func (r *Registry) Lookup(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tools[name]
+	return t, ok
+}
+
+// Definitions 按注册顺序返回全部工具定义，用于 llm.WithTools
+func (r *Registry) Definitions() []schema.Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]schema.Tool, 0, len(r.order))
+	for _, name := range r.order {
+		out = append(out, r.tools[name].Definition())
+	}
+	return out
+}
+
+// Names 按注册顺序返回工具名称
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.order)
+}
+
+// Call 按 ToolCall 的函数名分派调用
+func (r *Registry) Call(ctx context.Context, call schema.ToolCall) (string, error) {
+	t, ok := r.Lookup(call.Function.Name)
+	if !ok {
+		return "", &UnknownToolError{Name: call.Function.Name}
+	}
+	return t.Call(ctx, call.Function.Arguments)
+}
@@ -8,6 +8,9 @@ import (
 	"time"
 )
 
+// statusOverloaded 非标准状态码，Anthropic 在服务过载时返回
+const statusOverloaded = 529
+
 // APIError API 错误，用于非 2xx 响应
 //
 // 设计用于企业级处理：分类（限流、认证）、可观测性（请求追踪）、重试（retry-after）
@@ -114,6 +117,8 @@ func IsTemporary(err error) bool {
 		return true
 	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
 		return true
+	case statusOverloaded:
+		return true
 	default:
 		return false
 	}
@@ -0,0 +1,314 @@
+// Package cache 缓存 ChatModel 与 Embedder 的响应。
+//
+// 缓存键为请求中影响输出的字段（消息、模型、采样参数、工具等）规范编码后的哈希；
+// 存储可插拔，内置内存 LRU（NewLRU）与磁盘（NewDisk）两种实现。
+// Embed 按单个输入缓存，ChatStream 命中时以合成的 Stream 回放缓存的响应。
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// DefaultMaxEntries 未设置 Config.Store 时内存 LRU 的容量
+const DefaultMaxEntries = 1000
+
+// ExtraFields 中的缓存标记键
+const (
+	// ExtraCacheHit 响应来自缓存时为 true（ChatResponse / 回放流的首个事件）
+	ExtraCacheHit = "cache_hit"
+	// ExtraCacheHits EmbeddingResponse 中命中缓存的输入数（int）
+	ExtraCacheHits = "cache_hits"
+)
+
+// ErrNoModel 请求未通过 WithModel 指定模型且未设置 Config.Namespace，无法区分默认模型不同的客户端，因此不缓存
+var ErrNoModel = errors.New("cache: no model in request and no namespace")
+
+type Config struct {
+	// Store 缓存存储，默认 NewLRU(DefaultMaxEntries)
+	Store Store
+
+	// TTL 条目的有效期，0 表示不过期
+	TTL time.Duration
+
+	// Namespace 参与缓存键计算，用于隔离不同用途或使旧条目整体失效。
+	// 缓存键只包含请求中的模型；依赖客户端默认模型（未调用 WithModel）的请求须设置 Namespace 才会缓存，
+	// 且同一 Cache 不应被默认模型不同的客户端共用
+	Namespace string
+
+	// Force 缓存 Temperature > 0 的请求。默认不缓存，因为相同请求本应得到不同的输出；
+	// 未设置 Temperature 的请求按确定性请求处理
+	Force bool
+
+	// OnError 存储读写或编解码出错、或请求因 ErrNoModel 不缓存时调用，默认忽略；出错时按未命中处理，不影响请求
+	OnError func(err error)
+}
+
+// Cache 响应缓存，可并发使用
+type Cache struct {
+	cfg Config
+}
+
+func New(cfg Config) *Cache {
+	if cfg.Store == nil {
+		cfg.Store = NewLRU(DefaultMaxEntries)
+	}
+	return &Cache{cfg: cfg}
+}
+
+type ctxKey int
+
+const (
+	forceKey ctxKey = iota
+	bypassKey
+)
+
+// WithForce 使 ctx 中的请求在 Temperature > 0 时也读写缓存
+func WithForce(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceKey, true)
+}
+
+// WithBypass 使 ctx 中的请求跳过缓存（既不读取也不写入）
+func WithBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey, true)
+}
+
+// Wrap 返回带缓存的 ChatModel，等价于 llm.Chain(model, c.Middleware())
+func (c *Cache) Wrap(model llm.ChatModel) llm.ChatModel {
+	return llm.Chain(model, c.Middleware())
+}
+
+// WrapEmbedder 返回带缓存的 Embedder，等价于 llm.ChainEmbedder(e, c.EmbedderMiddleware())
+func (c *Cache) WrapEmbedder(e llm.Embedder) llm.Embedder {
+	return llm.ChainEmbedder(e, c.EmbedderMiddleware())
+}
+
+// Middleware 返回缓存 chat 响应的中间件。
+//
+// Chat 与 ChatStream 共用缓存条目：流正常读取到结尾后，累积的响应写入缓存；
+// ChatStream 命中时按 choice 回放为 delta 与 done 事件。出错的响应不会缓存。
+func (c *Cache) Middleware() llm.ChatMiddleware {
+	return llm.ChatMiddlewareFuncs{
+		Chat: func(ctx context.Context, next llm.ChatModel, messages []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+			key, ok := c.chatKey(ctx, next, messages, opts)
+			if !ok {
+				return next.Chat(ctx, messages, opts...)
+			}
+			if resp, ok := c.load(ctx, key); ok {
+				markHit(&resp.ExtraFields)
+				return resp, nil
+			}
+
+			resp, err := next.Chat(ctx, messages, opts...)
+			if err == nil && len(resp.Choices) > 0 {
+				c.save(ctx, key, resp)
+			}
+			return resp, err
+		},
+		ChatStream: func(ctx context.Context, next llm.ChatModel, messages []schema.Message, opts ...llm.ChatOption) (llm.Stream, error) {
+			key, ok := c.chatKey(ctx, next, messages, opts)
+			if !ok {
+				return next.ChatStream(ctx, messages, opts...)
+			}
+			if resp, ok := c.load(ctx, key); ok {
+				return Replay(resp), nil
+			}
+
+			stream, err := next.ChatStream(ctx, messages, opts...)
+			if err != nil {
+				return nil, err
+			}
+			var acc llm.Accumulator
+			return llm.WrapStream(stream, llm.StreamHooks{
+				OnEvent: func(ev *schema.StreamEvent) error {
+					acc.Add(*ev)
+					return nil
+				},
+				OnEnd: func(err error) {
+					if resp := acc.Response(); err == nil && len(resp.Choices) > 0 {
+						c.save(context.WithoutCancel(ctx), key, resp)
+					}
+				},
+			}), nil
+		},
+	}.Middleware()
+}
+
+// EmbedderMiddleware 返回按单个输入缓存向量的中间件：只对未命中的输入（去重后）请求 next，
+// 返回的 Data 按输入顺序排列，Usage 为本次实际请求的用量
+func (c *Cache) EmbedderMiddleware() llm.EmbedderMiddleware {
+	return func(next llm.Embedder) llm.Embedder {
+		return llm.EmbedderFunc(func(ctx context.Context, inputs []string, opts ...llm.EmbeddingOption) (schema.EmbeddingResponse, error) {
+			if bypassed(ctx) {
+				return next.Embed(ctx, inputs, opts...)
+			}
+			return c.embed(ctx, next, inputs, opts)
+		})
+	}
+}
+
+func (c *Cache) embed(ctx context.Context, next llm.Embedder, inputs []string, opts []llm.EmbeddingOption) (schema.EmbeddingResponse, error) {
+	cfg := llm.ApplyEmbeddingOptions(opts...)
+	if cfg.Model == "" && c.cfg.Namespace == "" {
+		c.report(ErrNoModel)
+		return next.Embed(ctx, inputs, opts...)
+	}
+	provider := llm.ProviderOfEmbedder(next)
+
+	vectors := make([][]float64, len(inputs))
+	keys := make([]string, len(inputs))
+	var (
+		missing []string             // 去重后未命中的输入
+		pending = map[string][]int{} // 未命中输入 -> 原始下标
+	)
+	for i, in := range inputs {
+		key, err := EmbeddingKey(c.cfg.Namespace, provider, in, cfg)
+		if err != nil {
+			c.report(err)
+			return next.Embed(ctx, inputs, opts...)
+		}
+		keys[i] = key
+		if v, ok := c.loadVector(ctx, key); ok {
+			vectors[i] = v
+			continue
+		}
+		if _, dup := pending[in]; !dup {
+			missing = append(missing, in)
+		}
+		pending[in] = append(pending[in], i)
+	}
+
+	out := schema.EmbeddingResponse{Model: cfg.Model}
+	if len(missing) > 0 {
+		resp, err := next.Embed(ctx, missing, opts...)
+		if err != nil {
+			return resp, err
+		}
+		got := make([][]float64, len(missing))
+		for _, e := range resp.Data {
+			if e.Index < 0 || e.Index >= len(missing) {
+				return resp, fmt.Errorf("cache: embedding index %d out of range", e.Index)
+			}
+			got[e.Index] = e.Vector
+		}
+		for j, in := range missing {
+			if got[j] == nil {
+				return resp, fmt.Errorf("cache: embedding response missing index %d", j)
+			}
+			idx := pending[in]
+			for _, i := range idx {
+				vectors[i] = got[j]
+			}
+			c.save(ctx, keys[idx[0]], got[j])
+		}
+		out = resp
+		if out.Model == "" {
+			out.Model = cfg.Model
+		}
+	}
+
+	hits := len(inputs)
+	for _, idx := range pending {
+		hits -= len(idx)
+	}
+	out.Data = make([]schema.Embedding, len(inputs))
+	for i, v := range vectors {
+		out.Data[i] = schema.Embedding{Index: i, Vector: v}
+	}
+	if hits > 0 {
+		if out.ExtraFields == nil {
+			out.ExtraFields = make(map[string]any, 1)
+		}
+		out.ExtraFields[ExtraCacheHits] = hits
+	}
+	return out, nil
+}
+
+// chatKey 返回请求的缓存键；请求不应缓存时 ok 为 false
+func (c *Cache) chatKey(ctx context.Context, next llm.ChatModel, messages []schema.Message, opts []llm.ChatOption) (string, bool) {
+	if bypassed(ctx) {
+		return "", false
+	}
+	cfg := llm.ApplyChatOptions(opts...)
+	if t := cfg.Temperature; t != nil && *t > 0 && !c.cfg.Force && ctx.Value(forceKey) != true {
+		return "", false
+	}
+	if cfg.Model == "" && c.cfg.Namespace == "" {
+		c.report(ErrNoModel)
+		return "", false
+	}
+	key, err := ChatKey(c.cfg.Namespace, llm.ProviderOf(next), messages, cfg)
+	if err != nil {
+		c.report(err)
+		return "", false
+	}
+	return key, true
+}
+
+func (c *Cache) load(ctx context.Context, key string) (schema.ChatResponse, bool) {
+	var resp schema.ChatResponse
+	b, ok, err := c.cfg.Store.Get(ctx, key)
+	if err != nil {
+		c.report(err)
+		return resp, false
+	}
+	if !ok {
+		return resp, false
+	}
+	if err := json.Unmarshal(b, &resp); err != nil {
+		c.report(fmt.Errorf("cache: decode %s: %w", key, err))
+		return resp, false
+	}
+	return resp, true
+}
+
+func (c *Cache) loadVector(ctx context.Context, key string) ([]float64, bool) {
+	b, ok, err := c.cfg.Store.Get(ctx, key)
+	if err != nil {
+		c.report(err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	var v []float64
+	if err := json.Unmarshal(b, &v); err != nil || v == nil {
+		c.report(fmt.Errorf("cache: decode %s: %v", key, err))
+		return nil, false
+	}
+	return v, true
+}
+
+func (c *Cache) save(ctx context.Context, key string, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		c.report(fmt.Errorf("cache: encode %s: %w", key, err))
+		return
+	}
+	if err := c.cfg.Store.Set(ctx, key, b, c.cfg.TTL); err != nil {
+		c.report(err)
+	}
+}
+
+func (c *Cache) report(err error) {
+	if c.cfg.OnError != nil {
+		c.cfg.OnError(err)
+	}
+}
+
+func bypassed(ctx context.Context) bool {
+	return ctx.Value(bypassKey) == true
+}
+
+func markHit(extra *map[string]any) {
+	if *extra == nil {
+		*extra = make(map[string]any, 1)
+	}
+	(*extra)[ExtraCacheHit] = true
+}
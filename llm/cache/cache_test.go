@@ -0,0 +1,326 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/cache"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// countingModel 回复固定内容并统计调用次数
+type countingModel struct {
+	calls int
+	resp  schema.ChatResponse
+}
+
+func (m *countingModel) Provider() llm.Provider { return llm.ProviderOpenAI }
+
+func (m *countingModel) Chat(context.Context, []schema.Message, ...llm.ChatOption) (schema.ChatResponse, error) {
+	m.calls++
+	return m.resp, nil
+}
+
+func (m *countingModel) ChatStream(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (llm.Stream, error) {
+	m.calls++
+	return cache.Replay(m.resp), nil
+}
+
+func newModel() *countingModel {
+	idx := 0
+	return &countingModel{resp: schema.ChatResponse{
+		ID:    "resp-1",
+		Model: "gpt-4o",
+		Choices: []schema.Choice{{
+			Message: schema.Message{
+				Role:             schema.RoleAssistant,
+				Content:          []schema.ContentPart{schema.TextContent{Text: "hello"}},
+				ReasoningContent: "think",
+				ToolCalls: []schema.ToolCall{{
+					Index: &idx, ID: "call_1", Type: schema.ToolCallTypeFunction,
+					Function: schema.ToolFunction{Name: "f", Arguments: `{"x":1}`},
+				}},
+			},
+			FinishReason: schema.FinishReasonToolCalls,
+		}},
+		Usage: schema.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+	}}
+}
+
+func TestCache_Chat(t *testing.T) {
+	t.Parallel()
+
+	base := newModel()
+	model := cache.New(cache.Config{}).Wrap(base)
+	ctx := context.Background()
+	msgs := []schema.Message{schema.UserMessage("hi")}
+
+	first, err := model.Chat(ctx, msgs, llm.WithModel("gpt-4o"))
+	if err != nil || first.ExtraFields[cache.ExtraCacheHit] != nil {
+		t.Fatalf("first = %+v, %v", first, err)
+	}
+	second, err := model.Chat(ctx, msgs, llm.WithModel("gpt-4o"), llm.WithUser("alice"))
+	if err != nil || base.calls != 1 || second.ExtraFields[cache.ExtraCacheHit] != true {
+		t.Fatalf("second = %+v, %v, calls = %d", second, err, base.calls)
+	}
+	if second.ID != "resp-1" || second.Choices[0].Message.Text() != "hello" || second.Usage.TotalTokens != 5 {
+		t.Errorf("cached response = %+v", second)
+	}
+	if llm.ProviderOf(model) != llm.ProviderOpenAI {
+		t.Errorf("ProviderOf = %s", llm.ProviderOf(model))
+	}
+
+	// 影响输出的字段不同则不命中
+	for _, opts := range [][]llm.ChatOption{
+		{llm.WithModel("gpt-4o-mini")},
+		{llm.WithModel("gpt-4o"), llm.WithMaxTokens(10)},
+		{llm.WithModel("gpt-4o"), llm.WithExtraField("reasoning_effort", "low")},
+	} {
+		if _, err := model.Chat(ctx, msgs, opts...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if base.calls != 4 {
+		t.Errorf("calls = %d, want 4", base.calls)
+	}
+}
+
+func TestCache_Temperature(t *testing.T) {
+	t.Parallel()
+
+	base := newModel()
+	model := cache.New(cache.Config{}).Wrap(base)
+	ctx := context.Background()
+
+	for range 2 {
+		_, _ = model.Chat(ctx, nil, llm.WithModel("gpt-4o"), llm.WithTemperature(0.7))
+	}
+	if base.calls != 2 {
+		t.Errorf("Temperature > 0 calls = %d, want 2", base.calls)
+	}
+
+	for range 2 {
+		_, _ = model.Chat(cache.WithForce(ctx), nil, llm.WithModel("gpt-4o"), llm.WithTemperature(0.7))
+	}
+	if base.calls != 3 {
+		t.Errorf("forced calls = %d, want 3", base.calls)
+	}
+
+	for range 2 {
+		_, _ = model.Chat(cache.WithBypass(ctx), nil, llm.WithModel("gpt-4o"), llm.WithTemperature(0))
+	}
+	if base.calls != 5 {
+		t.Errorf("bypass calls = %d, want 5", base.calls)
+	}
+}
+
+func TestCache_Stream(t *testing.T) {
+	t.Parallel()
+
+	base := newModel()
+	model := cache.New(cache.Config{}).Wrap(base)
+	ctx := context.Background()
+	gpt4o := llm.WithModel("gpt-4o")
+
+	// 流读取完毕后写入缓存，Chat 与 ChatStream 共用条目
+	stream, err := model.ChatStream(ctx, nil, gpt4o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamed, err := llm.Accumulate(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := model.Chat(ctx, nil, gpt4o)
+	if err != nil || base.calls != 1 {
+		t.Fatalf("Chat() err = %v, calls = %d", err, base.calls)
+	}
+
+	stream, err = model.ChatStream(ctx, nil, gpt4o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := llm.Accumulate(stream)
+	if err != nil || base.calls != 1 {
+		t.Fatalf("replay err = %v, calls = %d", err, base.calls)
+	}
+	if !reflect.DeepEqual(replayed.Choices, streamed.Choices) || replayed.Usage != streamed.Usage {
+		t.Errorf("replayed = %+v\nwant %+v", replayed, streamed)
+	}
+	if replayed.ExtraFields[cache.ExtraCacheHit] != true || resp.Choices[0].Message.ToolCalls[0].Function.Arguments != `{"x":1}` {
+		t.Errorf("replayed extra = %v, chat = %+v", replayed.ExtraFields, resp)
+	}
+}
+
+func TestCache_StreamClosedEarly(t *testing.T) {
+	t.Parallel()
+
+	base := newModel()
+	model := cache.New(cache.Config{}).Wrap(base)
+
+	stream, _ := model.ChatStream(context.Background(), nil, llm.WithModel("gpt-4o"))
+	_, _ = stream.Recv()
+	_ = stream.Close()
+	_, _ = model.Chat(context.Background(), nil, llm.WithModel("gpt-4o"))
+	if base.calls != 2 {
+		t.Errorf("partial stream should not be cached, calls = %d", base.calls)
+	}
+}
+
+func TestCache_Embed(t *testing.T) {
+	t.Parallel()
+
+	var requested [][]string
+	base := llm.EmbedderFunc(func(_ context.Context, inputs []string, _ ...llm.EmbeddingOption) (schema.EmbeddingResponse, error) {
+		requested = append(requested, inputs)
+		resp := schema.EmbeddingResponse{Model: "emb", Usage: schema.Usage{PromptTokens: len(inputs)}}
+		// 倒序返回，验证按 Index 还原
+		for i := len(inputs) - 1; i >= 0; i-- {
+			resp.Data = append(resp.Data, schema.Embedding{Index: i, Vector: []float64{float64(len(inputs[i]))}})
+		}
+		return resp, nil
+	})
+	emb := cache.New(cache.Config{Namespace: "test"}).WrapEmbedder(base)
+	ctx := context.Background()
+
+	if _, err := emb.Embed(ctx, []string{"a", "bb"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := emb.Embed(ctx, []string{"bb", "ccc", "a", "ccc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requested) != 2 || !reflect.DeepEqual(requested[1], []string{"ccc"}) {
+		t.Errorf("requested = %v", requested)
+	}
+	var got []float64
+	for i, e := range resp.Data {
+		if e.Index != i {
+			t.Errorf("Data[%d].Index = %d", i, e.Index)
+		}
+		got = append(got, e.Vector[0])
+	}
+	if !reflect.DeepEqual(got, []float64{2, 3, 1, 3}) || resp.ExtraFields[cache.ExtraCacheHits] != 2 || resp.Usage.PromptTokens != 1 {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	// 全部命中时不请求
+	if _, err := emb.Embed(ctx, []string{"a", "ccc"}); err != nil || len(requested) != 2 {
+		t.Errorf("err = %v, requests = %d", err, len(requested))
+	}
+	// 不同模型不命中
+	if _, err := emb.Embed(ctx, []string{"a"}, llm.WithModel("other")); err != nil || len(requested) != 3 {
+		t.Errorf("err = %v, requests = %d", err, len(requested))
+	}
+}
+
+// TestCache_DefaultModel 两个客户端只有默认模型不同，未指定模型的请求不能互相命中
+func TestCache_DefaultModel(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	a, b := newModel(), newModel()
+	b.resp.Model = "gpt-4o-mini"
+
+	var errs []error
+	shared := cache.New(cache.Config{OnError: func(err error) { errs = append(errs, err) }})
+	for _, m := range []*countingModel{a, b, a, b} {
+		resp, err := shared.Wrap(m).Chat(ctx, nil)
+		if err != nil || resp.Model != m.resp.Model {
+			t.Fatalf("resp = %+v, %v", resp, err)
+		}
+	}
+	if a.calls != 2 || b.calls != 2 || len(errs) != 4 || !errors.Is(errs[0], cache.ErrNoModel) {
+		t.Errorf("calls = %d/%d, errs = %v", a.calls, b.calls, errs)
+	}
+
+	var embedded []string
+	embedder := func(model string) llm.Embedder {
+		return llm.EmbedderFunc(func(context.Context, []string, ...llm.EmbeddingOption) (schema.EmbeddingResponse, error) {
+			embedded = append(embedded, model)
+			return schema.EmbeddingResponse{Model: model, Data: []schema.Embedding{{Vector: []float64{1}}}}, nil
+		})
+	}
+	for _, m := range []string{"emb-a", "emb-b"} {
+		if resp, err := shared.WrapEmbedder(embedder(m)).Embed(ctx, []string{"x"}); err != nil || resp.Model != m {
+			t.Fatalf("resp = %+v, %v", resp, err)
+		}
+	}
+	if len(embedded) != 2 {
+		t.Errorf("embedded = %v", embedded)
+	}
+
+	// 每个客户端使用各自的 Namespace 时可共用存储
+	store := cache.NewLRU(0)
+	a.calls, b.calls = 0, 0
+	for _, c := range []struct {
+		ns string
+		m  *countingModel
+	}{{"a", a}, {"b", b}, {"a", a}, {"b", b}} {
+		resp, err := cache.New(cache.Config{Store: store, Namespace: c.ns}).Wrap(c.m).Chat(ctx, nil)
+		if err != nil || resp.Model != c.m.resp.Model {
+			t.Fatalf("resp = %+v, %v", resp, err)
+		}
+	}
+	if a.calls != 1 || b.calls != 1 {
+		t.Errorf("namespaced calls = %d/%d, want 1/1", a.calls, b.calls)
+	}
+}
+
+type failingStore struct{ cache.Store }
+
+func (failingStore) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("store down")
+}
+
+func TestCache_StoreError(t *testing.T) {
+	t.Parallel()
+
+	var errs []error
+	base := newModel()
+	model := cache.New(cache.Config{
+		Store:   failingStore{cache.NewLRU(0)},
+		OnError: func(err error) { errs = append(errs, err) },
+	}).Wrap(base)
+
+	for range 2 {
+		if _, err := model.Chat(context.Background(), nil, llm.WithModel("gpt-4o")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if base.calls != 2 || len(errs) != 2 {
+		t.Errorf("calls = %d, errs = %v", base.calls, errs)
+	}
+}
+
+func TestChatKey(t *testing.T) {
+	t.Parallel()
+
+	msgs := []schema.Message{schema.SystemMessage("s"), schema.UserMessage("u")}
+	k1, _ := cache.ChatKey("", llm.ProviderOpenAI, msgs, llm.ApplyChatOptions(
+		llm.WithExtraFields(map[string]any{"a": 1, "b": 2}), llm.WithTimeout(1), llm.WithHeader("X", "1")))
+	k2, _ := cache.ChatKey("", llm.ProviderOpenAI, msgs, llm.ApplyChatOptions(
+		llm.WithExtraFields(map[string]any{"b": 2, "a": 1})))
+	if k1 != k2 || len(k1) != 64 {
+		t.Errorf("keys differ: %s %s", k1, k2)
+	}
+	for _, k := range []string{
+		must(cache.ChatKey("v2", llm.ProviderOpenAI, msgs, llm.ChatConfig{})),
+		must(cache.ChatKey("", llm.ProviderDeepSeek, msgs, llm.ChatConfig{})),
+		must(cache.ChatKey("", llm.ProviderOpenAI, msgs[1:], llm.ChatConfig{})),
+	} {
+		if k == k1 {
+			t.Errorf("key %s should differ", k)
+		}
+	}
+}
+
+func must(s string, err error) string {
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
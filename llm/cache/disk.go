@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Disk 以文件保存缓存条目的存储，适合在多次运行（如 CI、评测）之间复用。
+//
+// 每个条目保存为 <dir>/<key 前两位>/<key>.json，写入时先写临时文件再重命名，可被多个进程共享。
+type Disk struct {
+	dir string
+	now func() time.Time
+}
+
+type diskEntry struct {
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// NewDisk 创建以 dir 为根目录的存储，目录不存在时自动创建
+func NewDisk(dir string) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cache: %w", err)
+	}
+	return &Disk{dir: dir, now: time.Now}, nil
+}
+
+func (d *Disk) Get(_ context.Context, key string) ([]byte, bool, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, false, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("cache: %w", err)
+	}
+
+	var e diskEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		// 损坏的条目（如写入中断）视为未命中
+		_ = os.Remove(path)
+		return nil, false, nil
+	}
+	if e.ExpiresAt != nil && !d.now().Before(*e.ExpiresAt) {
+		_ = os.Remove(path)
+		return nil, false, nil
+	}
+	return e.Value, true, nil
+}
+
+// Set 写入 key；value 须为合法 JSON（Cache 写入的值均为 JSON）
+func (d *Disk) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	e := diskEntry{Value: value}
+	if ttl > 0 {
+		t := d.now().Add(ttl).UTC()
+		e.ExpiresAt = &t
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("cache: %w", err)
+	}
+	return nil
+}
+
+func (d *Disk) Delete(_ context.Context, key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("cache: %w", err)
+	}
+	return nil
+}
+
+func (d *Disk) path(key string) (string, error) {
+	if len(key) < 2 || !filepath.IsLocal(key) || filepath.Base(key) != key {
+		return "", fmt.Errorf("cache: invalid key %q", key)
+	}
+	return filepath.Join(d.dir, key[:2], key+".json"), nil
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// keyVersion 缓存键格式的版本，键包含的字段变化时递增，使旧条目失效
+const keyVersion = 1
+
+// chatKey 参与缓存键计算的 chat 请求字段。
+// 不包含 User、Metadata、StreamOptions 以及超时、请求头、钩子等客户端配置，它们不影响模型输出
+type chatKey struct {
+	Version   int              `json:"v"`
+	Namespace string           `json:"ns,omitempty"`
+	Provider  llm.Provider     `json:"provider"`
+	Messages  []schema.Message `json:"messages"`
+
+	Model               string                 `json:"model,omitempty"`
+	Temperature         *float64               `json:"temperature,omitempty"`
+	TopP                *float64               `json:"top_p,omitempty"`
+	MaxTokens           *int                   `json:"max_tokens,omitempty"`
+	MaxCompletionTokens *int                   `json:"max_completion_tokens,omitempty"`
+	Stop                *[]string              `json:"stop,omitempty"`
+	FrequencyPenalty    *float64               `json:"frequency_penalty,omitempty"`
+	PresencePenalty     *float64               `json:"presence_penalty,omitempty"`
+	Logprobs            *bool                  `json:"logprobs,omitempty"`
+	TopLogprobs         *int                   `json:"top_logprobs,omitempty"`
+	Tools               []schema.Tool          `json:"tools,omitempty"`
+	ToolChoice          *schema.ToolChoice     `json:"tool_choice,omitempty"`
+	ParallelToolCalls   *bool                  `json:"parallel_tool_calls,omitempty"`
+	ResponseFormat      *schema.ResponseFormat `json:"response_format,omitempty"`
+	N                   *int                   `json:"n,omitempty"`
+	Seed                *int                   `json:"seed,omitempty"`
+	LogitBias           map[string]int         `json:"logit_bias,omitempty"`
+	ServiceTier         *string                `json:"service_tier,omitempty"`
+	ExtraFields         map[string]any         `json:"extra_fields,omitempty"`
+}
+
+// embedKey 参与缓存键计算的单个 embedding 输入字段
+type embedKey struct {
+	Version     int            `json:"v"`
+	Namespace   string         `json:"ns,omitempty"`
+	Provider    llm.Provider   `json:"provider"`
+	Model       string         `json:"model,omitempty"`
+	ExtraFields map[string]any `json:"extra_fields,omitempty"`
+	Input       string         `json:"input"`
+}
+
+// ChatKey 返回 chat 请求的缓存键：请求中影响输出的字段的规范 JSON 编码（map 按键排序）的 SHA-256
+func ChatKey(namespace string, provider llm.Provider, messages []schema.Message, cfg llm.ChatConfig) (string, error) {
+	return hashKey(chatKey{
+		Version:             keyVersion,
+		Namespace:           namespace,
+		Provider:            provider,
+		Messages:            messages,
+		Model:               cfg.Model,
+		Temperature:         cfg.Temperature,
+		TopP:                cfg.TopP,
+		MaxTokens:           cfg.MaxTokens,
+		MaxCompletionTokens: cfg.MaxCompletionTokens,
+		Stop:                cfg.Stop,
+		FrequencyPenalty:    cfg.FrequencyPenalty,
+		PresencePenalty:     cfg.PresencePenalty,
+		Logprobs:            cfg.Logprobs,
+		TopLogprobs:         cfg.TopLogprobs,
+		Tools:               cfg.Tools,
+		ToolChoice:          cfg.ToolChoice,
+		ParallelToolCalls:   cfg.ParallelToolCalls,
+		ResponseFormat:      cfg.ResponseFormat,
+		N:                   cfg.N,
+		Seed:                cfg.Seed,
+		LogitBias:           cfg.LogitBias,
+		ServiceTier:         cfg.ServiceTier,
+		ExtraFields:         cfg.ExtraFields,
+	})
+}
+
+// EmbeddingKey 返回单个 embedding 输入的缓存键
+func EmbeddingKey(namespace string, provider llm.Provider, input string, cfg llm.EmbeddingConfig) (string, error) {
+	return hashKey(embedKey{
+		Version:     keyVersion,
+		Namespace:   namespace,
+		Provider:    provider,
+		Model:       cfg.Model,
+		ExtraFields: cfg.ExtraFields,
+		Input:       input,
+	})
+}
+
+func hashKey(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("cache: key: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,16 @@
+package cache
+
+import (
+	"maps"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// Replay 将完整的响应回放为 Stream，事件的拆分方式见 llm.ResponseEvents（不按长度拆分）。
+// 首个事件的 ExtraFields 为响应的 ExtraFields 并带有 ExtraCacheHit，llm.Accumulate 可还原出等价的响应
+func Replay(resp schema.ChatResponse) llm.Stream {
+	resp.ExtraFields = maps.Clone(resp.ExtraFields)
+	markHit(&resp.ExtraFields)
+	return llm.ResponseStream(resp, 0)
+}
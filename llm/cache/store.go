@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Store 缓存存储，实现须可并发使用
+type Store interface {
+	// Get 返回 key 对应的值；不存在或已过期时 ok 为 false
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set 写入 key，ttl <= 0 表示不过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除 key，不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+}
+
+// LRU 内存中的 LRU 缓存，超过容量时淘汰最久未使用的条目
+type LRU struct {
+	max int
+	now func() time.Time
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRU 创建最多保存 maxEntries 个条目的 LRU，maxEntries <= 0 表示不限制
+func NewLRU(maxEntries int) *LRU {
+	return &LRU{max: maxEntries, now: time.Now, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*lruEntry)
+	if !e.expires.IsZero() && !c.now().Before(e.expires) {
+		c.remove(el)
+		return nil, false, nil
+	}
+	c.ll.MoveToFront(el)
+	return e.value, true, nil
+}
+
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.now().Add(ttl)
+	}
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.value, e.expires = value, expires
+		c.ll.MoveToFront(el)
+		return nil
+	}
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	if c.max > 0 && c.ll.Len() > c.max {
+		c.remove(c.ll.Back())
+	}
+	return nil
+}
+
+func (c *LRU) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	return nil
+}
+
+// Len 返回条目数（含尚未清理的过期条目）
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*lruEntry).key)
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Unix(0, 0)
+	c := NewLRU(2)
+	c.now = func() time.Time { return now }
+
+	_ = c.Set(ctx, "a", []byte("1"), 0)
+	_ = c.Set(ctx, "b", []byte("2"), time.Minute)
+	_, _, _ = c.Get(ctx, "a") // a 变为最近使用
+	_ = c.Set(ctx, "c", []byte("3"), 0)
+
+	if _, ok, _ := c.Get(ctx, "b"); ok {
+		t.Error("b should be evicted")
+	}
+	if v, ok, _ := c.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Errorf("a = %q, %v", v, ok)
+	}
+
+	_ = c.Set(ctx, "d", []byte("4"), time.Minute)
+	now = now.Add(time.Minute)
+	if _, ok, _ := c.Get(ctx, "d"); ok {
+		t.Error("d should be expired")
+	}
+	_ = c.Delete(ctx, "a")
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", c.Len())
+	}
+}
+
+func TestDisk(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	now := time.Unix(1000, 0)
+	d, err := NewDisk(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.now = func() time.Time { return now }
+
+	if err := d.Set(ctx, "abcd", []byte(`{"x":1}`), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ab", "abcd.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 另一个实例（如下次运行）可读取
+	d2, _ := NewDisk(dir)
+	d2.now = d.now
+	if v, ok, err := d2.Get(ctx, "abcd"); err != nil || !ok || string(v) != `{"x":1}` {
+		t.Errorf("Get() = %s, %v, %v", v, ok, err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, ok, _ := d.Get(ctx, "abcd"); ok {
+		t.Error("entry should be expired")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ab", "abcd.json")); !os.IsNotExist(err) {
+		t.Errorf("expired entry not removed: %v", err)
+	}
+
+	if err := d.Set(ctx, "../x", []byte(`1`), 0); err == nil {
+		t.Error("path traversal key should fail")
+	}
+	if err := d.Delete(ctx, "missing"); err != nil {
+		t.Errorf("Delete(missing) = %v", err)
+	}
+}
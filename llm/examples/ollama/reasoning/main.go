@@ -20,7 +20,7 @@ func main() {
 	// 使用 DeepSeek R1 推理模型
 	client, err := ollama.New(ollama.Config{
 		BaseConfig: ollama.BaseConfig{
-			BaseURL: "http://localhost:11434/v1",
+			BaseURL: "http://localhost:11434",
 		},
 		DefaultOptions: []llm.ChatOption{
 			llm.WithModel(modelName),
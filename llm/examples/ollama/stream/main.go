@@ -19,7 +19,7 @@ func main() {
 
 	baseURL := os.Getenv("BASE_URL")
 	if baseURL == "" {
-		baseURL = "http://localhost:11434/v1"
+		baseURL = "http://localhost:11434"
 	}
 
 	client, err := ollama.New(ollama.Config{
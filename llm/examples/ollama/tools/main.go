@@ -35,7 +35,7 @@ func main() {
 
 	baseURL := os.Getenv("BASE_URL")
 	if baseURL == "" {
-		baseURL = "http://localhost:11434/v1"
+		baseURL = "http://localhost:11434"
 	}
 
 	// 创建工具定义
@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"slices"
 	"strings"
 
+	"github.com/lgc202/go-kit/httpx"
 	"github.com/lgc202/go-kit/llm"
 	"github.com/lgc202/go-kit/llm/internal/openai_compat/transport"
 	"github.com/lgc202/go-kit/llm/schema"
@@ -29,10 +31,18 @@ type Config struct {
 	BaseURL string
 	Path    string
 
-	APIKey     string
-	HTTPClient *http.Client
+	APIKey string
+	// APIKeyHeader 见 transport.Config
+	APIKeyHeader string
+	HTTPClient   *http.Client
+
+	// HTTPX 与 HTTPXOptions 见 transport.Config
+	HTTPX        *httpx.Client
+	HTTPXOptions []httpx.Option
 
 	DefaultHeaders http.Header
+	// DefaultQuery 见 transport.Config
+	DefaultQuery url.Values
 
 	// DefaultOptions 客户端级别的默认请求选项
 	DefaultOptions []llm.ChatOption
@@ -55,8 +65,12 @@ func New(cfg Config) (*Client, error) {
 		Path:           cfg.Path,
 		DefaultPath:    DefaultPath,
 		APIKey:         cfg.APIKey,
+		APIKeyHeader:   cfg.APIKeyHeader,
 		HTTPClient:     cfg.HTTPClient,
+		HTTPX:          cfg.HTTPX,
+		HTTPXOptions:   cfg.HTTPXOptions,
 		DefaultHeaders: cfg.DefaultHeaders,
+		DefaultQuery:   cfg.DefaultQuery,
 	})
 	if err != nil {
 		return nil, err
@@ -102,12 +116,16 @@ func (c *Client) ChatStream(ctx context.Context, messages []schema.Message, opts
 		Timeout:    reqCfg.Timeout,
 		Headers:    reqCfg.Headers,
 		ErrorHooks: reqCfg.ErrorHooks,
+
+		Stream:            true,
+		StreamIdleTimeout: reqCfg.StreamIdleTimeout,
+		FirstTokenTimeout: reqCfg.FirstTokenTimeout,
 	}, httpAcceptSSE)
 	if err != nil {
 		return nil, err
 	}
 
-	return newStream(c.provider, resp.Body, reqCfg.KeepRaw, reqCfg.StreamEventHooks), nil
+	return transport.WatchStream(resp.Body, newStream(c.provider, resp.Body, reqCfg.KeepRaw, reqCfg.StreamEventHooks)), nil
 }
 
 func (c *Client) parseChatResponse(body io.Reader, cfg llm.ChatConfig) (schema.ChatResponse, error) {
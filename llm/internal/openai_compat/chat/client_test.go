@@ -3,10 +3,14 @@ package chat
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/lgc202/go-kit/llm"
 	"github.com/lgc202/go-kit/llm/schema"
@@ -118,3 +122,178 @@ func TestClient_BaseURLIsFullEndpoint(t *testing.T) {
 		t.Fatalf("request: model got %#v", gotReq["model"])
 	}
 }
+
+func TestStream_MidStreamError(t *testing.T) {
+	t.Parallel()
+
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			body := "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"  indented\"}}]}\n\n" +
+				"data: {\"error\":{\"message\":\"Rate limit reached\",\"type\":\"requests\",\"code\":\"rate_limit_exceeded\"}}\n\n"
+			h := make(http.Header)
+			h.Set("Content-Type", "text/event-stream")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header:     h,
+				Request:    r,
+			}, nil
+		}),
+	}
+
+	c, err := New(Config{
+		Provider:       llm.Provider("test"),
+		BaseURL:        "https://example.com/v1",
+		HTTPClient:     httpClient,
+		DefaultOptions: []llm.ChatOption{llm.WithModel("m")},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	ev, err := stream.Recv()
+	if err != nil || ev.Delta != "  indented" {
+		t.Fatalf("first Recv = %+v, %v (leading spaces must be preserved)", ev, err)
+	}
+	_, err = stream.Recv()
+	ae, ok := llm.AsAPIError(err)
+	if !ok || !llm.IsRateLimit(err) || ae.Provider != "test" || ae.Message != "Rate limit reached" {
+		t.Fatalf("second Recv err = %v", err)
+	}
+}
+
+// sseServer 按 script 写出流式响应，script 返回后保持连接直到客户端断开
+func sseServer(t *testing.T, script func(w http.ResponseWriter, send func(string))) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		send := func(s string) {
+			_, _ = io.WriteString(w, s)
+			w.(http.Flusher).Flush()
+		}
+		script(w, send)
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(Config{
+		Provider:       llm.Provider("test"),
+		BaseURL:        srv.URL,
+		DefaultOptions: []llm.ChatOption{llm.WithModel("m")},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func contentChunk(text string) string {
+	return fmt.Sprintf("data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", text)
+}
+
+func TestStream_IdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	c := sseServer(t, func(_ http.ResponseWriter, send func(string)) {
+		send(contentChunk("a"))
+	})
+
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Hi")},
+		llm.WithStreamIdleTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	if ev, err := stream.Recv(); err != nil || ev.Delta != "a" {
+		t.Fatalf("first Recv = %+v, %v", ev, err)
+	}
+	start := time.Now()
+	_, err = stream.Recv()
+	var te *llm.StreamTimeoutError
+	if !errors.As(err, &te) || te.FirstToken || te.Provider != "test" || !errors.Is(err, llm.ErrStreamTimeout) {
+		t.Fatalf("Recv err = %v, want idle timeout", err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) || !llm.IsRetryable(err) {
+		t.Errorf("err = %v, want retryable and distinct from context deadline", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("timeout took %v", d)
+	}
+}
+
+func TestStream_HeartbeatKeepsAlive(t *testing.T) {
+	t.Parallel()
+
+	c := sseServer(t, func(_ http.ResponseWriter, send func(string)) {
+		for range 6 {
+			send(": ping\n\n")
+			time.Sleep(20 * time.Millisecond)
+		}
+		send(contentChunk("a"))
+		time.Sleep(10 * time.Millisecond)
+		send(contentChunk("b"))
+		send("data: [DONE]\n\n")
+	})
+
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Hi")},
+		llm.WithStreamIdleTimeout(80*time.Millisecond))
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	resp, err := llm.Accumulate(stream)
+	if err != nil {
+		t.Fatalf("Accumulate: %v", err)
+	}
+	if got := resp.Choices[0].Message.Text(); got != "ab" {
+		t.Errorf("text = %q", got)
+	}
+
+	stats, ok := llm.StatsOf(stream)
+	if !ok {
+		t.Fatal("StatsOf: not supported")
+	}
+	if stats.Tokens != 2 || stats.TTFT < 100*time.Millisecond || stats.InterTokenMax < 10*time.Millisecond ||
+		stats.InterTokenMean != stats.InterTokenMax || stats.Elapsed < stats.TTFT {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestStream_FirstTokenTimeout(t *testing.T) {
+	t.Parallel()
+
+	c := sseServer(t, func(_ http.ResponseWriter, send func(string)) {
+		// 只有心跳与空内容事件，没有 token
+		send(": ping\n\n")
+		send("data: {\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+	})
+
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Hi")},
+		llm.WithStreamIdleTimeout(time.Minute), llm.WithFirstTokenTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	for {
+		_, err = stream.Recv()
+		if err != nil {
+			break
+		}
+	}
+	var te *llm.StreamTimeoutError
+	if !errors.As(err, &te) || !te.FirstToken || te.Duration != 50*time.Millisecond {
+		t.Fatalf("Recv err = %v, want first token timeout", err)
+	}
+	if stats, _ := llm.StatsOf(stream); stats.Tokens != 0 || stats.TTFT != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
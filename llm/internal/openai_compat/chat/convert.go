@@ -45,8 +45,9 @@ func toSchemaToolCalls(in []wireToolCall) []schema.ToolCall {
 	out := make([]schema.ToolCall, len(in))
 	for i, tc := range in {
 		out[i] = schema.ToolCall{
-			ID:   tc.ID,
-			Type: schema.ToolCallType(tc.Type),
+			Index: tc.Index,
+			ID:    tc.ID,
+			Type:  schema.ToolCallType(tc.Type),
 			Function: schema.ToolFunction{
 				Name:      tc.Function.Name,
 				Arguments: tc.Function.Arguments,
@@ -3,6 +3,9 @@ package chat
 import "encoding/json"
 
 type wireToolCall struct {
+	// Index 仅出现在流式响应的 delta 中
+	Index *int `json:"index,omitempty"`
+
 	ID       string       `json:"id"`
 	Type     wireToolType `json:"type"`
 	Function struct {
@@ -57,6 +60,8 @@ type chatCompletionResponse struct {
 }
 
 type chatCompletionChunk struct {
+	Model string `json:"model"`
+
 	Choices []struct {
 		Index int       `json:"index"`
 		Delta wireDelta `json:"delta"`
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 	"slices"
+	"sync/atomic"
 
 	"github.com/lgc202/go-kit/llm"
 	"github.com/lgc202/go-kit/llm/internal/openai_compat/transport"
@@ -20,6 +21,7 @@ type stream struct {
 
 	pending []schema.StreamEvent
 	done    bool
+	closed  atomic.Bool
 }
 
 const sseDoneToken = "[DONE]"
@@ -36,7 +38,7 @@ func newStream(provider string, body io.ReadCloser, keepRaw bool, hooks []llm.St
 
 func (s *stream) Recv() (schema.StreamEvent, error) {
 	for {
-		if s.done {
+		if s.done || s.closed.Load() {
 			return schema.StreamEvent{}, io.EOF
 		}
 		if len(s.pending) > 0 {
@@ -45,10 +47,15 @@ func (s *stream) Recv() (schema.StreamEvent, error) {
 			return ev, nil
 		}
 
-		data, err := s.dec.NextData()
+		sse, err := s.dec.Next()
 		if err != nil {
 			return schema.StreamEvent{}, err
 		}
+		// 流中途的错误事件（如 {"error":{...}}）转换为 *llm.APIError
+		if err := transport.StreamError(llm.Provider(s.provider), sse); err != nil {
+			return schema.StreamEvent{}, err
+		}
+		data := sse.Data
 
 		if data == sseDoneToken {
 			s.done = true
@@ -107,6 +114,7 @@ func (s *stream) Recv() (schema.StreamEvent, error) {
 				}
 				if chunk.Usage != nil {
 					ev.Usage = toSchemaUsagePtr(chunk.Usage)
+					ev.Model = chunk.Model
 				}
 				mapped = append(mapped, ev)
 			}
@@ -116,12 +124,37 @@ func (s *stream) Recv() (schema.StreamEvent, error) {
 			mapped = append(mapped, schema.StreamEvent{
 				Type:  schema.StreamEventDelta,
 				Usage: toSchemaUsagePtr(chunk.Usage),
+				Model: chunk.Model,
 			})
 			if s.keepRaw {
 				mapped[len(mapped)-1].Raw = raw
 			}
 		}
 
+		// 没有可映射内容的数据块（如 Azure 仅含 prompt_filter_results 的首个 chunk）交给 hook 处理，
+		// hook 填充了 ExtraFields 时才作为事件发出
+		if len(mapped) == 0 && len(s.hooks) > 0 {
+			ev := schema.StreamEvent{Type: schema.StreamEventDelta}
+			if len(chunk.Choices) > 0 {
+				ev.ChoiceIndex = chunk.Choices[0].Index
+			}
+			for _, h := range s.hooks {
+				if h == nil {
+					continue
+				}
+				if err := h(&ev, raw); err != nil {
+					return schema.StreamEvent{}, err
+				}
+			}
+			if len(ev.ExtraFields) > 0 {
+				if s.keepRaw {
+					ev.Raw = raw
+				}
+				s.pending = []schema.StreamEvent{ev}
+			}
+			continue
+		}
+
 		for _, h := range s.hooks {
 			if h == nil {
 				continue
@@ -137,10 +170,10 @@ func (s *stream) Recv() (schema.StreamEvent, error) {
 	}
 }
 
+// Close 可与 Recv 并发调用，关闭 body 以中断阻塞中的读取
 func (s *stream) Close() error {
-	if s.done {
+	if !s.closed.CompareAndSwap(false, true) {
 		return nil
 	}
-	s.done = true
 	return s.body.Close()
 }
@@ -15,6 +15,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lgc202/go-kit/httpx"
 	"github.com/lgc202/go-kit/llm"
 )
 
@@ -32,17 +33,40 @@ type Config struct {
 	Path        string
 	DefaultPath string
 
-	APIKey     string
-	HTTPClient *http.Client
+	APIKey string
+	// APIKeyHeader 非空时以该请求头原样携带 APIKey（如 "x-api-key"、"api-key"），否则使用 "Authorization: Bearer"
+	APIKeyHeader string
+	HTTPClient   *http.Client
+
+	// HTTPX 设置后请求经由 httpx.Client 发出（重试、Retry-After、X-Request-ID、限流、钩子），优先于 HTTPClient
+	HTTPX *httpx.Client
+
+	// HTTPXOptions 未设置 HTTPX 时用于构建内部 httpx.Client，见 NewHTTPX
+	HTTPXOptions []httpx.Option
 
 	// DefaultHeaders 默认请求头，会被请求级别的 headers 覆盖
 	DefaultHeaders http.Header
+
+	// DefaultQuery 每个请求都携带的查询参数（如 Azure 的 api-version）
+	DefaultQuery url.Values
 }
 
 type RequestConfig struct {
 	Timeout    *time.Duration
 	Headers    http.Header
 	ErrorHooks []llm.ErrorHook
+
+	// Path 非空时覆盖客户端路径（相对 BaseURL），用于模型名出现在路径中的 provider（如 Gemini）
+	Path string
+
+	// Query 追加到请求 URL 的查询参数
+	Query url.Values
+
+	// Stream 为流式请求：响应体按 StreamIdleTimeout 与 FirstTokenTimeout 监控（计时从发出请求开始），
+	// 需配合 WatchStream 使用
+	Stream            bool
+	StreamIdleTimeout *time.Duration
+	FirstTokenTimeout *time.Duration
 }
 
 type Client struct {
@@ -52,8 +76,11 @@ type Client struct {
 	path    string
 
 	apiKey        string
+	apiKeyHeader  string
 	httpClient    *http.Client
+	hx            *httpx.Client
 	defaultHeader http.Header
+	defaultQuery  url.Values
 }
 
 func New(cfg Config) (*Client, error) {
@@ -90,6 +117,14 @@ func New(cfg Config) (*Client, error) {
 		hc = http.DefaultClient
 	}
 
+	hx := cfg.HTTPX
+	if hx == nil && len(cfg.HTTPXOptions) > 0 {
+		hx, err = NewHTTPX(cfg.HTTPClient, cfg.HTTPXOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("openai_compat: new httpx client: %w", err)
+		}
+	}
+
 	var hdr http.Header
 	if cfg.DefaultHeaders != nil {
 		hdr = cfg.DefaultHeaders.Clone()
@@ -100,55 +135,145 @@ func New(cfg Config) (*Client, error) {
 		baseURL:       u,
 		path:          path,
 		apiKey:        cfg.APIKey,
+		apiKeyHeader:  http.CanonicalHeaderKey(strings.TrimSpace(cfg.APIKeyHeader)),
 		httpClient:    hc,
+		hx:            hx,
 		defaultHeader: hdr,
+		defaultQuery:  cloneValues(cfg.DefaultQuery),
 	}, nil
 }
 
 func (c *Client) Provider() string { return c.provider }
 
+// NewHTTPX 构建供 LLM 请求使用的 httpx.Client。
+//
+// 与 httpx.DefaultConfig 的区别：Client 级总超时默认关闭（流式响应可能持续数分钟，
+// 应使用 context 或 llm.WithTimeout 控制）；若提供 hc，则复用其 Transport。
+// POST 默认不重试，需通过 httpx.WithRetry 显式将 POST 加入 RetryConfig.Methods。
+func NewHTTPX(hc *http.Client, opts ...httpx.Option) (*httpx.Client, error) {
+	base := []httpx.Option{httpx.WithTimeout(0)}
+	if hc != nil && hc.Transport != nil {
+		base = append(base, httpx.WithTransport(hc.Transport))
+	}
+	return httpx.New(slices.Concat(base, opts)...)
+}
+
 func (c *Client) PostJSON(ctx context.Context, payload any, cfg RequestConfig, accept string) (*http.Response, error) {
-	var cancel context.CancelFunc
-	if cfg.Timeout != nil {
-		ctx, cancel = context.WithTimeout(ctx, *cfg.Timeout)
-		defer cancel()
+	return c.DoJSON(ctx, http.MethodPost, payload, cfg, accept)
+}
+
+// DoJSON 发送 JSON 请求，payload 为 nil 时不携带请求体（如 GET）。
+// 非 2xx 响应转换为 *llm.APIError，成功时调用方负责关闭 resp.Body。
+func (c *Client) DoJSON(ctx context.Context, method string, payload any, cfg RequestConfig, accept string) (*http.Response, error) {
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("%s: marshal request: %w", c.provider, err)
+		}
 	}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("%s: marshal request: %w", c.provider, err)
+	var watch *streamWatch
+	if cfg.Stream {
+		ctx, watch = watchStream(ctx, c.provider, cfg)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(), bytes.NewReader(body))
+	var req *http.Request
+	var err error
+	if c.hx != nil {
+		var reqOpts []httpx.RequestOption
+		if cfg.Timeout != nil {
+			reqOpts = append(reqOpts, httpx.WithRequestTimeout(*cfg.Timeout))
+		}
+		if body != nil {
+			reqOpts = append(reqOpts, httpx.WithBodyBytes(body))
+		}
+		req, err = c.hx.NewRequest(ctx, method, c.endpoint(cfg), reqOpts...)
+	} else {
+		var r io.Reader
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+		req, err = http.NewRequestWithContext(ctx, method, c.endpoint(cfg), r)
+	}
 	if err != nil {
+		watch.finish()
 		return nil, fmt.Errorf("%s: new request: %w", c.provider, err)
 	}
 
-	c.applyHeaders(req, cfg)
+	c.applyHeaders(req, cfg, body != nil)
 	if strings.TrimSpace(accept) != "" {
 		req.Header.Set("Accept", accept)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req, cfg.Timeout)
 	if err != nil {
+		watch.finish()
+		if terr := watch.timeout(); terr != nil {
+			return nil, fmt.Errorf("%s: do request: %w", c.provider, terr)
+		}
 		return nil, fmt.Errorf("%s: do request: %w", c.provider, sanitizeHTTPError(err))
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer watch.finish()
 		defer resp.Body.Close()
 		respBytes, rerr := readLimited(resp.Body, maxErrorBodyBytes)
 		if rerr != nil {
 			return nil, fmt.Errorf("%s: http %d (also failed to read error body: %v)", c.provider, resp.StatusCode, rerr)
 		}
-		return nil, parseError(llm.Provider(c.provider), resp.StatusCode, resp.Header, respBytes, cfg.ErrorHooks)
+		err := parseError(llm.Provider(c.provider), resp.StatusCode, resp.Header, respBytes, cfg.ErrorHooks)
+		if ae, ok := err.(*llm.APIError); ok && ae.RequestID == "" {
+			// 服务端未回传请求 ID 时，使用本端发出的 X-Request-ID 便于关联日志
+			ae.RequestID = extractRequestID(req.Header)
+		}
+		return nil, err
+	}
+
+	if watch != nil {
+		resp.Body = &watchedBody{ReadCloser: resp.Body, w: watch}
+	}
+	return resp, nil
+}
+
+func (c *Client) do(req *http.Request, timeout *time.Duration) (*http.Response, error) {
+	if c.hx != nil {
+		// httpx 负责重试与超时；非 2xx 以 resp 返回，由调用方映射为 *llm.APIError
+		return c.hx.Do(req)
 	}
 
+	if timeout == nil {
+		return c.httpClient.Do(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), *timeout)
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// 超时需覆盖响应体读取（含流式），在 Body 关闭时释放
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
 	return resp, nil
 }
 
-func (c *Client) applyHeaders(req *http.Request, cfg RequestConfig) {
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (c *Client) applyHeaders(req *http.Request, cfg RequestConfig, hasBody bool) {
 	h := make(http.Header)
-	h.Set("Content-Type", httpContentTypeJSON)
+	if hasBody {
+		h.Set("Content-Type", httpContentTypeJSON)
+	}
 
 	if c.defaultHeader != nil {
 		for k, vs := range c.defaultHeader {
@@ -161,18 +286,57 @@ func (c *Client) applyHeaders(req *http.Request, cfg RequestConfig) {
 		}
 	}
 
-	if c.apiKey != "" && h.Get("Authorization") == "" {
-		h.Set("Authorization", "Bearer "+c.apiKey)
+	authHeader := "Authorization"
+	authValue := "Bearer " + c.apiKey
+	if c.apiKeyHeader != "" {
+		authHeader = c.apiKeyHeader
+		authValue = c.apiKey
+	}
+	if c.apiKey != "" && h.Get(authHeader) == "" && req.Header.Get(authHeader) == "" {
+		h.Set(authHeader, authValue)
+	}
+
+	// 保留 httpx 注入的请求头（如 X-Request-ID、User-Agent），同名时以本端为准
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	for k, vs := range h {
+		req.Header[k] = vs
+	}
+}
+
+func (c *Client) endpoint(cfg RequestConfig) string {
+	path := c.path
+	if strings.TrimSpace(cfg.Path) != "" {
+		path = cfg.Path
+	}
+
+	u := c.baseURL
+	if strings.TrimSpace(path) != "" {
+		u = u.JoinPath(strings.TrimPrefix(path, "/"))
+	}
+	if len(c.defaultQuery) == 0 && len(cfg.Query) == 0 {
+		return u.String()
 	}
 
-	req.Header = h
+	u2 := *u
+	q := u2.Query()
+	for _, extra := range []url.Values{c.defaultQuery, cfg.Query} {
+		for k, vs := range extra {
+			for _, v := range vs {
+				q.Add(k, v)
+			}
+		}
+	}
+	u2.RawQuery = q.Encode()
+	return u2.String()
 }
 
-func (c *Client) endpoint() string {
-	if strings.TrimSpace(c.path) == "" {
-		return c.baseURL.String()
+func cloneValues(v url.Values) url.Values {
+	if v == nil {
+		return nil
 	}
-	return c.baseURL.JoinPath(strings.TrimPrefix(c.path, "/")).String()
+	return url.Values(http.Header(v).Clone())
 }
 
 type errorResponse struct {
@@ -180,10 +344,37 @@ type errorResponse struct {
 		Message string          `json:"message"`
 		Type    string          `json:"type"`
 		Param   json.RawMessage `json:"param"`
-		Code    string          `json:"code"`
+		// Code 多数 provider 为字符串，Google 系为数字
+		Code json.RawMessage `json:"code"`
+		// Status Google 系错误状态（如 "RESOURCE_EXHAUSTED"）；Azure 此处为数字状态码，忽略
+		Status json.RawMessage `json:"status"`
+		// InnerError Azure 的内层错误（如内容过滤时 code 为 "ResponsibleAIPolicyViolation"）
+		InnerError struct {
+			Code string `json:"code"`
+		} `json:"innererror"`
 	} `json:"error"`
 }
 
+func (er errorResponse) code() string {
+	raw := er.Error.Code
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+func (er errorResponse) status() string {
+	var s string
+	if err := json.Unmarshal(er.Error.Status, &s); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(s)
+}
+
 func parseError(provider llm.Provider, statusCode int, hdr http.Header, body []byte, hooks []llm.ErrorHook) error {
 	for _, h := range hooks {
 		if h == nil {
@@ -196,11 +387,18 @@ func parseError(provider llm.Provider, statusCode int, hdr http.Header, body []b
 
 	var er errorResponse
 	if err := json.Unmarshal(body, &er); err == nil && strings.TrimSpace(er.Error.Message) != "" {
+		typ := strings.TrimSpace(er.Error.Type)
+		if typ == "" {
+			typ = er.status()
+		}
+		if typ == "" {
+			typ = strings.TrimSpace(er.Error.InnerError.Code)
+		}
 		return &llm.APIError{
 			Provider:   provider,
 			StatusCode: statusCode,
-			Code:       strings.TrimSpace(er.Error.Code),
-			Type:       strings.TrimSpace(er.Error.Type),
+			Code:       er.code(),
+			Type:       typ,
 			Message:    strings.TrimSpace(er.Error.Message),
 			RequestID:  extractRequestID(hdr),
 			RetryAfter: parseRetryAfter(hdr),
@@ -208,6 +406,21 @@ func parseError(provider llm.Provider, statusCode int, hdr http.Header, body []b
 		}
 	}
 
+	// Ollama 原生 API 的错误为 {"error":"..."} 字符串
+	var se struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &se); err == nil && strings.TrimSpace(se.Error) != "" {
+		return &llm.APIError{
+			Provider:   provider,
+			StatusCode: statusCode,
+			Message:    strings.TrimSpace(se.Error),
+			RequestID:  extractRequestID(hdr),
+			RetryAfter: parseRetryAfter(hdr),
+			Raw:        slices.Clone(body),
+		}
+	}
+
 	msg := strings.TrimSpace(string(body))
 	if msg == "" {
 		msg = http.StatusText(statusCode)
@@ -265,6 +478,7 @@ func extractRequestID(h http.Header) string {
 		"X-Request-ID",
 		"X-RequestId",
 		"X-Amzn-RequestId",
+		"Request-Id", // Anthropic
 	} {
 		if v := strings.TrimSpace(h.Get(k)); v != "" {
 			return v
@@ -7,9 +7,11 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/lgc202/go-kit/httpx"
 	"github.com/lgc202/go-kit/llm"
 )
 
@@ -165,3 +167,117 @@ func TestSanitizeHTTPError_PreservesSentinelsAndTypes(t *testing.T) {
 		t.Fatalf("net: expected wrapped original error")
 	}
 }
+
+func TestClient_PostJSON_HTTPXRetriesPOSTWhenOptedIn(t *testing.T) {
+	t.Parallel()
+
+	var n int32
+	var ids []string
+	rt := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		ids = append(ids, r.Header.Get("X-Request-ID"))
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"x":1}` {
+			t.Errorf("attempt body: got %q", string(body))
+		}
+		if atomic.AddInt32(&n, 1) == 1 {
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(strings.NewReader(`{"error":{"message":"overloaded"}}`)),
+				Request:    r,
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{}`)),
+			Request:    r,
+		}, nil
+	})
+
+	retry := httpx.DefaultRetryConfig()
+	retry.Methods = map[string]bool{http.MethodPost: true}
+	retry.Backoff = httpx.ExponentialBackoff{Base: time.Millisecond, Max: time.Millisecond}
+
+	c, err := New(Config{
+		Provider:     llm.ProviderOpenAI,
+		BaseURL:      "https://example.com/v1",
+		DefaultPath:  "/chat/completions",
+		APIKey:       "tok",
+		HTTPClient:   &http.Client{Transport: rt},
+		HTTPXOptions: []httpx.Option{httpx.WithRetry(retry)},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	resp, err := c.PostJSON(context.Background(), map[string]any{"x": 1}, RequestConfig{}, "")
+	if err != nil {
+		t.Fatalf("PostJSON: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if got := atomic.LoadInt32(&n); got != 2 {
+		t.Fatalf("attempts: got %d, want 2", got)
+	}
+	if len(ids) != 2 || ids[0] == "" || ids[0] != ids[1] {
+		t.Fatalf("X-Request-ID should be set once and reused across attempts, got %q", ids)
+	}
+}
+
+func TestClient_PostJSON_HTTPXNoPOSTRetryByDefault(t *testing.T) {
+	t.Parallel()
+
+	var n int32
+	var sentAuth string
+	rt := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&n, 1)
+		sentAuth = r.Header.Get("Authorization")
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{"error":{"message":"overloaded","code":"server_busy"}}`)),
+			Request:    r,
+		}, nil
+	})
+
+	hx, err := NewHTTPX(&http.Client{Transport: rt}, httpx.WithRequestID(httpx.RequestIDConfig{
+		Header: "X-Request-ID",
+		New:    func() string { return "rid_local" },
+	}))
+	if err != nil {
+		t.Fatalf("NewHTTPX: %v", err)
+	}
+
+	c, err := New(Config{
+		Provider:    llm.ProviderDeepSeek,
+		BaseURL:     "https://example.com",
+		DefaultPath: "/chat/completions",
+		APIKey:      "tok",
+		HTTPX:       hx,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	_, err = c.PostJSON(context.Background(), map[string]any{"x": 1}, RequestConfig{}, "")
+	ae, ok := llm.AsAPIError(err)
+	if !ok {
+		t.Fatalf("expected *llm.APIError, got %T: %v", err, err)
+	}
+	if got := atomic.LoadInt32(&n); got != 1 {
+		t.Fatalf("attempts: got %d, want 1", got)
+	}
+	if ae.StatusCode != http.StatusServiceUnavailable || ae.Code != "server_busy" {
+		t.Fatalf("unexpected APIError: %+v", ae)
+	}
+	if ae.RequestID != "rid_local" {
+		t.Fatalf("RequestID: got %q, want fallback to sent X-Request-ID", ae.RequestID)
+	}
+	if !llm.IsTemporary(err) {
+		t.Fatalf("IsTemporary: expected true")
+	}
+	if sentAuth != "Bearer tok" {
+		t.Fatalf("Authorization: got %q", sentAuth)
+	}
+}
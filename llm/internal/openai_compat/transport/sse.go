@@ -1,55 +1,169 @@
 package transport
 
 import (
-	"bufio"
+	"bytes"
 	"io"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// SSEDecoder SSE 解码器，解析并拼接 "data:" 载荷
+// SSEEvent 一个 Server-Sent Events 事件
+type SSEEvent struct {
+	// Event event 字段，未设置时为空（规范中的默认类型 "message"）
+	Event string
+	// ID 最近一次设置的 id 字段，跨事件保留（规范中的 last event ID）
+	ID string
+	// Retry 本事件中 retry 字段给出的重连间隔，未设置时为 0
+	Retry time.Duration
+	// Data 各 data 行的值以 "\n" 拼接，保留值中的空白（仅去除冒号后的一个空格）
+	Data string
+}
+
+// sseReadSize 每次从底层 reader 读取的字节数；行长度不受限制
+const sseReadSize = 4096
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// SSEDecoder 按 WHATWG HTML 规范解析 SSE 流：
+//   - 行以 CRLF、LF 或单独的 CR 结束，忽略开头的 UTF-8 BOM
+//   - 以 ":" 开头的行为注释，忽略
+//   - 字段值去除冒号后的一个空格，其余空白原样保留
+//   - 支持 event、data、id（含 NUL 时忽略）与 retry（仅数字）字段，未知字段忽略
+//   - 空行派发事件，没有 data 字段的事件不派发
+//
+// 与规范不同的是，流在末尾缺少空行时仍派发最后一个事件，以兼容不规范的服务端。
 type SSEDecoder struct {
-	r   *bufio.Reader
-	buf []string
+	r   io.Reader
+	buf []byte
+	off int
+	err error
+	bom bool
+
+	lastID string
 }
 
 func NewSSEDecoder(r io.Reader) *SSEDecoder {
-	return &SSEDecoder{r: bufio.NewReader(r)}
+	return &SSEDecoder{r: r}
 }
 
-// NextData 返回下一个 SSE data 载荷，用 "\n" 拼接
-// 底层 reader 结束时返回 io.EOF
-func (d *SSEDecoder) NextData() (string, error) {
+// Next 返回下一个事件，底层 reader 结束时返回 io.EOF
+func (d *SSEDecoder) Next() (SSEEvent, error) {
+	var (
+		ev      SSEEvent
+		data    strings.Builder
+		hasData bool
+	)
+	dispatch := func() SSEEvent {
+		ev.ID = d.lastID
+		ev.Data = data.String()
+		return ev
+	}
+
 	for {
-		line, err := d.r.ReadString('\n')
-		if err != nil && err != io.EOF {
-			return "", err
+		line, err := d.readLine()
+		if err != nil {
+			if err == io.EOF && hasData {
+				return dispatch(), nil
+			}
+			return SSEEvent{}, err
 		}
 
-		line = strings.TrimRight(line, "\r\n")
-
 		if line == "" {
-			if len(d.buf) > 0 {
-				out := strings.Join(d.buf, "\n")
-				d.buf = d.buf[:0]
-				return out, nil
-			}
-			if err == io.EOF {
-				return "", io.EOF
+			if hasData {
+				return dispatch(), nil
 			}
+			ev = SSEEvent{}
+			continue
+		}
+		if line[0] == ':' {
 			continue
 		}
 
-		if strings.HasPrefix(line, "data:") {
-			d.buf = append(d.buf, strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			ev.Event = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				d.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 63); err == nil && value != "" && value[0] != '+' {
+				ev.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// NextData 返回下一个事件的 data，底层 reader 结束时返回 io.EOF
+func (d *SSEDecoder) NextData() (string, error) {
+	ev, err := d.Next()
+	if err != nil {
+		return "", err
+	}
+	return ev.Data, nil
+}
+
+// readLine 返回下一行（不含行结束符）；reader 结束时返回剩余的不完整行，之后返回 io.EOF
+func (d *SSEDecoder) readLine() (string, error) {
+	for {
+		if !d.bom {
+			switch rest := d.buf[d.off:]; {
+			case bytes.HasPrefix(rest, utf8BOM):
+				d.off += len(utf8BOM)
+				d.bom = true
+			case len(rest) >= len(utf8BOM) || d.err != nil || !bytes.HasPrefix(utf8BOM, rest):
+				d.bom = true
+			}
 		}
 
-		if err == io.EOF {
-			if len(d.buf) > 0 {
-				out := strings.Join(d.buf, "\n")
-				d.buf = d.buf[:0]
-				return out, nil
+		rest := d.buf[d.off:]
+		if i := bytes.IndexAny(rest, "\r\n"); i >= 0 && d.bom {
+			// 行尾为 CR 时需要下一个字节判断是否为 CRLF
+			if rest[i] == '\r' && i+1 == len(rest) && d.err == nil {
+				d.fill()
+				continue
 			}
-			return "", io.EOF
+			line := string(rest[:i])
+			n := i + 1
+			if rest[i] == '\r' && n < len(rest) && rest[n] == '\n' {
+				n++
+			}
+			d.off += n
+			return line, nil
+		}
+
+		if d.err != nil {
+			if len(rest) > 0 {
+				d.off = len(d.buf)
+				return string(rest), nil
+			}
+			return "", d.err
 		}
+		d.fill()
+	}
+}
+
+// fill 丢弃已消费的数据并从底层 reader 读取更多
+func (d *SSEDecoder) fill() {
+	if d.off > 0 {
+		n := copy(d.buf, d.buf[d.off:])
+		d.buf = d.buf[:n]
+		d.off = 0
+	}
+	d.buf = slices.Grow(d.buf, sseReadSize)
+	n, err := d.r.Read(d.buf[len(d.buf) : len(d.buf)+sseReadSize])
+	d.buf = d.buf[:len(d.buf)+n]
+	if err != nil {
+		d.err = err
 	}
 }
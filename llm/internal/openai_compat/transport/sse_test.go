@@ -0,0 +1,171 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+)
+
+func decodeAll(t *testing.T, r io.Reader) []SSEEvent {
+	t.Helper()
+	dec := NewSSEDecoder(r)
+	var out []SSEEvent
+	for {
+		ev, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		out = append(out, ev)
+	}
+}
+
+func TestSSEDecoder(t *testing.T) {
+	t.Parallel()
+
+	stream := "\xef\xbb\xbf: comment\n" +
+		"event: message_start\n" +
+		"id: 1\n" +
+		"data: {\"a\":1}\n\n" +
+		"data:  two leading spaces\r\n" +
+		"data:\r\n" +
+		"data:trailing \r\n\r\n" +
+		"retry: 1500\r" +
+		"data: cr only\r\r" +
+		"id: \x00bad\n" +
+		"retry: x\n" +
+		"event: ignored\n\n" + // 没有 data，不派发，event 被重置
+		"unknown: field\n" +
+		"data\n\n" +
+		"data: last without blank line"
+
+	want := []SSEEvent{
+		{Event: "message_start", ID: "1", Data: `{"a":1}`},
+		{ID: "1", Data: " two leading spaces\n\ntrailing "},
+		{ID: "1", Retry: 1500 * time.Millisecond, Data: "cr only"},
+		{ID: "1", Data: ""},
+		{ID: "1", Data: "last without blank line"},
+	}
+
+	for name, r := range map[string]io.Reader{
+		"whole":    strings.NewReader(stream),
+		"one byte": iotest.OneByteReader(strings.NewReader(stream)),
+		"half":     iotest.HalfReader(strings.NewReader(stream)),
+	} {
+		got := decodeAll(t, r)
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %d events %+v, want %d", name, len(got), got, len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: event %d = %+v, want %+v", name, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestSSEDecoder_LongLine(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", 1<<20)
+	got := decodeAll(t, strings.NewReader("data: "+long+"\n\ndata: next\n\n"))
+	if len(got) != 2 || got[0].Data != long || got[1].Data != "next" {
+		t.Errorf("got %d events", len(got))
+	}
+}
+
+func TestSSEDecoder_ReadError(t *testing.T) {
+	t.Parallel()
+
+	boom := errors.New("boom")
+	dec := NewSSEDecoder(io.MultiReader(strings.NewReader("data: a\n\ndata: b\n"), iotest.ErrReader(boom)))
+	if data, err := dec.NextData(); err != nil || data != "a" {
+		t.Fatalf("NextData() = %q, %v", data, err)
+	}
+	if _, err := dec.Next(); !errors.Is(err, boom) {
+		t.Errorf("err = %v, want boom", err)
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		ev     SSEEvent
+		status int
+		code   string
+		msg    string
+	}{
+		{
+			name:   "openai server_error",
+			ev:     SSEEvent{Data: `{"error":{"message":"The server had an error","type":"server_error"}}`},
+			status: http.StatusInternalServerError,
+			msg:    "The server had an error",
+		},
+		{
+			name:   "rate limit code",
+			ev:     SSEEvent{Data: `{"error":{"message":"slow down","type":"requests","code":"rate_limit_exceeded"}}`},
+			status: http.StatusTooManyRequests,
+			code:   "rate_limit_exceeded",
+			msg:    "slow down",
+		},
+		{
+			name:   "numeric code (vLLM / Gemini)",
+			ev:     SSEEvent{Data: `{"error":{"code":503,"message":"overloaded","status":"UNAVAILABLE"}}`},
+			status: http.StatusServiceUnavailable,
+			code:   "503",
+			msg:    "overloaded",
+		},
+		{
+			name: "string error",
+			ev:   SSEEvent{Data: `{"error":"model not loaded"}`},
+			msg:  "model not loaded",
+		},
+		{
+			name: "unknown type",
+			ev:   SSEEvent{Data: `{"error":{"message":"bad output","type":"invalid_output","code":"content_filter"}}`},
+			code: "content_filter",
+			msg:  "bad output",
+		},
+		{
+			name: "error event with text data",
+			ev:   SSEEvent{Event: "error", Data: "upstream closed"},
+			msg:  "upstream closed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := StreamError(llm.ProviderOpenAI, tt.ev)
+			ae, ok := llm.AsAPIError(err)
+			if !ok {
+				t.Fatalf("StreamError() = %v, want *llm.APIError", err)
+			}
+			if ae.Provider != llm.ProviderOpenAI || ae.StatusCode != tt.status || ae.Code != tt.code || ae.Message != tt.msg {
+				t.Errorf("APIError = %+v", ae)
+			}
+			// 无法推断状态码的错误不视为临时错误
+			if tt.status == 0 && llm.IsTemporary(err) {
+				t.Errorf("IsTemporary(%v) = true", err)
+			}
+		})
+	}
+
+	for _, ev := range []SSEEvent{
+		{Data: `{"choices":[{"delta":{"content":"the \"error\" word"}}]}`},
+		{Data: `{"error":null,"choices":[]}`},
+		{Data: "[DONE]"},
+	} {
+		if err := StreamError(llm.ProviderOpenAI, ev); err != nil {
+			t.Errorf("StreamError(%q) = %v, want nil", ev.Data, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm"
+)
+
+// StreamError 识别流中途发送的错误事件，返回 *llm.APIError；不是错误事件时返回 nil。
+//
+// 错误事件为 event 字段为 "error" 的事件，或 data 为 {"error": ...} 的事件
+// （OpenAI 兼容服务、Gemini 在已返回 200 的流中发送的错误）。
+// 此时已没有 HTTP 状态码可用，StatusCode 由错误码或错误类型推断，使 llm.IsTemporary / llm.IsRateLimit 等判断可用；
+// 无法推断时为 0，不视为临时错误，避免重试确定性的失败。
+func StreamError(provider llm.Provider, ev SSEEvent) error {
+	data := strings.TrimSpace(ev.Data)
+	isErrorEvent := ev.Event == "error"
+	if !isErrorEvent && (!strings.HasPrefix(data, "{") || !strings.Contains(data, `"error"`)) {
+		return nil
+	}
+
+	var probe struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(data), &probe); err != nil || len(probe.Error) == 0 || string(probe.Error) == "null" {
+		if !isErrorEvent {
+			return nil
+		}
+		msg := data
+		if msg == "" {
+			msg = "stream error"
+		}
+		return &llm.APIError{
+			Provider: provider,
+			Message:  msg,
+			Raw:      []byte(ev.Data),
+		}
+	}
+
+	ae, ok := parseError(provider, 0, nil, []byte(data), nil).(*llm.APIError)
+	if !ok {
+		return nil
+	}
+	ae.StatusCode = streamErrorStatus(ae)
+	return ae
+}
+
+// streamErrorStatus 由错误码（数字码或字符串码）与错误类型推断 HTTP 状态码，无法推断时返回 0
+func streamErrorStatus(ae *llm.APIError) int {
+	if n, err := strconv.Atoi(ae.Code); err == nil && n >= 400 && n < 600 {
+		return n
+	}
+	for _, s := range []string{ae.Code, ae.Type} {
+		switch strings.ToLower(s) {
+		case "rate_limit_exceeded", "rate_limit_error", "insufficient_quota", "resource_exhausted", "too_many_requests":
+			return http.StatusTooManyRequests
+		case "invalid_api_key", "authentication_error", "unauthenticated":
+			return http.StatusUnauthorized
+		case "permission_error", "permission_denied":
+			return http.StatusForbidden
+		case "invalid_request_error", "invalid_argument", "badrequesterror", "context_length_exceeded":
+			return http.StatusBadRequest
+		case "server_error", "api_error", "internal", "internal_error":
+			return http.StatusInternalServerError
+		case "overloaded_error", "unavailable", "service_unavailable":
+			return http.StatusServiceUnavailable
+		case "timeout", "timeout_error", "deadline_exceeded":
+			return http.StatusGatewayTimeout
+		}
+	}
+	return 0
+}
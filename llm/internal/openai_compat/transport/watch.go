@@ -0,0 +1,213 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// streamWatch 监控一次流式请求：空闲超时、首 token 超时与时延统计。
+//
+// 超时触发时取消请求 context，使阻塞中的 Read 立即返回，Recv 返回 *llm.StreamTimeoutError
+type streamWatch struct {
+	provider llm.Provider
+	cancel   context.CancelFunc
+	start    time.Time
+
+	idle       time.Duration
+	first      time.Duration
+	idleTimer  *time.Timer
+	firstTimer *time.Timer
+
+	mu        sync.Mutex
+	err       error
+	done      bool
+	end       time.Time
+	lastRead  time.Time
+	lastToken time.Time
+	ttft      time.Duration
+	tokens    int
+	gapSum    time.Duration
+	gapMax    time.Duration
+}
+
+// watchStream 返回用于发送请求的 ctx，计时从调用时开始
+func watchStream(ctx context.Context, provider string, cfg RequestConfig) (context.Context, *streamWatch) {
+	ctx, cancel := context.WithCancel(ctx)
+	now := time.Now()
+	w := &streamWatch{provider: llm.Provider(provider), cancel: cancel, start: now, lastRead: now}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if d := cfg.StreamIdleTimeout; d != nil && *d > 0 {
+		w.idle = *d
+		w.idleTimer = time.AfterFunc(w.idle, w.checkIdle)
+	}
+	if d := cfg.FirstTokenTimeout; d != nil && *d > 0 {
+		w.first = *d
+		w.firstTimer = time.AfterFunc(w.first, w.firstTokenExpired)
+	}
+	return ctx, w
+}
+
+// checkIdle 距上次收到数据不足 idle 时重新计时，否则中止请求
+func (w *streamWatch) checkIdle() {
+	w.mu.Lock()
+	if w.done || w.err != nil {
+		w.mu.Unlock()
+		return
+	}
+	if rest := w.idle - time.Since(w.lastRead); rest > 0 {
+		w.idleTimer.Reset(rest)
+		w.mu.Unlock()
+		return
+	}
+	w.err = &llm.StreamTimeoutError{Provider: w.provider, Duration: w.idle}
+	w.mu.Unlock()
+	w.cancel()
+}
+
+func (w *streamWatch) firstTokenExpired() {
+	w.mu.Lock()
+	if w.done || w.err != nil || w.tokens > 0 {
+		w.mu.Unlock()
+		return
+	}
+	w.err = &llm.StreamTimeoutError{Provider: w.provider, FirstToken: true, Duration: w.first}
+	w.mu.Unlock()
+	w.cancel()
+}
+
+// read 记录收到数据的时间，心跳同样计入
+func (w *streamWatch) read() {
+	w.mu.Lock()
+	w.lastRead = time.Now()
+	w.mu.Unlock()
+}
+
+// token 记录一个内容事件
+func (w *streamWatch) token() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if w.tokens == 0 {
+		w.ttft = now.Sub(w.start)
+		if w.firstTimer != nil {
+			w.firstTimer.Stop()
+		}
+	} else {
+		gap := now.Sub(w.lastToken)
+		w.gapSum += gap
+		w.gapMax = max(w.gapMax, gap)
+	}
+	w.tokens++
+	w.lastToken = now
+}
+
+// timeout 返回已触发的超时错误
+func (w *streamWatch) timeout() error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// finish 流结束（或请求失败）时停止计时并释放 ctx，可重复调用
+func (w *streamWatch) finish() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	if !w.done {
+		w.done = true
+		w.end = time.Now()
+		if w.idleTimer != nil {
+			w.idleTimer.Stop()
+		}
+		if w.firstTimer != nil {
+			w.firstTimer.Stop()
+		}
+	}
+	w.mu.Unlock()
+	w.cancel()
+}
+
+func (w *streamWatch) stats() llm.StreamStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	s := llm.StreamStats{TTFT: w.ttft, Tokens: w.tokens, InterTokenMax: w.gapMax}
+	if w.tokens > 1 {
+		s.InterTokenMean = w.gapSum / time.Duration(w.tokens-1)
+	}
+	if w.done {
+		s.Elapsed = w.end.Sub(w.start)
+	} else {
+		s.Elapsed = time.Since(w.start)
+	}
+	return s
+}
+
+type watchedBody struct {
+	io.ReadCloser
+	w *streamWatch
+}
+
+func (b *watchedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.w.read()
+	}
+	return n, err
+}
+
+func (b *watchedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.w.finish()
+	return err
+}
+
+// WatchStream 包装由 body 解码得到的 Stream：记录时延统计（llm.StatsOf），
+// 收到首个内容事件后停止首 token 计时，超时中止后 Recv 返回 *llm.StreamTimeoutError。
+// body 须为 RequestConfig.Stream 请求返回的响应体，否则原样返回 s
+func WatchStream(body io.ReadCloser, s llm.Stream) llm.Stream {
+	b, ok := body.(*watchedBody)
+	if !ok {
+		return s
+	}
+	return &watchedStream{Stream: s, w: b.w}
+}
+
+type watchedStream struct {
+	llm.Stream
+	w *streamWatch
+}
+
+func (s *watchedStream) Recv() (schema.StreamEvent, error) {
+	ev, err := s.Stream.Recv()
+	if err != nil {
+		// 超时取消请求后，底层读取错误（如 context canceled）统一替换为超时错误
+		if terr := s.w.timeout(); terr != nil {
+			err = terr
+		}
+		s.w.finish()
+		return ev, err
+	}
+	if isContentEvent(ev) {
+		s.w.token()
+	}
+	return ev, nil
+}
+
+func (s *watchedStream) Stats() llm.StreamStats { return s.w.stats() }
+
+func isContentEvent(ev schema.StreamEvent) bool {
+	return ev.Delta != "" || ev.Reasoning != "" || len(ev.ToolCalls) > 0
+}
@@ -19,7 +19,8 @@ type Embedder interface {
 
 // Stream 流式响应读取器
 //
-// Recv 每次返回一个事件，流结束时返回 io.EOF
+// Recv 每次返回一个事件，流结束时返回 io.EOF。
+// Close 可与阻塞中的 Recv 并发调用以中断读取（Events 依赖这一点响应 ctx 取消），内置实现均满足
 type Stream interface {
 	Recv() (schema.StreamEvent, error)
 	Close() error
@@ -29,12 +30,15 @@ type Stream interface {
 type Provider string
 
 const (
-	ProviderUnknown  Provider = "unknown"
-	ProviderOpenAI   Provider = "openai"
-	ProviderDeepSeek Provider = "deepseek"
-	ProviderKimi     Provider = "kimi"
-	ProviderQwen     Provider = "qwen"
-	ProviderOllama   Provider = "ollama"
+	ProviderUnknown     Provider = "unknown"
+	ProviderOpenAI      Provider = "openai"
+	ProviderAzureOpenAI Provider = "azure-openai"
+	ProviderDeepSeek    Provider = "deepseek"
+	ProviderKimi        Provider = "kimi"
+	ProviderQwen        Provider = "qwen"
+	ProviderOllama      Provider = "ollama"
+	ProviderAnthropic   Provider = "anthropic"
+	ProviderGemini      Provider = "gemini"
 )
 
 // ProviderNamer 可选接口，用于标识 ChatModel 的 provider 类型
@@ -51,3 +55,16 @@ func ProviderOf(m ChatModel) Provider {
 	}
 	return ProviderUnknown
 }
+
+// ExtraProvider 响应或流式事件 ExtraFields 中实际给出响应的 provider（string），
+// 由 router 等在多个后端之间转发请求的 ChatModel 设置
+const ExtraProvider = "llm_provider"
+
+// ProviderOfResponse 获取某次调用实际给出响应的 provider：extra（响应或流式事件的 ExtraFields）
+// 中有 ExtraProvider 时以其为准，否则为 ProviderOf(m)
+func ProviderOfResponse(m ChatModel, extra map[string]any) Provider {
+	if p, ok := extra[ExtraProvider].(string); ok && p != "" {
+		return Provider(p)
+	}
+	return ProviderOf(m)
+}
@@ -0,0 +1,121 @@
+// Package cassette 录制与回放 provider 的 HTTP 交互，用于离线测试。
+//
+// Recorder 是一个 http.RoundTripper：录制模式下将真实的请求 / 响应（包括 SSE 流）保存到 YAML 或 JSON 文件，
+// 回放模式下按方法、URL 与规范化的请求体匹配已录制的交互并返回，不访问网络。
+// Authorization、api-key 等凭据在写入文件前自动脱敏。
+package cassette
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Version 当前的文件格式版本
+const Version = 1
+
+// Cassette 一个录制文件的内容
+type Cassette struct {
+	Version      int            `json:"version" yaml:"version"`
+	Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Interaction 一次请求与其响应
+type Interaction struct {
+	Request  Request  `json:"request" yaml:"request"`
+	Response Response `json:"response" yaml:"response"`
+}
+
+// Request 已录制的请求，凭据已脱敏
+type Request struct {
+	Method  string      `json:"method" yaml:"method"`
+	URL     string      `json:"url" yaml:"url"`
+	Headers http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// Response 已录制的响应；SSE 流保存为完整的响应体
+type Response struct {
+	StatusCode int         `json:"status_code" yaml:"status_code"`
+	Headers    http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body       string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// Load 读取录制文件，按扩展名（.yaml / .yml / .json）选择格式
+func Load(path string) (*Cassette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: %w", err)
+	}
+	var c Cassette
+	switch format(path) {
+	case "yaml":
+		err = yaml.Unmarshal(b, &c)
+	case "json":
+		err = json.Unmarshal(b, &c)
+	default:
+		return nil, fmt.Errorf("cassette: unsupported file extension %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cassette: decode %s: %w", path, err)
+	}
+	if c.Version > Version {
+		return nil, fmt.Errorf("cassette: %s: unsupported version %d", path, c.Version)
+	}
+	return &c, nil
+}
+
+// Save 写入录制文件，按扩展名选择格式，目录不存在时自动创建
+func (c *Cassette) Save(path string) error {
+	c.Version = Version
+
+	var (
+		b   []byte
+		err error
+	)
+	switch format(path) {
+	case "yaml":
+		b, err = yaml.Marshal(c)
+	case "json":
+		b, err = json.MarshalIndent(c, "", "  ")
+		b = append(b, '\n')
+	default:
+		return fmt.Errorf("cassette: unsupported file extension %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("cassette: encode %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return fmt.Errorf("cassette: %w", err)
+	}
+	return nil
+}
+
+func format(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	default:
+		return ""
+	}
+}
+
+func exists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
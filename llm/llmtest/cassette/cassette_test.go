@@ -0,0 +1,242 @@
+package cassette_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lgc202/go-kit/httpx"
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/llmtest/cassette"
+	"github.com/lgc202/go-kit/llm/provider/openai/chat"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+const sseBody = `data: {"id":"c1","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}
+
+data: {"id":"c1","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`
+
+// newServer 模拟 OpenAI chat/completions：stream 为 true 时返回 SSE
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-secret" {
+			http.Error(w, `{"error":{"message":"bad key"}}`, http.StatusUnauthorized)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(b), `"stream":true`) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, ev := range strings.SplitAfter(sseBody, "\n\n") {
+				_, _ = io.WriteString(w, ev)
+				w.(http.Flusher).Flush()
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"c0","model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"Hi!"},"finish_reason":"stop"}]}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newClient(t *testing.T, baseURL string, cfg chat.BaseConfig) *chat.Client {
+	t.Helper()
+	cfg.BaseURL = baseURL + "/v1"
+	cfg.APIKey = "sk-secret"
+	c, err := chat.New(chat.Config{BaseConfig: cfg, DefaultOptions: []llm.ChatOption{llm.WithModel("gpt-4o-mini")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func exercise(t *testing.T, c *chat.Client) (string, string) {
+	t.Helper()
+	ctx := context.Background()
+	resp, err := c.Chat(ctx, []schema.Message{schema.UserMessage("hi")})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	stream, err := c.ChatStream(ctx, []schema.Message{schema.UserMessage("hi")})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	streamed, err := llm.Accumulate(stream)
+	if err != nil {
+		t.Fatalf("Accumulate() error = %v", err)
+	}
+	return resp.Choices[0].Message.Text(), streamed.Choices[0].Message.Text()
+}
+
+func TestRecordReplay(t *testing.T) {
+	t.Parallel()
+
+	for _, ext := range []string{".yaml", ".json"} {
+		t.Run(ext, func(t *testing.T) {
+			t.Parallel()
+
+			srv := newServer(t)
+			path := filepath.Join(t.TempDir(), "chat"+ext)
+
+			rec, err := cassette.New(cassette.Config{Path: path, Mode: cassette.ModeRecord})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, streamed := exercise(t, newClient(t, srv.URL, chat.BaseConfig{HTTPClient: rec.Client()})); got != "Hi!" || streamed != "Hello" {
+				t.Fatalf("recorded = %q, %q", got, streamed)
+			}
+			if err := rec.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			b, _ := os.ReadFile(path)
+			if strings.Contains(string(b), "sk-secret") || !strings.Contains(string(b), cassette.Redacted) {
+				t.Errorf("credentials not scrubbed:\n%s", b)
+			}
+			if !strings.Contains(string(b), "data: [DONE]") {
+				t.Errorf("SSE body not recorded:\n%s", b)
+			}
+
+			// 回放不访问网络；经由 httpx.WithTransport 接入
+			replay, err := cassette.New(cassette.Config{Path: path, Transport: failTransport{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := newClient(t, srv.URL, chat.BaseConfig{HTTPXOptions: []httpx.Option{httpx.WithTransport(replay)}})
+			if got, streamed := exercise(t, c); got != "Hi!" || streamed != "Hello" {
+				t.Errorf("replayed = %q, %q", got, streamed)
+			}
+
+			// 请求体不同则不匹配
+			_, err = c.Chat(context.Background(), []schema.Message{schema.UserMessage("other")})
+			if !errors.Is(err, cassette.ErrNoInteraction) {
+				t.Errorf("unmatched err = %v", err)
+			}
+		})
+	}
+}
+
+// TestRecord_ScrubHeaders 写入文件的请求头与响应头不含凭据、Cookie 与账号标识
+func TestRecord_ScrubHeaders(t *testing.T) {
+	t.Parallel()
+
+	secrets := []string{"sk-secret", "session=abc", "org-123", "proj-456", "anth-org-789", "session=xyz"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Set-Cookie", "session=xyz; Path=/")
+		w.Header().Add("Set-Cookie", "__cf_bm=1")
+		w.Header().Set("OpenAI-Organization", "org-123")
+		w.Header().Set("OpenAI-Project", "proj-456")
+		w.Header().Set("Anthropic-Organization-Id", "anth-org-789")
+		w.Header().Set("X-Trace", "keep-me")
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "headers.yaml")
+	rec, err := cassette.New(cassette.Config{Path: path, Mode: cassette.ModeRecord, ScrubResponseHeaders: []string{"X-Trace"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Authorization", "Bearer sk-secret")
+	req.Header.Set("Cookie", "session=abc")
+	req.Header.Set("OpenAI-Organization", "org-123")
+	resp, err := rec.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 调用方收到的响应头不受影响
+	if got := resp.Header.Get("OpenAI-Organization"); got != "org-123" {
+		t.Errorf("live header = %q", got)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range append(secrets, "keep-me", "__cf_bm") {
+		if strings.Contains(string(b), s) {
+			t.Errorf("cassette contains %q:\n%s", s, b)
+		}
+	}
+	if !strings.Contains(string(b), "Set-Cookie") || !strings.Contains(string(b), cassette.Redacted) {
+		t.Errorf("scrubbed headers missing:\n%s", b)
+	}
+}
+
+type failTransport struct{}
+
+func (failTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("unexpected network access: %s", r.URL)
+}
+
+func TestReplayOrRecord(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer(t)
+	path := filepath.Join(t.TempDir(), "auto.yaml")
+	hits := 0
+	counting := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		hits++
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	for range 2 {
+		rec, err := cassette.New(cassette.Config{Path: path, Mode: cassette.ModeReplayOrRecord, Transport: counting})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exercise(t, newClient(t, srv.URL, chat.BaseConfig{HTTPClient: rec.Client()}))
+		if err := rec.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hits != 2 {
+		t.Errorf("network requests = %d, want 2 (second run replays)", hits)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestMatchers(t *testing.T) {
+	t.Parallel()
+
+	rec := cassette.Request{
+		Method: http.MethodPost,
+		URL:    "https://generativelanguage.googleapis.com/v1beta/models/gemini:generateContent?alt=sse&key=REDACTED",
+		Body:   `{"b":1,"a":[1,2]}`,
+	}
+	req := httptest.NewRequest(http.MethodPost,
+		"https://generativelanguage.googleapis.com/v1beta/models/gemini:generateContent?key=AIza-secret&alt=sse", nil)
+	body := []byte("{\n  \"a\": [1, 2],\n  \"b\": 1\n}")
+
+	if !cassette.MatchMethod(req, body, rec) || !cassette.MatchURL(req, body, rec) || !cassette.MatchBody(req, body, rec) {
+		t.Error("request should match")
+	}
+	if cassette.MatchBody(req, []byte(`{"a":[2,1],"b":1}`), rec) {
+		t.Error("different body should not match")
+	}
+	req.URL.Path = "/v1beta/models/other"
+	if cassette.MatchURL(req, body, rec) || cassette.MatchPath(req, body, rec) {
+		t.Error("different path should not match")
+	}
+}
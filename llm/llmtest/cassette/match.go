@@ -0,0 +1,120 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Redacted 脱敏后的凭据值
+const Redacted = "REDACTED"
+
+// DefaultScrubHeaders 默认脱敏的请求头
+var DefaultScrubHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Api-Key",
+	"X-Api-Key",
+	"X-Goog-Api-Key",
+	"Cookie",
+	"Openai-Organization",
+	"Openai-Project",
+}
+
+// DefaultScrubResponseHeaders 默认脱敏的响应头（会话 Cookie 与账号标识）
+var DefaultScrubResponseHeaders = []string{
+	"Set-Cookie",
+	"Openai-Organization",
+	"Openai-Project",
+	"Anthropic-Organization-Id",
+}
+
+// DefaultScrubQuery 默认脱敏的 URL 查询参数（如 Gemini 的 ?key=）
+var DefaultScrubQuery = []string{"key", "api_key", "api-key"}
+
+// Matcher 判断请求是否与已录制的请求匹配。body 为请求体原文，rec 中的 URL 与请求头已脱敏
+type Matcher func(r *http.Request, body []byte, rec Request) bool
+
+// DefaultMatchers 默认按方法、URL 与规范化的请求体匹配
+var DefaultMatchers = []Matcher{MatchMethod, MatchURL, MatchBody}
+
+// MatchMethod 匹配请求方法
+func MatchMethod(r *http.Request, _ []byte, rec Request) bool {
+	return r.Method == rec.Method
+}
+
+// MatchURL 匹配完整 URL（查询参数按键排序后比较）；录制时已脱敏的查询参数不比较取值
+func MatchURL(r *http.Request, _ []byte, rec Request) bool {
+	u, err := url.Parse(rec.URL)
+	if err != nil {
+		return false
+	}
+	var redacted []string
+	for k, v := range u.Query() {
+		if slices.Contains(v, Redacted) {
+			redacted = append(redacted, k)
+		}
+	}
+	return canonicalURL(scrubURL(r.URL, redacted)) == canonicalURL(u)
+}
+
+// MatchPath 仅匹配 URL 路径，适用于录制与回放时服务地址不同的场景（如 httptest.Server）
+func MatchPath(r *http.Request, _ []byte, rec Request) bool {
+	u, err := url.Parse(rec.URL)
+	return err == nil && r.URL.Path == u.Path
+}
+
+// MatchBody 匹配规范化后的请求体，见 NormalizeBody
+func MatchBody(_ *http.Request, body []byte, rec Request) bool {
+	return NormalizeBody(body) == NormalizeBody([]byte(rec.Body))
+}
+
+// NormalizeBody 规范化请求体：JSON 重新编码为紧凑格式且对象键有序，其余内容去除首尾空白
+func NormalizeBody(body []byte) string {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err == nil && !dec.More() {
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return string(bytes.TrimSpace(body))
+}
+
+func scrubHeaders(h http.Header, names []string) http.Header {
+	out := h.Clone()
+	for _, name := range names {
+		if out.Get(name) != "" {
+			out.Set(name, Redacted)
+		}
+	}
+	return out
+}
+
+func scrubURL(u *url.URL, params []string) *url.URL {
+	out := *u
+	q := out.Query()
+	changed := false
+	for k := range q {
+		if slices.ContainsFunc(params, func(p string) bool { return strings.EqualFold(p, k) }) {
+			q.Set(k, Redacted)
+			changed = true
+		}
+	}
+	if changed {
+		out.RawQuery = q.Encode()
+	}
+	out.User = nil
+	return &out
+}
+
+func canonicalURL(u *url.URL) string {
+	out := *u
+	out.RawQuery = out.Query().Encode()
+	out.Fragment = ""
+	return out.String()
+}
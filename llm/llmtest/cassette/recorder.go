@@ -0,0 +1,291 @@
+package cassette
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrNoInteraction 回放模式下没有与请求匹配的录制交互
+var ErrNoInteraction = errors.New("cassette: no matching interaction")
+
+// Mode 录制 / 回放模式
+type Mode int
+
+const (
+	// ModeReplay 只回放，没有匹配的交互时返回 ErrNoInteraction（默认）
+	ModeReplay Mode = iota
+	// ModeRecord 访问真实服务并重新录制，覆盖已有文件
+	ModeRecord
+	// ModeReplayOrRecord 有匹配的交互时回放，否则访问真实服务并追加录制
+	ModeReplayOrRecord
+)
+
+// ParseMode 解析模式名称："replay"（或空字符串）、"record"、"auto"（ModeReplayOrRecord），
+// 便于通过环境变量切换，如 cassette.ParseMode(os.Getenv("LLM_CASSETTE"))
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(s) {
+	case "", "replay":
+		return ModeReplay, nil
+	case "record":
+		return ModeRecord, nil
+	case "auto":
+		return ModeReplayOrRecord, nil
+	default:
+		return 0, fmt.Errorf("cassette: unknown mode %q", s)
+	}
+}
+
+type Config struct {
+	// Path 录制文件路径，扩展名决定格式（.yaml / .yml / .json）
+	Path string
+
+	Mode Mode
+
+	// Transport 录制时使用的真实 Transport，默认 http.DefaultTransport
+	Transport http.RoundTripper
+
+	// Matchers 回放时的匹配规则，须全部满足，默认 DefaultMatchers
+	Matchers []Matcher
+
+	// ScrubHeaders 除 DefaultScrubHeaders 外额外脱敏的请求头
+	ScrubHeaders []string
+
+	// ScrubQuery 除 DefaultScrubQuery 外额外脱敏的 URL 查询参数
+	ScrubQuery []string
+
+	// ScrubResponseHeaders 除 DefaultScrubResponseHeaders 外额外脱敏的响应头
+	ScrubResponseHeaders []string
+}
+
+// Recorder 录制或回放 HTTP 交互的 http.RoundTripper，可并发使用。
+//
+// 可通过 base.Config.HTTPClient（Client()）或 httpx.WithTransport 接入 provider。
+// 录制的交互在调用 Save 后写入文件；流式响应在响应体读取到结尾或关闭时完成录制。
+type Recorder struct {
+	cfg         Config
+	headers     []string
+	query       []string
+	respHeaders []string
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     map[*Interaction]bool
+	dirty    bool
+}
+
+var _ http.RoundTripper = (*Recorder)(nil)
+
+// New 创建 Recorder；ModeReplay 下文件须存在，ModeReplayOrRecord 下文件存在时加载
+func New(cfg Config) (*Recorder, error) {
+	if format(cfg.Path) == "" {
+		return nil, fmt.Errorf("cassette: path %q must end with .yaml, .yml or .json", cfg.Path)
+	}
+	if cfg.Transport == nil {
+		cfg.Transport = http.DefaultTransport
+	}
+	if len(cfg.Matchers) == 0 {
+		cfg.Matchers = DefaultMatchers
+	}
+
+	r := &Recorder{
+		cfg:         cfg,
+		headers:     append(append([]string{}, DefaultScrubHeaders...), cfg.ScrubHeaders...),
+		query:       append(append([]string{}, DefaultScrubQuery...), cfg.ScrubQuery...),
+		respHeaders: append(append([]string{}, DefaultScrubResponseHeaders...), cfg.ScrubResponseHeaders...),
+		cassette:    &Cassette{Version: Version},
+		used:        make(map[*Interaction]bool),
+	}
+
+	load := cfg.Mode == ModeReplay
+	if cfg.Mode == ModeReplayOrRecord {
+		ok, err := exists(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: %w", err)
+		}
+		load = ok
+	}
+	if load {
+		c, err := Load(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = c
+	}
+	return r, nil
+}
+
+// Client 返回使用该 Recorder 的 http.Client
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions 返回当前全部交互（已加载与新录制的）
+func (r *Recorder) Interactions() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*Interaction(nil), r.cassette.Interactions...)
+}
+
+// Save 将录制的交互写入文件；没有新录制的交互时不写入
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.dirty {
+		return nil
+	}
+	if err := r.cassette.Save(r.cfg.Path); err != nil {
+		return err
+	}
+	r.dirty = false
+	return nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cfg.Mode != ModeRecord {
+		if it := r.match(req, body); it != nil {
+			return replay(req, it.Response), nil
+		}
+		if r.cfg.Mode == ModeReplay {
+			return nil, fmt.Errorf("%w for %s %s", ErrNoInteraction, req.Method, scrubURL(req.URL, r.query))
+		}
+	}
+	return r.record(req, body)
+}
+
+// match 返回匹配的交互，优先返回尚未使用过的，使重复的相同请求按录制顺序回放
+func (r *Recorder) match(req *http.Request, body []byte) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var reused *Interaction
+	for _, it := range r.cassette.Interactions {
+		if !r.matches(req, body, it.Request) {
+			continue
+		}
+		if !r.used[it] {
+			r.used[it] = true
+			return it
+		}
+		if reused == nil {
+			reused = it
+		}
+	}
+	return reused
+}
+
+func (r *Recorder) matches(req *http.Request, body []byte, rec Request) bool {
+	for _, m := range r.cfg.Matchers {
+		if !m(req, body, rec) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := r.cfg.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     scrubURL(req.URL, r.query).String(),
+			Headers: scrubHeaders(req.Header, r.headers),
+			Body:    string(body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    scrubHeaders(resp.Header, r.respHeaders),
+		},
+	}
+	resp.Body = &recordingBody{ReadCloser: resp.Body, done: func(b []byte) {
+		it.Response.Body = string(b)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.cassette.Interactions = append(r.cassette.Interactions, it)
+		r.used[it] = true
+		r.dirty = true
+	}}
+	return resp, nil
+}
+
+// readBody 读取并还原请求体
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	b, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cassette: read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+func replay(req *http.Request, rec Response) *http.Response {
+	h := rec.Headers.Clone()
+	if h == nil {
+		h = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.StatusCode, http.StatusText(rec.StatusCode)),
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(strings.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}
+}
+
+// recordingBody 在响应体读取到结尾或关闭时完成录制，调用方仍按流式读取
+type recordingBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func([]byte)
+
+	// mu 保护 buf，Close 可能与 Read 并发调用
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	b.buf.Write(p[:n])
+	b.mu.Unlock()
+	if err != nil {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *recordingBody) finish() {
+	b.once.Do(func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.done(b.buf.Bytes())
+	})
+}
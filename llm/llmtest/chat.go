@@ -0,0 +1,374 @@
+// Package llmtest 提供 llm.ChatModel 与 llm.Embedder 的测试替身。
+//
+// FakeChatModel 按脚本或谓词返回预设的回复（文本、工具调用、错误、分块流），并记录收到的消息与解析后的 ChatConfig；
+// FakeEmbedder 将文本哈希为确定性的向量。HTTP 层的录制与回放见子包 cassette。
+package llmtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// ErrUnexpectedCall 没有可用的脚本回复，也没有匹配的规则
+var ErrUnexpectedCall = errors.New("llmtest: unexpected call")
+
+// DefaultChunkSize 由 Response 生成流时每个 delta 事件的文本长度（按 rune）
+const DefaultChunkSize = 4
+
+// Reply 一次预设的回复
+type Reply struct {
+	// Response 非流式调用返回的响应；流式调用时按 ChunkSize 拆分为事件（Events 为空时）
+	Response schema.ChatResponse
+
+	// Err 非 nil 时 Chat / ChatStream 直接返回该错误
+	Err error
+
+	// Events 流式调用依次返回的事件，为空时由 Response 生成
+	Events []schema.StreamEvent
+
+	// StreamErr 流式调用在全部事件之后返回的错误，用于模拟中途出错，默认 io.EOF
+	StreamErr error
+
+	// ChunkSize 由 Response 生成流时每个 delta 事件的文本长度（按 rune），默认 DefaultChunkSize
+	ChunkSize int
+
+	// Delay 返回响应（或建立流）前的等待时间
+	Delay time.Duration
+
+	// ChunkDelay 流式调用每个事件之前的等待时间
+	ChunkDelay time.Duration
+}
+
+// Text 返回内容为 text 的回复
+func Text(text string) Reply {
+	return Reply{Response: response(schema.AssistantMessage(text), schema.FinishReasonStop)}
+}
+
+// ToolCalls 返回调用工具的回复
+func ToolCalls(calls ...schema.ToolCall) Reply {
+	msg := schema.Message{Role: schema.RoleAssistant, ToolCalls: calls}
+	return Reply{Response: response(msg, schema.FinishReasonToolCalls)}
+}
+
+// Error 返回以 err 失败的回复
+func Error(err error) Reply {
+	return Reply{Err: err}
+}
+
+// WithUsage 设置回复的用量
+func (r Reply) WithUsage(u schema.Usage) Reply {
+	r.Response.Usage = u
+	return r
+}
+
+// ToolCall 构造工具调用，args 为 string / []byte 时原样作为参数，否则编码为 JSON
+func ToolCall(id, name string, args any) schema.ToolCall {
+	var s string
+	switch v := args.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			panic(fmt.Sprintf("llmtest: marshal tool arguments: %v", err))
+		}
+		s = string(b)
+	}
+	return schema.ToolCall{
+		ID:       id,
+		Type:     schema.ToolCallTypeFunction,
+		Function: schema.ToolFunction{Name: name, Arguments: s},
+	}
+}
+
+// APIError 构造 *llm.APIError，Message 为状态码的描述
+func APIError(status int, code string) *llm.APIError {
+	return &llm.APIError{StatusCode: status, Code: code, Message: http.StatusText(status)}
+}
+
+// RateLimit 构造 429 限流错误，retryAfter 对应 Retry-After 响应头
+func RateLimit(retryAfter time.Duration) *llm.APIError {
+	e := APIError(http.StatusTooManyRequests, "rate_limit_exceeded")
+	e.RetryAfter = retryAfter
+	return e
+}
+
+func response(msg schema.Message, finish schema.FinishReason) schema.ChatResponse {
+	return schema.ChatResponse{Choices: []schema.Choice{{Message: msg, FinishReason: finish}}}
+}
+
+// Call 一次收到的调用
+type Call struct {
+	Messages []schema.Message
+	// Config 由调用选项解析出的配置
+	Config llm.ChatConfig
+	Stream bool
+}
+
+// LastMessage 返回最后一条消息，没有消息时为零值
+func (c Call) LastMessage() schema.Message {
+	if len(c.Messages) == 0 {
+		return schema.Message{}
+	}
+	return c.Messages[len(c.Messages)-1]
+}
+
+// Matcher 判断调用是否匹配规则
+type Matcher func(Call) bool
+
+// LastMessageContains 匹配最后一条消息的文本包含 substr 的调用
+func LastMessageContains(substr string) Matcher {
+	return func(c Call) bool { return strings.Contains(c.LastMessage().Text(), substr) }
+}
+
+// ModelIs 匹配 llm.WithModel 为 model 的调用
+func ModelIs(model string) Matcher {
+	return func(c Call) bool { return c.Config.Model == model }
+}
+
+// HasTool 匹配提供了名为 name 的工具的调用
+func HasTool(name string) Matcher {
+	return func(c Call) bool {
+		return slices.ContainsFunc(c.Config.Tools, func(t schema.Tool) bool { return t.Function.Name == name })
+	}
+}
+
+// HasToolResult 匹配包含工具结果消息（即处于工具调用循环中）的调用
+func HasToolResult() Matcher {
+	return func(c Call) bool {
+		return slices.ContainsFunc(c.Messages, func(m schema.Message) bool { return m.Role == schema.RoleTool })
+	}
+}
+
+type rule struct {
+	match Matcher
+	reply Reply
+}
+
+// FakeChatModel 可编排的 llm.ChatModel 测试替身，可并发使用。
+//
+// 每次调用先按注册顺序查找匹配的规则（When），其次依次消耗脚本回复（NewFakeChatModel / Enqueue），
+// 都没有时返回 ErrUnexpectedCall。
+type FakeChatModel struct {
+	// ProviderName Provider() 的返回值，默认 llm.ProviderUnknown
+	ProviderName llm.Provider
+
+	mu     sync.Mutex
+	script []Reply
+	rules  []rule
+	calls  []Call
+}
+
+var (
+	_ llm.ChatModel     = (*FakeChatModel)(nil)
+	_ llm.ProviderNamer = (*FakeChatModel)(nil)
+)
+
+// NewFakeChatModel 创建按顺序返回 replies 的 FakeChatModel
+func NewFakeChatModel(replies ...Reply) *FakeChatModel {
+	return &FakeChatModel{script: replies}
+}
+
+// Enqueue 追加脚本回复
+func (m *FakeChatModel) Enqueue(replies ...Reply) *FakeChatModel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.script = append(m.script, replies...)
+	return m
+}
+
+// When 注册规则：匹配 match 的调用返回 reply，可重复匹配，优先于脚本回复
+func (m *FakeChatModel) When(match Matcher, reply Reply) *FakeChatModel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rules = append(m.rules, rule{match: match, reply: reply})
+	return m
+}
+
+// Calls 返回收到的全部调用
+func (m *FakeChatModel) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.calls)
+}
+
+// LastCall 返回最后一次调用，没有调用时 ok 为 false
+func (m *FakeChatModel) LastCall() (Call, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.calls) == 0 {
+		return Call{}, false
+	}
+	return m.calls[len(m.calls)-1], true
+}
+
+// Remaining 返回尚未消耗的脚本回复数
+func (m *FakeChatModel) Remaining() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.script)
+}
+
+func (m *FakeChatModel) Provider() llm.Provider {
+	if m.ProviderName == "" {
+		return llm.ProviderUnknown
+	}
+	return m.ProviderName
+}
+
+func (m *FakeChatModel) Chat(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+	reply, cfg, err := m.next(messages, opts, false)
+	if err != nil {
+		return schema.ChatResponse{}, err
+	}
+	if err := sleep(ctx, reply.Delay); err != nil {
+		return schema.ChatResponse{}, err
+	}
+	if reply.Err != nil {
+		return schema.ChatResponse{}, m.withProvider(reply.Err)
+	}
+	return m.finish(reply.Response, cfg), nil
+}
+
+func (m *FakeChatModel) ChatStream(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (llm.Stream, error) {
+	reply, cfg, err := m.next(messages, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := sleep(ctx, reply.Delay); err != nil {
+		return nil, err
+	}
+	if reply.Err != nil {
+		return nil, m.withProvider(reply.Err)
+	}
+
+	events := slices.Clone(reply.Events)
+	if len(events) == 0 {
+		events = Chunk(m.finish(reply.Response, cfg), reply.ChunkSize)
+	}
+	end := io.EOF
+	if reply.StreamErr != nil {
+		end = m.withProvider(reply.StreamErr)
+	}
+	return &fakeStream{ctx: ctx, events: events, end: end, delay: reply.ChunkDelay}, nil
+}
+
+func (m *FakeChatModel) next(messages []schema.Message, opts []llm.ChatOption, stream bool) (Reply, llm.ChatConfig, error) {
+	call := Call{Messages: slices.Clone(messages), Config: llm.ApplyChatOptions(opts...), Stream: stream}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, call)
+	for _, r := range m.rules {
+		if r.match(call) {
+			return r.reply, call.Config, nil
+		}
+	}
+	if len(m.script) > 0 {
+		r := m.script[0]
+		m.script = m.script[1:]
+		return r, call.Config, nil
+	}
+	return Reply{}, call.Config, fmt.Errorf("%w #%d: last message %q", ErrUnexpectedCall, len(m.calls), call.LastMessage().Text())
+}
+
+// finish 补全响应中未设置的字段：ID、Model（取 llm.WithModel）、Choice 的角色
+func (m *FakeChatModel) finish(resp schema.ChatResponse, cfg llm.ChatConfig) schema.ChatResponse {
+	m.mu.Lock()
+	n := len(m.calls)
+	m.mu.Unlock()
+
+	if resp.ID == "" {
+		resp.ID = fmt.Sprintf("fake-%d", n)
+	}
+	if resp.Model == "" {
+		resp.Model = cfg.Model
+	}
+	resp.Choices = slices.Clone(resp.Choices)
+	for i := range resp.Choices {
+		if resp.Choices[i].Message.Role == "" {
+			resp.Choices[i].Message.Role = schema.RoleAssistant
+		}
+	}
+	return resp
+}
+
+// withProvider 为未设置 Provider 的 *llm.APIError 填充 provider（不修改脚本中的原值）
+func (m *FakeChatModel) withProvider(err error) error {
+	if ae, ok := err.(*llm.APIError); ok && ae.Provider == "" {
+		cp := *ae
+		cp.Provider = m.Provider()
+		return &cp
+	}
+	return err
+}
+
+// Chunk 将完整响应拆分为流式事件，拆分方式见 llm.ResponseEvents；size <= 0 时为 DefaultChunkSize
+func Chunk(resp schema.ChatResponse, size int) []schema.StreamEvent {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	return llm.ResponseEvents(resp, size)
+}
+
+type fakeStream struct {
+	ctx    context.Context
+	events []schema.StreamEvent
+	end    error
+	delay  time.Duration
+	closed atomic.Bool
+}
+
+func (s *fakeStream) Recv() (schema.StreamEvent, error) {
+	if s.closed.Load() {
+		return schema.StreamEvent{}, llm.ErrStreamClosed
+	}
+	if len(s.events) == 0 {
+		return schema.StreamEvent{}, s.end
+	}
+	if err := sleep(s.ctx, s.delay); err != nil {
+		return schema.StreamEvent{}, err
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func (s *fakeStream) Close() error {
+	s.closed.Store(true)
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
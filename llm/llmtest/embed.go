@@ -0,0 +1,159 @@
+package llmtest
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// DefaultDimensions FakeEmbedder 默认的向量维度
+const DefaultDimensions = 64
+
+// FakeEmbedder 确定性的 llm.Embedder 测试替身，可并发使用。
+//
+// 文本按词（小写，以非字母数字字符分隔，汉字逐字）哈希到各维度并归一化为单位向量：
+// 相同文本得到相同向量，共享词越多的文本余弦相似度越高，便于测试检索与排序逻辑。
+// 空文本得到零向量。
+type FakeEmbedder struct {
+	// Dimensions 向量维度，默认 DefaultDimensions
+	Dimensions int
+
+	// ProviderName Provider() 的返回值，默认 llm.ProviderUnknown
+	ProviderName llm.Provider
+
+	// Err 非 nil 时 Embed 返回该错误
+	Err error
+
+	mu    sync.Mutex
+	calls [][]string
+}
+
+var (
+	_ llm.Embedder      = (*FakeEmbedder)(nil)
+	_ llm.ProviderNamer = (*FakeEmbedder)(nil)
+)
+
+func (e *FakeEmbedder) Provider() llm.Provider {
+	if e.ProviderName == "" {
+		return llm.ProviderUnknown
+	}
+	return e.ProviderName
+}
+
+// Calls 返回每次调用的输入
+func (e *FakeEmbedder) Calls() [][]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return slices.Clone(e.calls)
+}
+
+// Embed 返回各输入的向量，Usage.PromptTokens 为输入的词数之和
+func (e *FakeEmbedder) Embed(ctx context.Context, inputs []string, opts ...llm.EmbeddingOption) (schema.EmbeddingResponse, error) {
+	e.mu.Lock()
+	e.calls = append(e.calls, slices.Clone(inputs))
+	e.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return schema.EmbeddingResponse{}, err
+	}
+	if e.Err != nil {
+		return schema.EmbeddingResponse{}, e.Err
+	}
+
+	resp := schema.EmbeddingResponse{Model: llm.ApplyEmbeddingOptions(opts...).Model}
+	for i, in := range inputs {
+		words := tokenize(in)
+		resp.Data = append(resp.Data, schema.Embedding{Index: i, Vector: e.vector(words)})
+		resp.Usage.PromptTokens += len(words)
+	}
+	resp.Usage.TotalTokens = resp.Usage.PromptTokens
+	return resp, nil
+}
+
+// Vector 返回 text 的向量，与 Embed 的结果相同
+func (e *FakeEmbedder) Vector(text string) []float64 {
+	return e.vector(tokenize(text))
+}
+
+func (e *FakeEmbedder) vector(words []string) []float64 {
+	dims := e.Dimensions
+	if dims <= 0 {
+		dims = DefaultDimensions
+	}
+	v := make([]float64, dims)
+	for _, w := range words {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(w))
+		sum := h.Sum64()
+		// 低位决定维度，最高位决定符号，减少不同词之间的相互抵消
+		sign := 1.0
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		v[sum%uint64(dims)] += sign
+	}
+
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range v {
+			v[i] /= norm
+		}
+	}
+	return v
+}
+
+// tokenize 按非字母数字字符分词，汉字逐字成词
+func tokenize(s string) []string {
+	var (
+		words []string
+		cur   strings.Builder
+	)
+	flush := func() {
+		if cur.Len() > 0 {
+			words = append(words, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			words = append(words, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			cur.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words
+}
+
+// Cosine 返回两个向量的余弦相似度，任一向量为零向量或长度不同时为 0
+func Cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,209 @@
+package llmtest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/llmtest"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+func TestFakeChatModel_Script(t *testing.T) {
+	t.Parallel()
+
+	m := llmtest.NewFakeChatModel(
+		llmtest.ToolCalls(llmtest.ToolCall("call_1", "get_weather", map[string]string{"city": "北京"})),
+		llmtest.Text("晴").WithUsage(schema.Usage{TotalTokens: 7}),
+	)
+	m.ProviderName = llm.ProviderDeepSeek
+	ctx := context.Background()
+
+	resp, err := m.Chat(ctx, []schema.Message{schema.UserMessage("天气？")}, llm.WithModel("deepseek-chat"), llm.WithTemperature(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := resp.Choices[0].Message.ToolCalls
+	if len(tc) != 1 || tc[0].Function.Arguments != `{"city":"北京"}` || resp.Choices[0].FinishReason != schema.FinishReasonToolCalls {
+		t.Errorf("tool call response = %+v", resp)
+	}
+	if resp.Model != "deepseek-chat" || resp.ID == "" || resp.Choices[0].Message.Role != schema.RoleAssistant {
+		t.Errorf("filled fields = %q %q %q", resp.Model, resp.ID, resp.Choices[0].Message.Role)
+	}
+
+	resp, err = m.Chat(ctx, []schema.Message{schema.ToolResultMessage("call_1", "sunny")})
+	if err != nil || resp.Choices[0].Message.Text() != "晴" || resp.Usage.TotalTokens != 7 {
+		t.Errorf("text response = %+v, %v", resp, err)
+	}
+
+	if _, err := m.Chat(ctx, []schema.Message{schema.UserMessage("again")}); !errors.Is(err, llmtest.ErrUnexpectedCall) {
+		t.Errorf("exhausted script err = %v", err)
+	}
+
+	calls := m.Calls()
+	if len(calls) != 3 || calls[0].Config.Temperature == nil || *calls[0].Config.Temperature != 0 {
+		t.Fatalf("calls = %+v", calls)
+	}
+	if last, ok := m.LastCall(); !ok || last.LastMessage().Text() != "again" {
+		t.Errorf("LastCall() = %+v", last)
+	}
+	if llm.ProviderOf(m) != llm.ProviderDeepSeek || m.Remaining() != 0 {
+		t.Errorf("provider = %s, remaining = %d", llm.ProviderOf(m), m.Remaining())
+	}
+}
+
+func TestFakeChatModel_When(t *testing.T) {
+	t.Parallel()
+
+	tool := schema.Tool{Type: schema.ToolTypeFunction, Function: schema.FunctionDefinition{Name: "search"}}
+	m := llmtest.NewFakeChatModel(llmtest.Text("scripted")).
+		When(llmtest.LastMessageContains("hello"), llmtest.Text("hi")).
+		When(llmtest.HasTool("search"), llmtest.Text("with tool")).
+		When(llmtest.ModelIs("broken"), llmtest.Error(llmtest.RateLimit(2*time.Second)))
+	ctx := context.Background()
+
+	for range 2 {
+		if resp, _ := m.Chat(ctx, []schema.Message{schema.UserMessage("hello there")}); resp.Choices[0].Message.Text() != "hi" {
+			t.Errorf("rule reply = %+v", resp)
+		}
+	}
+	if resp, _ := m.Chat(ctx, nil, llm.WithTools(tool)); resp.Choices[0].Message.Text() != "with tool" {
+		t.Errorf("HasTool reply = %+v", resp)
+	}
+
+	_, err := m.Chat(ctx, nil, llm.WithModel("broken"))
+	ae, ok := llm.AsAPIError(err)
+	if !ok || !llm.IsRateLimit(err) || ae.RetryAfter != 2*time.Second || ae.Provider != llm.ProviderUnknown {
+		t.Errorf("APIError = %+v", ae)
+	}
+
+	// 规则不消耗脚本
+	if resp, _ := m.Chat(ctx, nil); resp.Choices[0].Message.Text() != "scripted" {
+		t.Errorf("scripted reply = %+v", resp)
+	}
+}
+
+func TestFakeChatModel_Stream(t *testing.T) {
+	t.Parallel()
+
+	reply := llmtest.Text("Hello, world")
+	reply.Response.Choices[0].Message.ReasoningContent = "hmm"
+	reply.ChunkSize = 5
+	m := llmtest.NewFakeChatModel(reply)
+
+	stream, err := m.ChatStream(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deltas []string
+	for ev, err := range llm.Events(context.Background(), stream) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Delta != "" {
+			deltas = append(deltas, ev.Delta)
+		}
+	}
+	if len(deltas) != 3 || deltas[0] != "Hello" || deltas[2] != "ld" {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if call, _ := m.LastCall(); !call.Stream {
+		t.Error("call should be marked as stream")
+	}
+}
+
+func TestFakeChatModel_StreamError(t *testing.T) {
+	t.Parallel()
+
+	reply := llmtest.Text("partial")
+	reply.StreamErr = llmtest.APIError(503, "overloaded")
+	m := llmtest.NewFakeChatModel(reply, llmtest.Error(llmtest.APIError(401, "invalid_api_key")))
+
+	stream, _ := m.ChatStream(context.Background(), nil)
+	resp, err := llm.Accumulate(stream)
+	if !llm.IsTemporary(err) || resp.Choices[0].Message.Text() != "partial" {
+		t.Errorf("Accumulate() = %+v, %v", resp, err)
+	}
+	if _, err := m.ChatStream(context.Background(), nil); !llm.IsAuth(err) {
+		t.Errorf("open err = %v", err)
+	}
+}
+
+func TestFakeChatModel_Delay(t *testing.T) {
+	t.Parallel()
+
+	reply := llmtest.Text("slow")
+	reply.ChunkDelay = time.Hour
+	m := llmtest.NewFakeChatModel(reply)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	stream, err := m.ChatStream(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Recv() err = %v", err)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	t.Parallel()
+
+	resp := llmtest.ToolCalls(
+		llmtest.ToolCall("a", "f", `{}`),
+		llmtest.ToolCall("b", "g", []byte(`{"x":1}`)),
+	).WithUsage(schema.Usage{TotalTokens: 3}).Response
+
+	events := llmtest.Chunk(resp, 0)
+	var acc llm.Accumulator
+	for _, ev := range events {
+		acc.Add(ev)
+	}
+	got := acc.Response()
+	calls := got.Choices[0].Message.ToolCalls
+	if len(calls) != 2 || calls[1].ID != "b" || calls[1].Function.Arguments != `{"x":1}` || got.Usage.TotalTokens != 3 {
+		t.Errorf("accumulated = %+v", got)
+	}
+	if last := events[len(events)-1]; last.Type != schema.StreamEventDone || *last.FinishReason != schema.FinishReasonToolCalls {
+		t.Errorf("last event = %+v", last)
+	}
+}
+
+func TestFakeEmbedder(t *testing.T) {
+	t.Parallel()
+
+	e := &llmtest.FakeEmbedder{Dimensions: 256}
+	resp, err := e.Embed(context.Background(), []string{
+		"The quick brown fox",
+		"the QUICK brown fox!",
+		"a quick brown dog",
+		"interest rates rise",
+		"",
+	}, llm.WithModel("fake"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := func(i int) []float64 { return resp.Data[i].Vector }
+
+	if len(v(0)) != 256 || llmtest.Cosine(v(0), v(1)) < 0.999 {
+		t.Errorf("same words should give the same vector, cos = %v", llmtest.Cosine(v(0), v(1)))
+	}
+	if near, far := llmtest.Cosine(v(0), v(2)), llmtest.Cosine(v(0), v(3)); near <= far {
+		t.Errorf("cos(similar) = %v, cos(unrelated) = %v", near, far)
+	}
+	if llmtest.Cosine(v(0), v(4)) != 0 || resp.Model != "fake" || resp.Usage.PromptTokens != 15 {
+		t.Errorf("resp = %+v", resp)
+	}
+	if got := e.Vector("the quick brown fox"); llmtest.Cosine(got, v(0)) < 0.999 {
+		t.Error("Vector() should match Embed()")
+	}
+
+	e.Err = io.ErrUnexpectedEOF
+	if _, err := e.Embed(context.Background(), []string{"x"}); !errors.Is(err, io.ErrUnexpectedEOF) || len(e.Calls()) != 2 {
+		t.Errorf("err = %v, calls = %d", err, len(e.Calls()))
+	}
+}
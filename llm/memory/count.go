@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"github.com/lgc202/go-kit/llm/schema"
+	"github.com/lgc202/go-kit/llm/tokenizer"
+)
+
+// Counter 计算一组消息的 token 数。
+//
+// 内置策略假定计数对消息可加：一组消息的计数等于各条消息计数之和加上 CountMessages(nil) 的固定开销，
+// 据此每组只计数一次
+type Counter interface {
+	CountMessages(messages []schema.Message) int
+}
+
+// CounterFunc 函数形式的 Counter
+type CounterFunc func(messages []schema.Message) int
+
+func (f CounterFunc) CountMessages(messages []schema.Message) int { return f(messages) }
+
+// TokenizerCounter 使用 t 按 tokenizer.CountMessages 计数，t 为对应模型的 *tokenizer.BPE 时结果与 OpenAI 一致
+func TokenizerCounter(t tokenizer.Tokenizer) Counter {
+	return CounterFunc(func(messages []schema.Message) int {
+		return tokenizer.CountMessages(t, messages, nil)
+	})
+}
+
+// EstimateCounter 不依赖词表的粗略估算（tokenizer.Heuristic）
+var EstimateCounter = TokenizerCounter(tokenizer.Heuristic{})
@@ -0,0 +1,124 @@
+// Package memory 保存多轮对话历史，并在每次调用模型前按 token 预算裁剪，避免超出模型上下文窗口。
+//
+// 裁剪以"消息组"为单位：带 ToolCalls 的 assistant 消息与其后对应的 tool 结果消息属于同一组，
+// 总是一起保留或一起移除，不会产生没有对应工具调用的 tool 消息。
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+type Config struct {
+	// MaxTokens 历史消息的 token 预算，必填。应为模型上下文窗口减去工具定义与回复所需的 token
+	MaxTokens int
+
+	// Strategy 超出预算时的裁剪方式，默认 SlidingWindow()
+	Strategy Strategy
+
+	// Counter 计算消息 token 数，默认 EstimateCounter；需要精确计数时使用 TokenizerCounter
+	Counter Counter
+}
+
+// Memory 一段对话的历史，可并发使用
+type Memory struct {
+	mu       sync.Mutex
+	messages []schema.Message
+	budget   int
+	strategy Strategy
+	counter  Counter
+}
+
+func New(cfg Config) (*Memory, error) {
+	if cfg.MaxTokens <= 0 {
+		return nil, fmt.Errorf("memory: MaxTokens must be positive")
+	}
+	m := &Memory{
+		budget:   cfg.MaxTokens,
+		strategy: cfg.Strategy,
+		counter:  cfg.Counter,
+	}
+	if m.strategy == nil {
+		m.strategy = SlidingWindow()
+	}
+	if m.counter == nil {
+		m.counter = EstimateCounter
+	}
+	return m, nil
+}
+
+// Add 追加消息
+func (m *Memory) Add(messages ...schema.Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, messages...)
+}
+
+// Messages 返回当前保存的全部消息（副本）
+func (m *Memory) Messages() []schema.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.messages)
+}
+
+// Tokens 返回当前保存的消息的 token 数
+func (m *Memory) Tokens() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.counter.CountMessages(m.messages)
+}
+
+// Reset 清空历史
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
+
+// Context 返回用于下一次请求的历史。
+//
+// 超出预算时按 Strategy 裁剪，裁剪结果会替换保存的历史，之后的消息在此基础上追加
+// （Summarize 因此只需总结新移出窗口的消息）。
+func (m *Memory) Context(ctx context.Context) ([]schema.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.counter.CountMessages(m.messages) > m.budget {
+		trimmed, err := m.strategy.Trim(ctx, slices.Clone(m.messages), m.budget, m.counter)
+		if err != nil {
+			return nil, err
+		}
+		m.messages = trimmed
+	}
+	return slices.Clone(m.messages), nil
+}
+
+// Chat 以裁剪后的历史调用模型，并将回复追加到历史
+func (m *Memory) Chat(ctx context.Context, model llm.ChatModel, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+	messages, err := m.Context(ctx)
+	if err != nil {
+		return schema.ChatResponse{}, err
+	}
+	resp, err := model.Chat(ctx, messages, opts...)
+	if err != nil {
+		return resp, err
+	}
+	if len(resp.Choices) == 0 {
+		return resp, fmt.Errorf("memory: empty response")
+	}
+	msg := resp.Choices[0].Message
+	if msg.Role == "" {
+		msg.Role = schema.RoleAssistant
+	}
+	m.Add(msg)
+	return resp, nil
+}
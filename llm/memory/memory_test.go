@@ -0,0 +1,259 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// perMessage 每条消息计 10 个 token，便于推算裁剪结果
+var perMessage = CounterFunc(func(messages []schema.Message) int { return 10 * len(messages) })
+
+func toolCall(id string) schema.Message {
+	return schema.Message{Role: schema.RoleAssistant, ToolCalls: []schema.ToolCall{{
+		ID: id, Type: schema.ToolCallTypeFunction, Function: schema.ToolFunction{Name: "search", Arguments: `{"q":"` + id + `"}`},
+	}}}
+}
+
+// conversation 系统消息 + 两轮带工具调用的对话
+func conversation() []schema.Message {
+	return []schema.Message{
+		schema.SystemMessage("sys"),
+		schema.UserMessage("u1"),
+		toolCall("c1"),
+		schema.ToolResultMessage("c1", "r1"),
+		schema.AssistantMessage("a1"),
+		schema.UserMessage("u2"),
+		toolCall("c2"),
+		schema.ToolResultMessage("c2", "r2"),
+		schema.AssistantMessage("a2"),
+		schema.UserMessage("u3"),
+	}
+}
+
+// describe 将消息简写为 "role:text" 列表，工具调用写作 "call:ID"
+func describe(messages []schema.Message) string {
+	out := make([]string, len(messages))
+	for i, m := range messages {
+		switch {
+		case len(m.ToolCalls) > 0:
+			out[i] = "call:" + m.ToolCalls[0].ID
+		case m.Role == schema.RoleTool:
+			out[i] = "tool:" + m.ToolCallID
+		default:
+			out[i] = string(m.Role) + ":" + m.Text()
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// checkNoOrphans 每条 tool 消息之前都有包含其 ToolCallID 的 assistant 消息
+func checkNoOrphans(t *testing.T, messages []schema.Message) {
+	t.Helper()
+	for i, m := range messages {
+		if m.Role != schema.RoleTool {
+			continue
+		}
+		ok := slices.ContainsFunc(messages[:i], func(p schema.Message) bool {
+			return slices.ContainsFunc(p.ToolCalls, func(tc schema.ToolCall) bool { return tc.ID == m.ToolCallID })
+		})
+		if !ok {
+			t.Errorf("orphaned tool message %q in %s", m.ToolCallID, describe(messages))
+		}
+	}
+}
+
+func TestGroups(t *testing.T) {
+	t.Parallel()
+
+	msgs := conversation()
+	msgs = slices.Insert(msgs, 4, schema.ToolResultMessage("stray", "x"))
+	system, groups := Groups(msgs)
+	if describe(system) != "system:sys" {
+		t.Errorf("system = %s", describe(system))
+	}
+	var got []string
+	for _, g := range groups {
+		got = append(got, describe(g))
+	}
+	want := []string{"user:u1", "call:c1 tool:c1", "tool:stray", "assistant:a1", "user:u2", "call:c2 tool:c2", "assistant:a2", "user:u3"}
+	if !slices.Equal(got, want) {
+		t.Errorf("groups =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		strategy Strategy
+		budget   int
+		want     string
+	}{
+		{"sliding window keeps tool pairs", SlidingWindow(), 60, "system:sys user:u2 call:c2 tool:c2 assistant:a2 user:u3"},
+		// 预算内可以从 call:c2 开始，但不以 user 开头，继续移除到 u3
+		{"sliding window starts with user", SlidingWindow(), 50, "system:sys user:u3"},
+		{"sliding window keeps last group", SlidingWindow(), 5, "system:sys user:u3"},
+		{"keep first turn", KeepFirstTurn(), 60, "system:sys user:u1 call:c2 tool:c2 assistant:a2 user:u3"},
+		{"drop tool calls", DropToolCalls(), 60, "system:sys user:u1 assistant:a1 user:u2 assistant:a2 user:u3"},
+		{"drop tool calls then slide", DropToolCalls(), 40, "system:sys user:u2 assistant:a2 user:u3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tt.strategy.Trim(context.Background(), conversation(), tt.budget, perMessage)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if describe(got) != tt.want {
+				t.Errorf("Trim() =\n%s\nwant\n%s", describe(got), tt.want)
+			}
+			checkNoOrphans(t, got)
+		})
+	}
+}
+
+func TestStrategies_CountEachGroupOnce(t *testing.T) {
+	t.Parallel()
+
+	// 与 tokenizer.CountMessages 一样带固定的回复开销
+	var counted int
+	counter := CounterFunc(func(messages []schema.Message) int {
+		counted += len(messages)
+		return 3 + 10*len(messages)
+	})
+	msgs := []schema.Message{schema.SystemMessage("sys")}
+	for i := range 100 {
+		msgs = append(msgs, schema.UserMessage(fmt.Sprint("u", i)), schema.AssistantMessage(fmt.Sprint("a", i)))
+	}
+
+	for _, s := range []Strategy{SlidingWindow(), DropToolCalls()} {
+		counted = 0
+		got, err := s.Trim(context.Background(), msgs, 3+10*5, counter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := describe(got); d != "system:sys user:u98 assistant:a98 user:u99 assistant:a99" {
+			t.Errorf("Trim() = %s", d)
+		}
+		if counted > 2*len(msgs) {
+			t.Errorf("counted %d messages for %d input messages", counted, len(msgs))
+		}
+	}
+}
+
+// summaryModel 记录摘要请求并返回固定摘要
+type summaryModel struct {
+	requests []string
+	opts     []llm.ChatConfig
+}
+
+func (m *summaryModel) Chat(_ context.Context, messages []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+	m.requests = append(m.requests, messages[len(messages)-1].Text())
+	m.opts = append(m.opts, llm.ApplyChatOptions(opts...))
+	return schema.ChatResponse{Choices: []schema.Choice{{Message: schema.AssistantMessage("S" + string(rune('0'+len(m.requests))))}}}, nil
+}
+
+func (m *summaryModel) ChatStream(context.Context, []schema.Message, ...llm.ChatOption) (llm.Stream, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+
+	model := &summaryModel{}
+	s := Summarize(SummarizeConfig{Model: model, MaxSummaryTokens: 10})
+
+	got, err := s.Trim(context.Background(), conversation(), 70, perMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "system:sys system:" + SummaryPrefix + "S1 user:u2 call:c2 tool:c2 assistant:a2 user:u3"
+	if describe(got) != want {
+		t.Errorf("Trim() =\n%s\nwant\n%s", describe(got), want)
+	}
+	wantTranscript := "user: u1\nassistant called search({\"q\":\"c1\"})\ntool result: r1\nassistant: a1\n"
+	if model.requests[0] != wantTranscript {
+		t.Errorf("transcript =\n%s\nwant\n%s", model.requests[0], wantTranscript)
+	}
+	if mt := model.opts[0].MaxTokens; mt == nil || *mt != 10 {
+		t.Errorf("max tokens = %v", mt)
+	}
+
+	// 再次裁剪时合并之前的摘要
+	got = append(got, schema.AssistantMessage("a3"), schema.UserMessage("u4"))
+	got, err = s.Trim(context.Background(), got, 50, perMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "system:sys system:" + SummaryPrefix + "S2 user:u3 assistant:a3 user:u4"; describe(got) != want {
+		t.Errorf("second Trim() =\n%s\nwant\n%s", describe(got), want)
+	}
+	if !strings.HasPrefix(model.requests[1], "Earlier summary:\nS1\n\nConversation:\nuser: u2\n") {
+		t.Errorf("second request =\n%s", model.requests[1])
+	}
+	checkNoOrphans(t, got)
+}
+
+type replyModel struct{ seen [][]schema.Message }
+
+func (m *replyModel) Chat(_ context.Context, messages []schema.Message, _ ...llm.ChatOption) (schema.ChatResponse, error) {
+	m.seen = append(m.seen, messages)
+	return schema.ChatResponse{Choices: []schema.Choice{{Message: schema.Message{Content: []schema.ContentPart{schema.TextContent{Text: "ok"}}}}}}, nil
+}
+
+func (m *replyModel) ChatStream(context.Context, []schema.Message, ...llm.ChatOption) (llm.Stream, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestMemory_Chat(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(Config{}); err == nil {
+		t.Error("New() without MaxTokens should fail")
+	}
+
+	mem, err := New(Config{MaxTokens: 40, Counter: perMessage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := &replyModel{}
+	mem.Add(schema.SystemMessage("sys"))
+	for _, q := range []string{"u1", "u2", "u3"} {
+		mem.Add(schema.UserMessage(q))
+		if _, err := mem.Chat(context.Background(), model); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := describe(model.seen[2]); got != "system:sys user:u2 assistant:ok user:u3" {
+		t.Errorf("third request = %s", got)
+	}
+	if got := describe(mem.Messages()); got != "system:sys user:u2 assistant:ok user:u3 assistant:ok" {
+		t.Errorf("history = %s", got)
+	}
+	if mem.Tokens() != 50 {
+		t.Errorf("Tokens() = %d", mem.Tokens())
+	}
+	mem.Reset()
+	if len(mem.Messages()) != 0 {
+		t.Error("Reset() did not clear history")
+	}
+}
+
+func TestEstimateCounter(t *testing.T) {
+	t.Parallel()
+
+	n := EstimateCounter.CountMessages([]schema.Message{schema.UserMessage("hello world!"), schema.AssistantMessage("你好")})
+	// 3 + (3 + user 1 + 3) + (3 + assistant 3 + 2)
+	if n != 18 {
+		t.Errorf("CountMessages() = %d, want 18", n)
+	}
+}
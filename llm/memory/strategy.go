@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// Strategy 将超出预算的历史裁剪到 budget 以内。
+//
+// 实现应以消息组（见 Groups）为单位移除消息；无法裁剪到预算内时尽量接近预算，至少保留最后一组消息。
+type Strategy interface {
+	Trim(ctx context.Context, messages []schema.Message, budget int, counter Counter) ([]schema.Message, error)
+}
+
+// StrategyFunc 函数形式的 Strategy
+type StrategyFunc func(ctx context.Context, messages []schema.Message, budget int, counter Counter) ([]schema.Message, error)
+
+func (f StrategyFunc) Trim(ctx context.Context, messages []schema.Message, budget int, counter Counter) ([]schema.Message, error) {
+	return f(ctx, messages, budget, counter)
+}
+
+// SlidingWindow 保留开头的 system 消息，从最早的消息组开始移除，直到满足预算。
+// 移除后若开头不是 user 消息，继续移除到下一条 user 消息（部分 provider 要求对话以 user 消息开头）。
+func SlidingWindow() Strategy {
+	return StrategyFunc(func(_ context.Context, messages []schema.Message, budget int, counter Counter) ([]schema.Message, error) {
+		system, groups := Groups(messages)
+		return slide(system, groups, budget, counter, true), nil
+	})
+}
+
+// KeepFirstTurn 同 SlidingWindow，但始终保留第一条 user 消息（通常是任务描述）及其之前的消息
+func KeepFirstTurn() Strategy {
+	return StrategyFunc(func(_ context.Context, messages []schema.Message, budget int, counter Counter) ([]schema.Message, error) {
+		system, groups := Groups(messages)
+		k := slices.IndexFunc(groups, startsWithUser)
+		if k < 0 {
+			return slide(system, groups, budget, counter, true), nil
+		}
+		pinned := slices.Concat(system, flatten(groups[:k+1]))
+		return slide(pinned, groups[k+1:], budget, counter, false), nil
+	})
+}
+
+// DropToolCalls 先从最早的开始移除工具调用组（带 ToolCalls 的 assistant 消息及其 tool 结果），
+// 保留普通问答；仍超出预算时按 SlidingWindow 裁剪。最后一组消息不会被移除。
+func DropToolCalls() Strategy {
+	return StrategyFunc(func(_ context.Context, messages []schema.Message, budget int, counter Counter) ([]schema.Message, error) {
+		system, groups := Groups(messages)
+		total, sizes := measure(system, groups, counter)
+		kept := groups[:0:0]
+		for i, g := range groups {
+			if total > budget && i < len(groups)-1 && isToolGroup(g) {
+				total -= sizes[i]
+				continue
+			}
+			kept = append(kept, g)
+		}
+		groups = kept
+		return slide(system, groups, budget, counter, true), nil
+	})
+}
+
+// Groups 将消息分为开头的 system 消息与其后的消息组。
+//
+// 带 ToolCalls 的 assistant 消息与紧随其后、ToolCallID 属于这些调用的 tool 消息组成一组，其余消息各自成组。
+func Groups(messages []schema.Message) (system []schema.Message, groups [][]schema.Message) {
+	i := 0
+	for i < len(messages) && messages[i].Role == schema.RoleSystem {
+		i++
+	}
+	system = messages[:i:i]
+
+	for i < len(messages) {
+		m := messages[i]
+		j := i + 1
+		if m.Role == schema.RoleAssistant && len(m.ToolCalls) > 0 {
+			for j < len(messages) && messages[j].Role == schema.RoleTool && hasToolCall(m.ToolCalls, messages[j].ToolCallID) {
+				j++
+			}
+		}
+		groups = append(groups, messages[i:j:j])
+		i = j
+	}
+	return system, groups
+}
+
+// slide 从最早的消息组开始移除直到满足预算，至少保留最后一组
+func slide(pinned []schema.Message, groups [][]schema.Message, budget int, counter Counter, userFirst bool) []schema.Message {
+	total, sizes := measure(pinned, groups, counter)
+	start := 0
+	for start < len(groups)-1 && total > budget {
+		total -= sizes[start]
+		start++
+	}
+	if userFirst && start > 0 {
+		if k := slices.IndexFunc(groups[start:], startsWithUser); k > 0 {
+			start += k
+		}
+	}
+	return join(pinned, groups[start:])
+}
+
+// measure 只对每组计数一次：total 为 pinned 与全部消息组的总数，sizes[i] 为移除第 i 组后总数的减少量。
+//
+// 组的计数扣除空消息列表的计数（回复起始等固定开销），要求 counter 对消息可加，
+// TokenizerCounter 与 EstimateCounter 均满足
+func measure(pinned []schema.Message, groups [][]schema.Message, counter Counter) (total int, sizes []int) {
+	base := counter.CountMessages(nil)
+	total = counter.CountMessages(pinned)
+	sizes = make([]int, len(groups))
+	for i, g := range groups {
+		sizes[i] = counter.CountMessages(g) - base
+		total += sizes[i]
+	}
+	return total, sizes
+}
+
+func join(pinned []schema.Message, groups [][]schema.Message) []schema.Message {
+	return slices.Concat(pinned, flatten(groups))
+}
+
+func flatten(groups [][]schema.Message) []schema.Message {
+	return slices.Concat(groups...)
+}
+
+func startsWithUser(g []schema.Message) bool {
+	return g[0].Role == schema.RoleUser
+}
+
+func isToolGroup(g []schema.Message) bool {
+	return len(g[0].ToolCalls) > 0
+}
+
+func hasToolCall(calls []schema.ToolCall, id string) bool {
+	// 部分 provider（如 Gemini、Ollama）的工具调用没有 ID，此时按位置归组
+	if id == "" {
+		return true
+	}
+	return slices.ContainsFunc(calls, func(tc schema.ToolCall) bool { return tc.ID == id })
+}
@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// SummaryPrefix Summarize 生成的摘要消息的开头，用于在下一次裁剪时识别并合并之前的摘要
+const SummaryPrefix = "Summary of the earlier conversation:\n"
+
+// DefaultSummaryPrompt 未设置 SummarizeConfig.Prompt 时使用的系统提示词
+const DefaultSummaryPrompt = "Summarize the conversation below so that the summary can replace it as context for continuing the conversation. " +
+	"Keep facts, decisions, user preferences, open tasks and tool results that may matter later. " +
+	"Be concise and write in the language of the conversation."
+
+type SummarizeConfig struct {
+	// Model 生成摘要的模型，必填
+	Model llm.ChatModel
+
+	// Prompt 摘要的系统提示词，默认 DefaultSummaryPrompt
+	Prompt string
+
+	// MaxSummaryTokens 为摘要预留的 token 数，同时作为摘要请求的 max_tokens，默认为预算的 1/4
+	MaxSummaryTokens int
+
+	// ChatOptions 摘要请求的选项
+	ChatOptions []llm.ChatOption
+}
+
+// Summarize 保留开头的 system 消息与尽可能多的最近消息组，将更早的消息（连同之前的摘要）交给模型总结，
+// 以一条 system 消息（以 SummaryPrefix 开头）放在其余 system 消息之后。摘要后仍超出预算时按 SlidingWindow 裁剪。
+func Summarize(cfg SummarizeConfig) Strategy {
+	return StrategyFunc(func(ctx context.Context, messages []schema.Message, budget int, counter Counter) ([]schema.Message, error) {
+		if cfg.Model == nil {
+			return nil, fmt.Errorf("memory: summarize model required")
+		}
+		reserve := cfg.MaxSummaryTokens
+		if reserve <= 0 {
+			reserve = budget / 4
+		}
+
+		system, groups := Groups(messages)
+		var previous string
+		system = slices.DeleteFunc(slices.Clone(system), func(m schema.Message) bool {
+			text, ok := strings.CutPrefix(m.Text(), SummaryPrefix)
+			if ok {
+				previous = text
+			}
+			return ok
+		})
+
+		// 保留能放入 budget - reserve 的最近消息组，至少保留最后一组
+		total, sizes := measure(system, groups, counter)
+		start := 0
+		for start < len(groups)-1 && total > budget-reserve {
+			total -= sizes[start]
+			start++
+		}
+		if k := slices.IndexFunc(groups[start:], startsWithUser); k > 0 {
+			start += k
+		}
+		if start <= 0 {
+			return slide(withSummary(system, previous), groups, budget, counter, true), nil
+		}
+
+		summary, err := summarize(ctx, cfg, reserve, previous, flatten(groups[:start]))
+		if err != nil {
+			return nil, err
+		}
+		return slide(withSummary(system, summary), groups[start:], budget, counter, true), nil
+	})
+}
+
+func withSummary(system []schema.Message, summary string) []schema.Message {
+	if summary == "" {
+		return system
+	}
+	return append(system, schema.SystemMessage(SummaryPrefix+summary))
+}
+
+func summarize(ctx context.Context, cfg SummarizeConfig, maxTokens int, previous string, messages []schema.Message) (string, error) {
+	prompt := cfg.Prompt
+	if prompt == "" {
+		prompt = DefaultSummaryPrompt
+	}
+
+	var b strings.Builder
+	if previous != "" {
+		b.WriteString("Earlier summary:\n")
+		b.WriteString(previous)
+		b.WriteString("\n\nConversation:\n")
+	}
+	writeTranscript(&b, messages)
+
+	opts := slices.Concat([]llm.ChatOption{llm.WithMaxTokens(maxTokens)}, cfg.ChatOptions)
+	resp, err := cfg.Model.Chat(ctx, []schema.Message{
+		schema.SystemMessage(prompt),
+		schema.UserMessage(b.String()),
+	}, opts...)
+	if err != nil {
+		return "", fmt.Errorf("memory: summarize: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("memory: summarize: empty response")
+	}
+	summary := strings.TrimSpace(resp.Choices[0].Message.Text())
+	if summary == "" {
+		return "", fmt.Errorf("memory: summarize: empty summary")
+	}
+	return summary, nil
+}
+
+// writeTranscript 将消息渲染为纯文本对话记录，图片等非文本内容以占位符表示
+func writeTranscript(b *strings.Builder, messages []schema.Message) {
+	for _, m := range messages {
+		var parts []string
+		for _, p := range m.Content {
+			switch c := p.(type) {
+			case schema.TextContent:
+				if c.Text != "" {
+					parts = append(parts, c.Text)
+				}
+			case schema.ImageURLContent, schema.BinaryContent:
+				parts = append(parts, "[image]")
+			}
+		}
+		if len(parts) > 0 {
+			role := string(m.Role)
+			if m.Role == schema.RoleTool {
+				role = "tool result"
+			}
+			fmt.Fprintf(b, "%s: %s\n", role, strings.Join(parts, " "))
+		}
+		for _, tc := range m.ToolCalls {
+			fmt.Fprintf(b, "%s called %s(%s)\n", m.Role, tc.Function.Name, tc.Function.Arguments)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// ChatMiddleware 包装 ChatModel，用于日志、指标、缓存、脱敏、策略检查等横切逻辑
+type ChatMiddleware func(next ChatModel) ChatModel
+
+// EmbedderMiddleware 包装 Embedder
+type EmbedderMiddleware func(next Embedder) Embedder
+
+// Chain 依次用 mws 包装 model，第一个中间件位于最外层（最先收到请求）。
+//
+// 中间件返回的 ChatModel 未实现 ProviderNamer 时，Chain 会转发内层的 provider 标识，ProviderOf 结果不变。
+func Chain(model ChatModel, mws ...ChatMiddleware) ChatModel {
+	for i := len(mws) - 1; i >= 0; i-- {
+		if mws[i] == nil {
+			continue
+		}
+		inner := model
+		model = mws[i](inner)
+		if _, ok := model.(ProviderNamer); !ok {
+			if p := ProviderOf(inner); p != ProviderUnknown {
+				model = providerChatModel{ChatModel: model, provider: p}
+			}
+		}
+	}
+	return model
+}
+
+// ChainEmbedder 同 Chain，用于 Embedder
+func ChainEmbedder(e Embedder, mws ...EmbedderMiddleware) Embedder {
+	for i := len(mws) - 1; i >= 0; i-- {
+		if mws[i] == nil {
+			continue
+		}
+		inner := e
+		e = mws[i](inner)
+		if _, ok := e.(ProviderNamer); !ok {
+			if p := ProviderOfEmbedder(inner); p != ProviderUnknown {
+				e = providerEmbedder{Embedder: e, provider: p}
+			}
+		}
+	}
+	return e
+}
+
+// ProviderOfEmbedder 获取 Embedder 的 provider 标识
+func ProviderOfEmbedder(e Embedder) Provider {
+	if p, ok := e.(ProviderNamer); ok && p.Provider() != "" {
+		return p.Provider()
+	}
+	return ProviderUnknown
+}
+
+type providerChatModel struct {
+	ChatModel
+	provider Provider
+}
+
+func (m providerChatModel) Provider() Provider { return m.provider }
+
+type providerEmbedder struct {
+	Embedder
+	provider Provider
+}
+
+func (e providerEmbedder) Provider() Provider { return e.provider }
+
+// ChatMiddlewareFuncs 由函数构造 ChatMiddleware，未设置的函数直接调用下一层
+type ChatMiddlewareFuncs struct {
+	Chat       func(ctx context.Context, next ChatModel, messages []schema.Message, opts ...ChatOption) (schema.ChatResponse, error)
+	ChatStream func(ctx context.Context, next ChatModel, messages []schema.Message, opts ...ChatOption) (Stream, error)
+}
+
+// Middleware 返回对应的 ChatMiddleware
+func (f ChatMiddlewareFuncs) Middleware() ChatMiddleware {
+	return func(next ChatModel) ChatModel {
+		return &funcsChatModel{next: next, funcs: f}
+	}
+}
+
+type funcsChatModel struct {
+	next  ChatModel
+	funcs ChatMiddlewareFuncs
+}
+
+func (m *funcsChatModel) Chat(ctx context.Context, messages []schema.Message, opts ...ChatOption) (schema.ChatResponse, error) {
+	if m.funcs.Chat == nil {
+		return m.next.Chat(ctx, messages, opts...)
+	}
+	return m.funcs.Chat(ctx, m.next, messages, opts...)
+}
+
+func (m *funcsChatModel) ChatStream(ctx context.Context, messages []schema.Message, opts ...ChatOption) (Stream, error) {
+	if m.funcs.ChatStream == nil {
+		return m.next.ChatStream(ctx, messages, opts...)
+	}
+	return m.funcs.ChatStream(ctx, m.next, messages, opts...)
+}
+
+// EmbedderFunc 函数形式的 Embedder，便于编写 EmbedderMiddleware
+type EmbedderFunc func(ctx context.Context, inputs []string, opts ...EmbeddingOption) (schema.EmbeddingResponse, error)
+
+func (f EmbedderFunc) Embed(ctx context.Context, inputs []string, opts ...EmbeddingOption) (schema.EmbeddingResponse, error) {
+	return f(ctx, inputs, opts...)
+}
+
+// ErrStreamClosed 流在读取到结尾之前被关闭，作为 StreamHooks.OnEnd 的参数
+var ErrStreamClosed = errors.New("llm: stream closed before end")
+
+// StreamHooks 观察或修改流式事件的回调，均可为 nil
+type StreamHooks struct {
+	// OnEvent 在每个事件返回给调用方之前调用，可修改事件；返回错误时 Recv 返回该错误
+	OnEvent func(ev *schema.StreamEvent) error
+
+	// OnEnd 在流结束时调用一次：正常结束时 err 为 nil，出错时为该错误，
+	// 读取到结尾前被关闭时为 ErrStreamClosed
+	OnEnd func(err error)
+}
+
+// WrapStream 返回在每个事件上调用 hooks 的 Stream，Close 会关闭 s
+func WrapStream(s Stream, hooks StreamHooks) Stream {
+	return &hookedStream{Stream: s, hooks: hooks}
+}
+
+type hookedStream struct {
+	Stream
+	hooks StreamHooks
+	once  sync.Once
+
+	// mu 串行化回调：Close 可能在其他 goroutine 中调用（如 Events 的 ctx 取消）
+	mu sync.Mutex
+}
+
+func (s *hookedStream) Recv() (schema.StreamEvent, error) {
+	ev, err := s.Stream.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			s.end(nil)
+		} else {
+			s.end(err)
+		}
+		return ev, err
+	}
+	if s.hooks.OnEvent != nil {
+		s.mu.Lock()
+		err := s.hooks.OnEvent(&ev)
+		s.mu.Unlock()
+		if err != nil {
+			s.end(err)
+			return schema.StreamEvent{}, err
+		}
+	}
+	return ev, nil
+}
+
+// Unwrap 返回被包装的 Stream，供 StatsOf 使用
+func (s *hookedStream) Unwrap() Stream { return s.Stream }
+
+func (s *hookedStream) Close() error {
+	s.end(ErrStreamClosed)
+	return s.Stream.Close()
+}
+
+func (s *hookedStream) end(err error) {
+	s.once.Do(func() {
+		if s.hooks.OnEnd != nil {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.hooks.OnEnd(err)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// streamModel 非流式返回固定文本，流式返回 newSliceStream
+type streamModel struct {
+	provider llm.Provider
+	stream   *sliceStream
+}
+
+func (m *streamModel) Provider() llm.Provider { return m.provider }
+
+func (m *streamModel) Chat(context.Context, []schema.Message, ...llm.ChatOption) (schema.ChatResponse, error) {
+	return schema.ChatResponse{Choices: []schema.Choice{{Message: schema.AssistantMessage("ok")}}}, nil
+}
+
+func (m *streamModel) ChatStream(context.Context, []schema.Message, ...llm.ChatOption) (llm.Stream, error) {
+	return m.stream, nil
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	var order []string
+	trace := func(name string) llm.ChatMiddleware {
+		return llm.ChatMiddlewareFuncs{
+			Chat: func(ctx context.Context, next llm.ChatModel, messages []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+				order = append(order, name+">")
+				resp, err := next.Chat(ctx, messages, opts...)
+				order = append(order, "<"+name)
+				return resp, err
+			},
+		}.Middleware()
+	}
+
+	base := &streamModel{provider: llm.ProviderDeepSeek, stream: newSliceStream("a")}
+	model := llm.Chain(base, trace("outer"), nil, trace("inner"))
+	if _, err := model.Chat(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"outer>", "inner>", "<inner", "<outer"}; !slices.Equal(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if p := llm.ProviderOf(model); p != llm.ProviderDeepSeek {
+		t.Errorf("ProviderOf(chained) = %s", p)
+	}
+
+	// 未设置 ChatStream 时直接调用下一层
+	stream, err := model.ChatStream(context.Background(), nil)
+	if err != nil || stream != llm.Stream(base.stream) {
+		t.Errorf("ChatStream() = %v, %v", stream, err)
+	}
+
+	if llm.Chain(base) != llm.ChatModel(base) {
+		t.Error("Chain without middleware should return the model itself")
+	}
+}
+
+func TestWrapStream(t *testing.T) {
+	t.Parallel()
+
+	var ends []error
+	upper := llm.StreamHooks{
+		OnEvent: func(ev *schema.StreamEvent) error {
+			ev.Delta = strings.ToUpper(ev.Delta)
+			return nil
+		},
+		OnEnd: func(err error) { ends = append(ends, err) },
+	}
+
+	model := llm.Chain(&streamModel{stream: newSliceStream("a", "b")}, llm.ChatMiddlewareFuncs{
+		ChatStream: func(ctx context.Context, next llm.ChatModel, messages []schema.Message, opts ...llm.ChatOption) (llm.Stream, error) {
+			s, err := next.ChatStream(ctx, messages, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return llm.WrapStream(s, upper), nil
+		},
+	}.Middleware())
+	stream, _ := model.ChatStream(context.Background(), nil)
+	resp, err := llm.Accumulate(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Choices[0].Message.Text(); got != "AB" {
+		t.Errorf("text = %q", got)
+	}
+	if len(ends) != 1 || ends[0] != nil {
+		t.Errorf("OnEnd calls = %v, want [nil]", ends)
+	}
+
+	// 提前关闭
+	ends = nil
+	src := newSliceStream("a", "b")
+	s := llm.WrapStream(src, upper)
+	s.Recv()
+	s.Close()
+	s.Close()
+	if len(ends) != 1 || !errors.Is(ends[0], llm.ErrStreamClosed) || !src.closed.Load() {
+		t.Errorf("OnEnd calls = %v, source closed = %v", ends, src.closed.Load())
+	}
+
+	// 源错误与 OnEvent 错误
+	ends = nil
+	boom := errors.New("boom")
+	src = newSliceStream()
+	src.err = boom
+	if _, err := llm.WrapStream(src, upper).Recv(); !errors.Is(err, boom) || len(ends) != 1 || ends[0] != boom {
+		t.Errorf("Recv() = %v, OnEnd = %v", err, ends)
+	}
+	reject := llm.WrapStream(newSliceStream("x"), llm.StreamHooks{OnEvent: func(*schema.StreamEvent) error { return boom }})
+	if _, err := reject.Recv(); !errors.Is(err, boom) {
+		t.Errorf("OnEvent error = %v", err)
+	}
+	if _, err := llm.WrapStream(newSliceStream(), llm.StreamHooks{}).Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("empty hooks Recv() = %v", err)
+	}
+}
+
+type namedEmbedder struct{ llm.EmbedderFunc }
+
+func (namedEmbedder) Provider() llm.Provider { return llm.ProviderOllama }
+
+func TestChainEmbedder(t *testing.T) {
+	t.Parallel()
+
+	base := namedEmbedder{func(_ context.Context, inputs []string, _ ...llm.EmbeddingOption) (schema.EmbeddingResponse, error) {
+		return schema.EmbeddingResponse{Model: strings.Join(inputs, ",")}, nil
+	}}
+	lower := func(next llm.Embedder) llm.Embedder {
+		return llm.EmbedderFunc(func(ctx context.Context, inputs []string, opts ...llm.EmbeddingOption) (schema.EmbeddingResponse, error) {
+			out := make([]string, len(inputs))
+			for i, in := range inputs {
+				out[i] = strings.ToLower(in)
+			}
+			return next.Embed(ctx, out, opts...)
+		})
+	}
+	e := llm.ChainEmbedder(base, lower)
+	resp, err := e.Embed(context.Background(), []string{"A", "B"})
+	if err != nil || resp.Model != "a,b" {
+		t.Errorf("Embed() = %+v, %v", resp, err)
+	}
+	if p := llm.ProviderOfEmbedder(e); p != llm.ProviderOllama {
+		t.Errorf("ProviderOfEmbedder() = %s", p)
+	}
+}
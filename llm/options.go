@@ -160,6 +160,12 @@ type ChatConfig struct {
 	// StreamOptions 设置流式响应的选项
 	StreamOptions *schema.StreamOptions
 
+	// StreamIdleTimeout 流式响应的空闲超时：超过该时间未收到任何数据（含心跳）时中止流
+	StreamIdleTimeout *time.Duration
+
+	// FirstTokenTimeout 首 token 超时：发出请求后超过该时间仍未收到内容事件时中止流
+	FirstTokenTimeout *time.Duration
+
 	// === 客户端配置（不发送到 API） ===
 
 	// Timeout 设置请求的超时时间
@@ -433,6 +439,23 @@ func WithStreamIncludeUsage() ChatOption {
 	return WithStreamOptions(schema.StreamOptions{IncludeUsage: true})
 }
 
+// WithStreamIdleTimeout 设置流式响应的空闲超时：连续 d 未收到任何数据时中止流，
+// Recv 返回 *StreamTimeoutError（errors.Is(err, ErrStreamTimeout)）。
+// 服务端的心跳（如 SSE 注释行）同样视为数据，可用于区分“仍在推理”与“连接已停滞”
+func WithStreamIdleTimeout(d time.Duration) ChatOption {
+	return chatOptionFunc(func(c *ChatConfig) {
+		c.StreamIdleTimeout = &d
+	})
+}
+
+// WithFirstTokenTimeout 设置首 token 超时：发出请求后 d 内未收到内容事件（文本、推理内容或工具调用）时中止流，
+// Recv 返回 *StreamTimeoutError
+func WithFirstTokenTimeout(d time.Duration) ChatOption {
+	return chatOptionFunc(func(c *ChatConfig) {
+		c.FirstTokenTimeout = &d
+	})
+}
+
 // === 客户端配置（Common）===
 
 // WithTimeout 设置请求的超时时间
@@ -0,0 +1,26 @@
+// Package all 导入全部内置 provider，使其注册到 llm 的 provider 注册表：
+//
+//	import _ "github.com/lgc202/go-kit/llm/provider/all"
+//
+//	model, err := llm.OpenDSN("deepseek://?model=deepseek-chat&api_key=sk-xxx")
+package all
+
+import (
+	_ "github.com/lgc202/go-kit/llm/provider/anthropic/chat"
+	_ "github.com/lgc202/go-kit/llm/provider/azureopenai/chat"
+	_ "github.com/lgc202/go-kit/llm/provider/azureopenai/embeddings"
+	_ "github.com/lgc202/go-kit/llm/provider/deepseek/chat"
+	_ "github.com/lgc202/go-kit/llm/provider/deepseek/embeddings"
+	_ "github.com/lgc202/go-kit/llm/provider/gemini/chat"
+	_ "github.com/lgc202/go-kit/llm/provider/gemini/embeddings"
+	_ "github.com/lgc202/go-kit/llm/provider/kimi/chat"
+	_ "github.com/lgc202/go-kit/llm/provider/kimi/embeddings"
+	_ "github.com/lgc202/go-kit/llm/provider/ollama/chat"
+	_ "github.com/lgc202/go-kit/llm/provider/ollama/embeddings"
+	_ "github.com/lgc202/go-kit/llm/provider/openai/chat"
+	_ "github.com/lgc202/go-kit/llm/provider/openai/embeddings"
+	_ "github.com/lgc202/go-kit/llm/provider/openaicompat/chat"
+	_ "github.com/lgc202/go-kit/llm/provider/openaicompat/embeddings"
+	_ "github.com/lgc202/go-kit/llm/provider/qwen/chat"
+	_ "github.com/lgc202/go-kit/llm/provider/qwen/embeddings"
+)
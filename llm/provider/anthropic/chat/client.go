@@ -0,0 +1,233 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/internal/openai_compat/transport"
+	"github.com/lgc202/go-kit/llm/provider/base"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+const (
+	DefaultBaseURL = "https://api.anthropic.com/v1"
+
+	// DefaultPath Messages API 端点路径
+	DefaultPath = "/messages"
+
+	// DefaultVersion anthropic-version 请求头的默认值
+	DefaultVersion = "2023-06-01"
+
+	// DefaultMaxTokens Anthropic 要求必须设置 max_tokens，未通过 llm.WithMaxTokens 指定时使用该值
+	DefaultMaxTokens = 4096
+)
+
+const (
+	httpAcceptJSON = "application/json"
+	httpAcceptSSE  = "text/event-stream"
+)
+
+var _ llm.ChatModel = (*Client)(nil)
+var _ llm.ProviderNamer = (*Client)(nil)
+
+type BaseConfig = base.Config
+
+type Config struct {
+	BaseConfig
+
+	// Version anthropic-version 请求头，为空时使用 DefaultVersion
+	Version string
+
+	// Beta anthropic-beta 请求头，用于启用 beta 功能
+	Beta []string
+
+	// DefaultOptions 客户端级别的默认请求选项
+	DefaultOptions []llm.ChatOption
+}
+
+// Client Anthropic Messages API（/v1/messages）原生客户端
+type Client struct {
+	provider string
+
+	t *transport.Client
+
+	defaultOpts []llm.ChatOption
+}
+
+func New(cfg Config) (*Client, error) {
+	baseURL := strings.TrimSpace(cfg.BaseURL)
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	hdr := make(http.Header)
+	for k, vs := range cfg.DefaultHeaders {
+		hdr[k] = slices.Clone(vs)
+	}
+	if hdr.Get("anthropic-version") == "" {
+		version := strings.TrimSpace(cfg.Version)
+		if version == "" {
+			version = DefaultVersion
+		}
+		hdr.Set("anthropic-version", version)
+	}
+	if len(cfg.Beta) > 0 && hdr.Get("anthropic-beta") == "" {
+		hdr.Set("anthropic-beta", strings.Join(cfg.Beta, ","))
+	}
+
+	t, err := transport.New(transport.Config{
+		Provider:       llm.ProviderAnthropic,
+		BaseURL:        baseURL,
+		DefaultPath:    DefaultPath,
+		APIKey:         cfg.APIKey,
+		APIKeyHeader:   "x-api-key",
+		HTTPClient:     cfg.HTTPClient,
+		HTTPX:          cfg.HTTPX,
+		HTTPXOptions:   cfg.HTTPXOptions,
+		DefaultHeaders: hdr,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		provider:    t.Provider(),
+		t:           t,
+		defaultOpts: slices.Clone(cfg.DefaultOptions),
+	}, nil
+}
+
+func (*Client) Provider() llm.Provider { return llm.ProviderAnthropic }
+
+func (c *Client) Chat(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+	reqCfg := llm.ApplyChatOptions(slices.Concat(c.defaultOpts, opts)...)
+
+	payload, err := c.buildRequest(messages, reqCfg, false)
+	if err != nil {
+		return schema.ChatResponse{}, err
+	}
+
+	resp, err := c.t.PostJSON(ctx, payload, transport.RequestConfig{
+		Timeout:    reqCfg.Timeout,
+		Headers:    reqCfg.Headers,
+		ErrorHooks: reqCfg.ErrorHooks,
+	}, httpAcceptJSON)
+	if err != nil {
+		return schema.ChatResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return schema.ChatResponse{}, fmt.Errorf("%s: read response: %w", c.provider, err)
+	}
+
+	var in messagesResponse
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return schema.ChatResponse{}, fmt.Errorf("%s: decode response: %w", c.provider, err)
+	}
+
+	out := toSchemaChatResponse(in)
+	if reqCfg.KeepRaw {
+		out.Raw = json.RawMessage(raw)
+	}
+	for _, h := range reqCfg.ResponseHooks {
+		if h == nil {
+			continue
+		}
+		if err := h(&out, json.RawMessage(raw)); err != nil {
+			return schema.ChatResponse{}, err
+		}
+	}
+	return out, nil
+}
+
+func (c *Client) ChatStream(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (llm.Stream, error) {
+	reqCfg := llm.ApplyChatOptions(slices.Concat(c.defaultOpts, opts)...)
+
+	payload, err := c.buildRequest(messages, reqCfg, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.t.PostJSON(ctx, payload, transport.RequestConfig{
+		Timeout:    reqCfg.Timeout,
+		Headers:    reqCfg.Headers,
+		ErrorHooks: reqCfg.ErrorHooks,
+
+		Stream:            true,
+		StreamIdleTimeout: reqCfg.StreamIdleTimeout,
+		FirstTokenTimeout: reqCfg.FirstTokenTimeout,
+	}, httpAcceptSSE)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.WatchStream(resp.Body, newStream(c.provider, resp.Body, reqCfg.KeepRaw, reqCfg.StreamEventHooks)), nil
+}
+
+// buildRequest 构建 Messages API 请求。
+//
+// Anthropic 不支持的选项（N、Logprobs、Seed、FrequencyPenalty、PresencePenalty、
+// LogitBias、ResponseFormat 等）会被忽略。
+func (c *Client) buildRequest(messages []schema.Message, cfg llm.ChatConfig, stream bool) (messagesRequest, error) {
+	if len(messages) == 0 {
+		return messagesRequest{}, fmt.Errorf("%s: messages required", c.provider)
+	}
+	if strings.TrimSpace(cfg.Model) == "" {
+		return messagesRequest{}, fmt.Errorf("%s: model required (use llm.WithModel)", c.provider)
+	}
+
+	system, wireMsgs, err := toWireMessages(c.provider, messages)
+	if err != nil {
+		return messagesRequest{}, err
+	}
+	if len(wireMsgs) == 0 {
+		return messagesRequest{}, fmt.Errorf("%s: at least one non-system message required", c.provider)
+	}
+
+	maxTokens := DefaultMaxTokens
+	if cfg.MaxCompletionTokens != nil {
+		maxTokens = *cfg.MaxCompletionTokens
+	} else if cfg.MaxTokens != nil {
+		maxTokens = *cfg.MaxTokens
+	}
+
+	req := messagesRequest{
+		provider:    c.provider,
+		Model:       cfg.Model,
+		MaxTokens:   maxTokens,
+		System:      system,
+		Messages:    wireMsgs,
+		Stream:      stream,
+		Temperature: cfg.Temperature,
+		TopP:        cfg.TopP,
+	}
+	if cfg.Stop != nil {
+		req.StopSequences = *cfg.Stop
+	}
+
+	if len(cfg.Tools) > 0 {
+		tools, err := toWireTools(c.provider, cfg.Tools)
+		if err != nil {
+			return messagesRequest{}, err
+		}
+		req.Tools = tools
+		req.ToolChoice = toWireToolChoice(cfg.ToolChoice, cfg.ParallelToolCalls)
+	}
+
+	if cfg.User != nil && *cfg.User != "" {
+		req.Metadata = &wireMetadata{UserID: *cfg.User}
+	}
+
+	req.extra = cfg.ExtraFields
+	req.allowExtraFieldOverride = cfg.AllowExtraFieldOverride
+
+	return req, nil
+}
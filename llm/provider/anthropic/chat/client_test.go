@@ -0,0 +1,434 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func newTestClient(t *testing.T, status int, contentType, body string, inspect func(*http.Request, map[string]any)) *Client {
+	t.Helper()
+
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			var got map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+			if inspect != nil {
+				inspect(r, got)
+			}
+			h := make(http.Header)
+			h.Set("Content-Type", contentType)
+			return &http.Response{
+				StatusCode: status,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header:     h,
+				Request:    r,
+			}, nil
+		}),
+	}
+
+	c, err := New(Config{
+		BaseConfig: BaseConfig{
+			APIKey:     "sk-ant",
+			HTTPClient: httpClient,
+		},
+		DefaultOptions: []llm.ChatOption{llm.WithModel("claude-sonnet-4-5")},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+// TestChat_RequestMapping 测试 system、tool_use/tool_result 与多模态内容的映射
+func TestChat_RequestMapping(t *testing.T) {
+	t.Parallel()
+
+	var gotReq map[string]any
+	var gotHeader http.Header
+	c := newTestClient(t, http.StatusOK, "application/json", `{
+  "id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5",
+  "content":[{"type":"text","text":"ok"}],
+  "stop_reason":"end_turn",
+  "usage":{"input_tokens":10,"output_tokens":2}
+}`, func(r *http.Request, req map[string]any) {
+		gotReq = req
+		gotHeader = r.Header
+	})
+
+	messages := []schema.Message{
+		schema.SystemMessage("You are terse."),
+		{
+			Role: schema.RoleUser,
+			Content: []schema.ContentPart{
+				schema.TextPart("Weather in Paris?"),
+				schema.BinaryPart("image/png", []byte{0x89, 0x50}),
+			},
+		},
+		{
+			Role: schema.RoleAssistant,
+			ToolCalls: []schema.ToolCall{
+				{ID: "toolu_1", Type: schema.ToolCallTypeFunction, Function: schema.ToolFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+				{ID: "toolu_2", Type: schema.ToolCallTypeFunction, Function: schema.ToolFunction{Name: "get_time", Arguments: `{}`}},
+			},
+		},
+		schema.ToolResultMessage("toolu_1", "sunny"),
+		schema.ToolResultMessage("toolu_2", "noon"),
+	}
+
+	tool, err := schema.NewFunctionTool("get_weather", "Get weather", map[string]any{"type": "object"})
+	if err != nil {
+		t.Fatalf("NewFunctionTool() error = %v", err)
+	}
+
+	_, err = c.Chat(context.Background(), messages,
+		llm.WithTools(tool),
+		llm.WithToolChoice(schema.ToolChoice{FunctionName: "get_weather"}),
+		llm.WithParallelToolCalls(false),
+		llm.WithMaxTokens(256),
+		WithThinking(1024),
+	)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if gotHeader.Get("x-api-key") != "sk-ant" || gotHeader.Get("Authorization") != "" {
+		t.Errorf("auth headers: x-api-key=%q Authorization=%q", gotHeader.Get("x-api-key"), gotHeader.Get("Authorization"))
+	}
+	if gotHeader.Get("anthropic-version") != DefaultVersion {
+		t.Errorf("anthropic-version = %q", gotHeader.Get("anthropic-version"))
+	}
+
+	system, _ := gotReq["system"].([]any)
+	if len(system) != 1 || system[0].(map[string]any)["text"] != "You are terse." {
+		t.Errorf("system = %#v", gotReq["system"])
+	}
+	if gotReq["max_tokens"] != float64(256) {
+		t.Errorf("max_tokens = %v", gotReq["max_tokens"])
+	}
+	thinking, _ := gotReq["thinking"].(map[string]any)
+	if thinking["type"] != "enabled" || thinking["budget_tokens"] != float64(1024) {
+		t.Errorf("thinking = %#v", gotReq["thinking"])
+	}
+
+	msgs, _ := gotReq["messages"].([]any)
+	if len(msgs) != 3 {
+		t.Fatalf("len(messages) = %d, want 3 (user, assistant, merged tool results)", len(msgs))
+	}
+
+	user := msgs[0].(map[string]any)["content"].([]any)
+	img := user[1].(map[string]any)
+	if img["type"] != "image" || img["source"].(map[string]any)["type"] != "base64" {
+		t.Errorf("image block = %#v", img)
+	}
+
+	assistant := msgs[1].(map[string]any)["content"].([]any)
+	tu := assistant[0].(map[string]any)
+	if tu["type"] != "tool_use" || tu["id"] != "toolu_1" || tu["input"].(map[string]any)["city"] != "Paris" {
+		t.Errorf("tool_use block = %#v", tu)
+	}
+
+	results := msgs[2].(map[string]any)
+	if results["role"] != "user" {
+		t.Errorf("tool results role = %v, want user", results["role"])
+	}
+	blocks := results["content"].([]any)
+	if len(blocks) != 2 || blocks[1].(map[string]any)["tool_use_id"] != "toolu_2" {
+		t.Errorf("tool_result blocks = %#v", blocks)
+	}
+
+	tc := gotReq["tool_choice"].(map[string]any)
+	if tc["type"] != "tool" || tc["name"] != "get_weather" || tc["disable_parallel_tool_use"] != true {
+		t.Errorf("tool_choice = %#v", tc)
+	}
+	tools := gotReq["tools"].([]any)
+	if _, ok := tools[0].(map[string]any)["input_schema"]; !ok {
+		t.Errorf("tools[0] missing input_schema: %#v", tools[0])
+	}
+}
+
+// TestChat_ResponseMapping 测试 thinking、tool_use 与用量的映射
+func TestChat_ResponseMapping(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, http.StatusOK, "application/json", `{
+  "id":"msg_2","type":"message","role":"assistant","model":"claude-sonnet-4-5",
+  "content":[
+    {"type":"thinking","thinking":"Need weather.","signature":"sig"},
+    {"type":"text","text":"Checking."},
+    {"type":"tool_use","id":"toolu_9","name":"get_weather","input":{"city":"Paris"}}
+  ],
+  "stop_reason":"tool_use",
+  "usage":{"input_tokens":5,"output_tokens":7,"cache_read_input_tokens":20,"cache_creation_input_tokens":3}
+}`, nil)
+
+	resp, err := c.Chat(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	msg := resp.Choices[0].Message
+	if msg.ReasoningContent != "Need weather." {
+		t.Errorf("ReasoningContent = %q", msg.ReasoningContent)
+	}
+	if want := []schema.ReasoningBlock{{Text: "Need weather.", Signature: "sig"}}; !reflect.DeepEqual(msg.ReasoningBlocks, want) {
+		t.Errorf("ReasoningBlocks = %+v", msg.ReasoningBlocks)
+	}
+	if msg.Text() != "Checking." {
+		t.Errorf("Text() = %q", msg.Text())
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].ID != "toolu_9" || msg.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("ToolCalls = %#v", msg.ToolCalls)
+	}
+	if resp.Choices[0].FinishReason != schema.FinishReasonToolCalls {
+		t.Errorf("FinishReason = %q", resp.Choices[0].FinishReason)
+	}
+	want := schema.Usage{PromptTokens: 28, CompletionTokens: 7, TotalTokens: 35, PromptCacheHitTokens: 20, PromptCacheMissTokens: 8}
+	if resp.Usage != want {
+		t.Errorf("Usage = %+v, want %+v", resp.Usage, want)
+	}
+}
+
+// TestChatStream_NamedEvents 测试具名 SSE 事件到 StreamEvent 的映射
+func TestChatStream_NamedEvents(t *testing.T) {
+	t.Parallel()
+
+	body := `event: message_start
+data: {"type":"message_start","message":{"id":"msg_3","model":"claude-sonnet-4-5","content":[],"usage":{"input_tokens":12,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Hmm."}}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Hello"}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":2}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":30}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+	c := newTestClient(t, http.StatusOK, "text/event-stream", body, func(r *http.Request, req map[string]any) {
+		if req["stream"] != true {
+			t.Errorf("stream = %v, want true", req["stream"])
+		}
+	})
+
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var text, reasoning, args string
+	var toolID, model string
+	var finish *schema.FinishReason
+	var usage *schema.Usage
+	var dones int
+	for {
+		ev, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		text += ev.Delta
+		reasoning += ev.Reasoning
+		for _, tc := range ev.ToolCalls {
+			if tc.Function.Name != "" {
+				toolID = tc.ID
+			}
+			if tc.ID != toolID {
+				t.Errorf("tool call fragment ID = %q, want %q", tc.ID, toolID)
+			}
+			args += tc.Function.Arguments
+		}
+		if ev.Type == schema.StreamEventDone {
+			dones++
+			if ev.FinishReason != nil {
+				finish, usage, model = ev.FinishReason, ev.Usage, ev.Model
+			}
+		}
+	}
+
+	if text != "Hello" || reasoning != "Hmm." {
+		t.Errorf("text = %q, reasoning = %q", text, reasoning)
+	}
+	if model != "claude-sonnet-4-5" {
+		t.Errorf("Model = %q", model)
+	}
+	if toolID != "toolu_1" || args != `{"city":"Paris"}` {
+		t.Errorf("tool call id = %q, args = %q", toolID, args)
+	}
+	if finish == nil || *finish != schema.FinishReasonToolCalls {
+		t.Errorf("FinishReason = %v", finish)
+	}
+	if usage == nil || usage.PromptTokens != 12 || usage.CompletionTokens != 30 {
+		t.Errorf("Usage = %+v", usage)
+	}
+	if dones != 2 {
+		t.Errorf("done events = %d, want 2 (message_delta + message_stop)", dones)
+	}
+}
+
+// TestChatStream_ErrorEvent 测试流中 error 事件转换为 *llm.APIError
+func TestChatStream_ErrorEvent(t *testing.T) {
+	t.Parallel()
+
+	body := `event: message_start
+data: {"type":"message_start","message":{"id":"msg_4","usage":{"input_tokens":1}}}
+
+event: error
+data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}
+
+`
+	c := newTestClient(t, http.StatusOK, "text/event-stream", body, nil)
+
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	ae, ok := llm.AsAPIError(err)
+	if !ok {
+		t.Fatalf("Recv() error = %v, want *llm.APIError", err)
+	}
+	if ae.Type != "overloaded_error" || ae.Message != "Overloaded" {
+		t.Errorf("APIError = %+v", ae)
+	}
+	if !llm.IsTemporary(err) {
+		t.Error("IsTemporary() should return true for overloaded_error")
+	}
+}
+
+// TestChat_APIErrorResponse 测试 Anthropic 错误响应
+func TestChat_APIErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, http.StatusTooManyRequests, "application/json",
+		`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`, nil)
+
+	_, err := c.Chat(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	ae, ok := llm.AsAPIError(err)
+	if !ok {
+		t.Fatalf("Chat() error = %v, want *llm.APIError", err)
+	}
+	if ae.Provider != llm.ProviderAnthropic || ae.Type != "rate_limit_error" || ae.Message != "slow down" {
+		t.Errorf("APIError = %+v", ae)
+	}
+	if !llm.IsRateLimit(err) {
+		t.Error("IsRateLimit() should return true")
+	}
+}
+
+// TestThinking_RoundTrip 测试流式 thinking 签名与 redacted_thinking 被保留，并在下一轮请求中随 tool_use 回传
+func TestThinking_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	body := `event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":"","signature":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Need "}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"weather."}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"EqQBsig"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"EmwKAhgB"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: content_block_start
+data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"city\":\"Paris\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":2}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+	c := newTestClient(t, http.StatusOK, "text/event-stream", body, nil)
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Weather?")})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	resp, err := llm.Accumulate(stream)
+	if err != nil {
+		t.Fatalf("Accumulate() error = %v", err)
+	}
+	msg := resp.Choices[0].Message
+	wantBlocks := []schema.ReasoningBlock{{Text: "Need weather.", Signature: "EqQBsig"}, {Redacted: "EmwKAhgB"}}
+	if msg.ReasoningContent != "Need weather." || !reflect.DeepEqual(msg.ReasoningBlocks, wantBlocks) {
+		t.Fatalf("reasoning = %q, %+v", msg.ReasoningContent, msg.ReasoningBlocks)
+	}
+
+	var gotReq map[string]any
+	c = newTestClient(t, http.StatusOK, "application/json",
+		`{"id":"msg_2","type":"message","role":"assistant","content":[{"type":"text","text":"Sunny."}],"stop_reason":"end_turn","usage":{}}`,
+		func(_ *http.Request, req map[string]any) { gotReq = req })
+	history := []schema.Message{
+		schema.UserMessage("Weather?"),
+		msg,
+		schema.ToolResultMessage("toolu_1", `{"temp":20}`),
+	}
+	if _, err := c.Chat(context.Background(), history); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	assistant := gotReq["messages"].([]any)[1].(map[string]any)
+	b, _ := json.Marshal(assistant["content"])
+	want := `[{"signature":"EqQBsig","thinking":"Need weather.","type":"thinking"},` +
+		`{"data":"EmwKAhgB","type":"redacted_thinking"},` +
+		`{"id":"toolu_1","input":{"city":"Paris"},"name":"get_weather","type":"tool_use"}]`
+	if string(b) != want {
+		t.Errorf("assistant content =\n%s\nwant\n%s", b, want)
+	}
+}
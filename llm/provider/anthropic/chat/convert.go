@@ -0,0 +1,324 @@
+package chat
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+const (
+	wireRoleUser      = "user"
+	wireRoleAssistant = "assistant"
+)
+
+// toWireMessages 将 schema 消息拆分为 system 块与 messages。
+//
+// Anthropic 要求 tool_result 位于 user 消息中，且相邻同角色消息需合并，
+// 因此 RoleTool 消息会被转换为 user 消息的 tool_result 块并与相邻 user 消息合并。
+func toWireMessages(provider string, messages []schema.Message) ([]wireBlock, []wireMessage, error) {
+	var system []wireBlock
+	out := make([]wireMessage, 0, len(messages))
+
+	for _, m := range messages {
+		var role string
+		var blocks []wireBlock
+		var err error
+
+		switch m.Role {
+		case schema.RoleSystem:
+			text := m.Text()
+			if text != "" {
+				system = append(system, wireBlock{Type: wireBlockText, Text: text})
+			}
+			continue
+		case schema.RoleUser:
+			role = wireRoleUser
+			blocks, err = toWireContentBlocks(provider, m.Content)
+		case schema.RoleAssistant:
+			role = wireRoleAssistant
+			blocks, err = toWireAssistantBlocks(provider, m)
+		case schema.RoleTool:
+			if strings.TrimSpace(m.ToolCallID) == "" {
+				return nil, nil, fmt.Errorf("%s: tool message requires tool_call_id", provider)
+			}
+			role = wireRoleUser
+			var content []wireBlock
+			content, err = toWireContentBlocks(provider, m.Content)
+			blocks = []wireBlock{{
+				Type:      wireBlockToolResult,
+				ToolUseID: m.ToolCallID,
+				Content:   content,
+			}}
+		default:
+			return nil, nil, fmt.Errorf("%s: unsupported message role %q", provider, m.Role)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+
+		if n := len(out); n > 0 && out[n-1].Role == role {
+			out[n-1].Content = append(out[n-1].Content, blocks...)
+			continue
+		}
+		out = append(out, wireMessage{Role: role, Content: blocks})
+	}
+
+	return system, out, nil
+}
+
+// toWireAssistantBlocks 转换 assistant 消息。
+//
+// 推理内容按 ReasoningBlocks 回传为 thinking / redacted_thinking 块并置于最前（启用 thinking 时，
+// 含 tool_use 的 assistant 消息必须携带原样签名的 thinking 块）；没有签名的 ReasoningContent 不会回传。
+func toWireAssistantBlocks(provider string, m schema.Message) ([]wireBlock, error) {
+	var blocks []wireBlock
+	for _, rb := range m.ReasoningBlocks {
+		switch {
+		case rb.Redacted != "":
+			blocks = append(blocks, wireBlock{Type: wireBlockRedactedThinking, Data: rb.Redacted})
+		case rb.Signature != "":
+			blocks = append(blocks, wireBlock{Type: wireBlockThinking, Thinking: rb.Text, Signature: rb.Signature})
+		}
+	}
+	content, err := toWireContentBlocks(provider, m.Content)
+	if err != nil {
+		return nil, err
+	}
+	blocks = append(blocks, content...)
+	for _, tc := range m.ToolCalls {
+		input := json.RawMessage(strings.TrimSpace(tc.Function.Arguments))
+		if len(input) == 0 {
+			input = json.RawMessage("{}")
+		}
+		if !json.Valid(input) {
+			return nil, fmt.Errorf("%s: invalid tool call arguments JSON for %q", provider, tc.Function.Name)
+		}
+		blocks = append(blocks, wireBlock{
+			Type:  wireBlockToolUse,
+			ID:    tc.ID,
+			Name:  tc.Function.Name,
+			Input: input,
+		})
+	}
+	return blocks, nil
+}
+
+func toWireContentBlocks(provider string, parts []schema.ContentPart) ([]wireBlock, error) {
+	blocks := make([]wireBlock, 0, len(parts))
+	for _, p := range parts {
+		switch part := p.(type) {
+		case schema.TextContent:
+			if part.Text == "" {
+				continue
+			}
+			blocks = append(blocks, wireBlock{Type: wireBlockText, Text: part.Text})
+		case schema.ImageURLContent:
+			src, err := imageURLSource(provider, part.URL)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, wireBlock{Type: wireBlockImage, Source: src})
+		case schema.BinaryContent:
+			if strings.TrimSpace(part.MIMEType) == "" {
+				return nil, fmt.Errorf("%s: binary mime type required", provider)
+			}
+			if len(part.Data) == 0 {
+				return nil, fmt.Errorf("%s: binary data required", provider)
+			}
+			typ := wireBlockImage
+			if !strings.HasPrefix(part.MIMEType, "image/") {
+				typ = wireBlockDocument
+			}
+			blocks = append(blocks, wireBlock{
+				Type: typ,
+				Source: &wireSource{
+					Type:      "base64",
+					MediaType: part.MIMEType,
+					Data:      base64.StdEncoding.EncodeToString(part.Data),
+				},
+			})
+		default:
+			return nil, fmt.Errorf("%s: unsupported message.content part type %T", provider, p)
+		}
+	}
+	return blocks, nil
+}
+
+// imageURLSource 支持普通 URL 与 data URL（data:image/png;base64,...）
+func imageURLSource(provider, u string) (*wireSource, error) {
+	u = strings.TrimSpace(u)
+	if u == "" {
+		return nil, fmt.Errorf("%s: image url required", provider)
+	}
+	rest, ok := strings.CutPrefix(u, "data:")
+	if !ok {
+		return &wireSource{Type: "url", URL: u}, nil
+	}
+	meta, data, ok := strings.Cut(rest, ",")
+	mediaType, isBase64 := strings.CutSuffix(meta, ";base64")
+	if !ok || !isBase64 || mediaType == "" {
+		return nil, fmt.Errorf("%s: unsupported data url, want data:<mime>;base64,<data>", provider)
+	}
+	return &wireSource{Type: "base64", MediaType: mediaType, Data: data}, nil
+}
+
+func toWireTools(provider string, tools []schema.Tool) ([]wireTool, error) {
+	out := make([]wireTool, 0, len(tools))
+	for _, t := range tools {
+		if t.Type != schema.ToolTypeFunction {
+			continue
+		}
+		params := json.RawMessage(`{"type":"object"}`)
+		if len(t.Function.Parameters) > 0 {
+			if !json.Valid(t.Function.Parameters) {
+				return nil, fmt.Errorf("%s: invalid tool parameters JSON for %q", provider, t.Function.Name)
+			}
+			params = t.Function.Parameters
+		}
+		out = append(out, wireTool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: params,
+		})
+	}
+	return out, nil
+}
+
+func toWireToolChoice(tc *schema.ToolChoice, parallel *bool) *wireToolChoice {
+	if tc == nil && parallel == nil {
+		return nil
+	}
+
+	out := &wireToolChoice{Type: "auto"}
+	if tc != nil {
+		switch {
+		case tc.Mode == schema.ToolChoiceNone:
+			out.Type = "none"
+		case tc.Mode == schema.ToolChoiceAuto:
+			out.Type = "auto"
+		case tc.FunctionName != "":
+			out.Type = "tool"
+			out.Name = tc.FunctionName
+		}
+	}
+	if parallel != nil && !*parallel && out.Type != "none" {
+		out.DisableParallelToolUse = true
+	}
+	return out
+}
+
+func toSchemaFinishReason(stopReason string) schema.FinishReason {
+	switch stopReason {
+	case "end_turn", "stop_sequence":
+		return schema.FinishReasonStop
+	case "max_tokens":
+		return schema.FinishReasonLength
+	case "tool_use":
+		return schema.FinishReasonToolCalls
+	case "refusal":
+		return schema.FinishReasonContentFilter
+	default:
+		return schema.FinishReason(stopReason)
+	}
+}
+
+// toSchemaUsage 将 Anthropic 用量映射为 schema.Usage。
+//
+// Anthropic 的 input_tokens 不含缓存部分，这里 PromptTokens 统计全部输入（含缓存读写），
+// 缓存读取计入 PromptCacheHitTokens，其余计入 PromptCacheMissTokens。
+func toSchemaUsage(u wireUsage) schema.Usage {
+	prompt := u.InputTokens + u.CacheReadInputTokens + u.CacheCreationInputTokens
+	out := schema.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      prompt + u.OutputTokens,
+	}
+	if u.CacheReadInputTokens > 0 || u.CacheCreationInputTokens > 0 {
+		out.PromptCacheHitTokens = u.CacheReadInputTokens
+		out.PromptCacheMissTokens = u.InputTokens + u.CacheCreationInputTokens
+	}
+	return out
+}
+
+func toSchemaChatResponse(in messagesResponse) schema.ChatResponse {
+	msg := schema.Message{Role: schema.RoleAssistant}
+	for _, b := range in.Content {
+		switch b.Type {
+		case wireBlockText:
+			if b.Text != "" {
+				msg.Content = append(msg.Content, schema.TextContent{Text: b.Text})
+			}
+		case wireBlockThinking:
+			msg.ReasoningContent += b.Thinking
+			msg.ReasoningBlocks = append(msg.ReasoningBlocks, schema.ReasoningBlock{Text: b.Thinking, Signature: b.Signature})
+		case wireBlockRedactedThinking:
+			msg.ReasoningBlocks = append(msg.ReasoningBlocks, schema.ReasoningBlock{Redacted: b.Data})
+		case wireBlockToolUse:
+			args := string(b.Input)
+			if args == "" {
+				args = "{}"
+			}
+			msg.ToolCalls = append(msg.ToolCalls, schema.ToolCall{
+				ID:   b.ID,
+				Type: schema.ToolCallTypeFunction,
+				Function: schema.ToolFunction{
+					Name:      b.Name,
+					Arguments: args,
+				},
+			})
+		}
+	}
+
+	return schema.ChatResponse{
+		ID:    in.ID,
+		Model: in.Model,
+		Choices: []schema.Choice{{
+			Index:        0,
+			Message:      msg,
+			FinishReason: toSchemaFinishReason(in.StopReason),
+		}},
+		Usage: toSchemaUsage(in.Usage),
+	}
+}
+
+// streamErrorStatus 为流中 error 事件推断 HTTP 状态码，使 llm.IsTemporary/IsRateLimit 可用
+func streamErrorStatus(errType string) int {
+	switch errType {
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "overloaded_error":
+		return 529
+	case "api_error":
+		return http.StatusInternalServerError
+	case "timeout_error":
+		return http.StatusGatewayTimeout
+	case "authentication_error":
+		return http.StatusUnauthorized
+	case "permission_error":
+		return http.StatusForbidden
+	case "not_found_error":
+		return http.StatusNotFound
+	case "request_too_large":
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func toStreamAPIError(provider string, e wireError, raw []byte) *llm.APIError {
+	return &llm.APIError{
+		Provider:   llm.Provider(provider),
+		StatusCode: streamErrorStatus(e.Type),
+		Type:       e.Type,
+		Message:    e.Message,
+		Raw:        raw,
+	}
+}
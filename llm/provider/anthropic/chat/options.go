@@ -0,0 +1,27 @@
+package chat
+
+import "github.com/lgc202/go-kit/llm"
+
+// 扩展字段键，用于 llm.WithExtraField()
+const (
+	extThinking = "thinking"
+	extTopK     = "top_k"
+)
+
+// WithThinking 启用扩展思考（extended thinking），budgetTokens 为思考可用的 token 预算
+// 预算需小于 max_tokens；budgetTokens <= 0 时显式禁用
+// 思考内容通过 Message.ReasoningContent / StreamEvent.Reasoning 返回
+func WithThinking(budgetTokens int) llm.ChatOption {
+	if budgetTokens <= 0 {
+		return llm.WithExtraField(extThinking, map[string]any{"type": "disabled"})
+	}
+	return llm.WithExtraField(extThinking, map[string]any{
+		"type":          "enabled",
+		"budget_tokens": budgetTokens,
+	})
+}
+
+// WithTopK 只从概率最高的 K 个 token 中采样
+func WithTopK(k int) llm.ChatOption {
+	return llm.WithExtraField(extTopK, k)
+}
@@ -0,0 +1,24 @@
+package chat
+
+import (
+	"strings"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/provider/base"
+)
+
+// init 注册到 llm；llm.Config.Params 支持 "version"（anthropic-version）与逗号分隔的 "beta"
+func init() {
+	llm.RegisterChat(llm.ProviderAnthropic, func(cfg llm.Config) (llm.ChatModel, error) {
+		var beta []string
+		if v := cfg.Param("beta"); v != "" {
+			beta = strings.Split(v, ",")
+		}
+		return New(Config{
+			BaseConfig:     base.FromConfig(cfg),
+			Version:        cfg.Param("version"),
+			Beta:           beta,
+			DefaultOptions: cfg.DefaultChatOptions(),
+		})
+	})
+}
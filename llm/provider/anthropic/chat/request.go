@@ -0,0 +1,126 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type wireBlockType string
+
+const (
+	wireBlockText             wireBlockType = "text"
+	wireBlockImage            wireBlockType = "image"
+	wireBlockDocument         wireBlockType = "document"
+	wireBlockToolUse          wireBlockType = "tool_use"
+	wireBlockToolResult       wireBlockType = "tool_result"
+	wireBlockThinking         wireBlockType = "thinking"
+	wireBlockRedactedThinking wireBlockType = "redacted_thinking"
+)
+
+type messagesRequest struct {
+	provider string `json:"-"`
+
+	Model     string        `json:"model"`
+	MaxTokens int           `json:"max_tokens"`
+	System    []wireBlock   `json:"system,omitempty"`
+	Messages  []wireMessage `json:"messages"`
+	Stream    bool          `json:"stream,omitempty"`
+
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"top_p,omitempty"`
+	StopSequences []string `json:"stop_sequences,omitempty"`
+
+	Tools      []wireTool      `json:"tools,omitempty"`
+	ToolChoice *wireToolChoice `json:"tool_choice,omitempty"`
+
+	Metadata *wireMetadata `json:"metadata,omitempty"`
+
+	extra                   map[string]any `json:"-"`
+	allowExtraFieldOverride bool           `json:"-"`
+}
+
+func (r messagesRequest) MarshalJSON() ([]byte, error) {
+	type alias messagesRequest
+	base, err := json.Marshal(alias(r))
+	if err != nil {
+		return nil, err
+	}
+	if len(r.extra) == 0 {
+		return base, nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(base, &obj); err != nil {
+		return nil, err
+	}
+
+	for k, v := range r.extra {
+		if !r.allowExtraFieldOverride {
+			if _, exists := obj[k]; exists {
+				return nil, fmt.Errorf("%s: extra field %q conflicts with a built-in option (set llm.WithAllowExtraFieldOverride(true) to override)", r.provider, k)
+			}
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		obj[k] = b
+	}
+
+	return json.Marshal(obj)
+}
+
+type wireMessage struct {
+	Role    string      `json:"role"`
+	Content []wireBlock `json:"content"`
+}
+
+// wireBlock 是请求与响应共用的内容块，按 Type 使用不同字段
+type wireBlock struct {
+	Type wireBlockType `json:"type"`
+
+	// text
+	Text string `json:"text,omitempty"`
+
+	// image / document
+	Source *wireSource `json:"source,omitempty"`
+
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// tool_result
+	ToolUseID string      `json:"tool_use_id,omitempty"`
+	Content   []wireBlock `json:"content,omitempty"`
+	IsError   bool        `json:"is_error,omitempty"`
+
+	// thinking / redacted_thinking
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Data      string `json:"data,omitempty"`
+}
+
+type wireSource struct {
+	Type      string `json:"type"` // "base64" 或 "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type wireTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type wireToolChoice struct {
+	Type string `json:"type"` // auto / any / tool / none
+	Name string `json:"name,omitempty"`
+
+	DisableParallelToolUse bool `json:"disable_parallel_tool_use,omitempty"`
+}
+
+type wireMetadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
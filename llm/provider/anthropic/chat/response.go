@@ -0,0 +1,63 @@
+package chat
+
+import "encoding/json"
+
+type wireUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+type messagesResponse struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Role  string `json:"role"`
+	Model string `json:"model"`
+
+	Content []wireBlock `json:"content"`
+
+	StopReason   string `json:"stop_reason"`
+	StopSequence string `json:"stop_sequence,omitempty"`
+
+	Usage wireUsage `json:"usage"`
+}
+
+// streamPayload 覆盖所有具名 SSE 事件的 data 载荷，按 Type 区分
+type streamPayload struct {
+	Type string `json:"type"`
+
+	// message_start
+	Message *messagesResponse `json:"message,omitempty"`
+
+	// content_block_start / content_block_delta / content_block_stop
+	Index        int        `json:"index"`
+	ContentBlock *wireBlock `json:"content_block,omitempty"`
+
+	// content_block_delta / message_delta
+	Delta json.RawMessage `json:"delta,omitempty"`
+
+	// message_delta
+	Usage *wireUsage `json:"usage,omitempty"`
+
+	// error
+	Error *wireError `json:"error,omitempty"`
+}
+
+type wireBlockDelta struct {
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`
+	Thinking    string `json:"thinking,omitempty"`
+	PartialJSON string `json:"partial_json,omitempty"`
+	Signature   string `json:"signature,omitempty"`
+}
+
+type wireMessageDelta struct {
+	StopReason   string `json:"stop_reason,omitempty"`
+	StopSequence string `json:"stop_sequence,omitempty"`
+}
+
+type wireError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,253 @@
+package chat
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"sync/atomic"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/internal/openai_compat/transport"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// Anthropic 具名 SSE 事件，data 载荷中的 type 字段与事件名一致
+const (
+	eventMessageStart      = "message_start"
+	eventContentBlockStart = "content_block_start"
+	eventContentBlockDelta = "content_block_delta"
+	eventContentBlockStop  = "content_block_stop"
+	eventMessageDelta      = "message_delta"
+	eventMessageStop       = "message_stop"
+	eventPing              = "ping"
+	eventError             = "error"
+)
+
+const (
+	deltaText      = "text_delta"
+	deltaThinking  = "thinking_delta"
+	deltaInputJSON = "input_json_delta"
+	deltaSignature = "signature_delta"
+)
+
+type stream struct {
+	body io.ReadCloser
+	dec  *transport.SSEDecoder
+
+	provider string
+	keepRaw  bool
+	hooks    []llm.StreamEventHook
+
+	// message_start 中的模型与输入用量，需与 message_delta 的输出用量合并
+	model string
+	usage wireUsage
+	// 内容块下标 -> tool_use ID，用于给 input_json_delta 片段关联工具调用
+	toolIDs map[int]string
+	// 内容块下标 -> 进行中的 thinking 块，在 content_block_stop 时连同签名一起给出
+	thinking map[int]*schema.ReasoningBlock
+
+	pending []schema.StreamEvent
+	done    bool
+	closed  atomic.Bool
+}
+
+func newStream(provider string, body io.ReadCloser, keepRaw bool, hooks []llm.StreamEventHook) *stream {
+	return &stream{
+		body:     body,
+		dec:      transport.NewSSEDecoder(body),
+		provider: provider,
+		keepRaw:  keepRaw,
+		hooks:    hooks,
+		toolIDs:  make(map[int]string),
+		thinking: make(map[int]*schema.ReasoningBlock),
+	}
+}
+
+func (s *stream) Recv() (schema.StreamEvent, error) {
+	for {
+		if len(s.pending) > 0 {
+			ev := s.pending[0]
+			s.pending = s.pending[1:]
+			return ev, nil
+		}
+		if s.done || s.closed.Load() {
+			return schema.StreamEvent{}, io.EOF
+		}
+
+		data, err := s.dec.NextData()
+		if err != nil {
+			return schema.StreamEvent{}, err
+		}
+
+		rawBytes := []byte(data)
+		var raw json.RawMessage
+		if s.keepRaw || len(s.hooks) > 0 {
+			raw = json.RawMessage(rawBytes)
+			if s.keepRaw {
+				raw = json.RawMessage(slices.Clone(rawBytes))
+			}
+		}
+
+		var p streamPayload
+		if err := json.Unmarshal(rawBytes, &p); err != nil {
+			return schema.StreamEvent{}, err
+		}
+
+		mapped, err := s.mapPayload(p, rawBytes)
+		if err != nil {
+			return schema.StreamEvent{}, err
+		}
+
+		for i := range mapped {
+			if s.keepRaw {
+				mapped[i].Raw = raw
+			}
+			for _, h := range s.hooks {
+				if h == nil {
+					continue
+				}
+				if err := h(&mapped[i], raw); err != nil {
+					return schema.StreamEvent{}, err
+				}
+			}
+		}
+
+		s.pending = mapped
+	}
+}
+
+func (s *stream) mapPayload(p streamPayload, rawBytes []byte) ([]schema.StreamEvent, error) {
+	switch p.Type {
+	case eventMessageStart:
+		if p.Message != nil {
+			s.model = p.Message.Model
+			s.usage = p.Message.Usage
+		}
+		return nil, nil
+
+	case eventContentBlockStart:
+		if p.ContentBlock == nil {
+			return nil, nil
+		}
+		switch p.ContentBlock.Type {
+		case wireBlockThinking:
+			s.thinking[p.Index] = &schema.ReasoningBlock{Text: p.ContentBlock.Thinking, Signature: p.ContentBlock.Signature}
+			return nil, nil
+		case wireBlockRedactedThinking:
+			return []schema.StreamEvent{{
+				Type:           schema.StreamEventDelta,
+				ReasoningBlock: &schema.ReasoningBlock{Redacted: p.ContentBlock.Data},
+			}}, nil
+		case wireBlockToolUse:
+			s.toolIDs[p.Index] = p.ContentBlock.ID
+			return []schema.StreamEvent{{
+				Type: schema.StreamEventDelta,
+				ToolCalls: []schema.ToolCall{{
+					Index:    &p.Index,
+					ID:       p.ContentBlock.ID,
+					Type:     schema.ToolCallTypeFunction,
+					Function: schema.ToolFunction{Name: p.ContentBlock.Name},
+				}},
+			}}, nil
+		default:
+			return nil, nil
+		}
+
+	case eventContentBlockDelta:
+		var d wireBlockDelta
+		if err := json.Unmarshal(p.Delta, &d); err != nil {
+			return nil, err
+		}
+		switch d.Type {
+		case deltaText:
+			if d.Text == "" {
+				return nil, nil
+			}
+			return []schema.StreamEvent{{Type: schema.StreamEventDelta, Delta: d.Text}}, nil
+		case deltaThinking:
+			if d.Thinking == "" {
+				return nil, nil
+			}
+			if b := s.thinking[p.Index]; b != nil {
+				b.Text += d.Thinking
+			}
+			return []schema.StreamEvent{{Type: schema.StreamEventDelta, Reasoning: d.Thinking}}, nil
+		case deltaInputJSON:
+			if d.PartialJSON == "" {
+				return nil, nil
+			}
+			return []schema.StreamEvent{{
+				Type: schema.StreamEventDelta,
+				ToolCalls: []schema.ToolCall{{
+					Index:    &p.Index,
+					ID:       s.toolIDs[p.Index],
+					Type:     schema.ToolCallTypeFunction,
+					Function: schema.ToolFunction{Arguments: d.PartialJSON},
+				}},
+			}}, nil
+		case deltaSignature:
+			if b := s.thinking[p.Index]; b != nil {
+				b.Signature += d.Signature
+			}
+			return nil, nil
+		default:
+			return nil, nil
+		}
+
+	case eventContentBlockStop:
+		b := s.thinking[p.Index]
+		if b == nil {
+			return nil, nil
+		}
+		delete(s.thinking, p.Index)
+		return []schema.StreamEvent{{Type: schema.StreamEventDelta, ReasoningBlock: b}}, nil
+
+	case eventMessageDelta:
+		var d wireMessageDelta
+		if len(p.Delta) > 0 {
+			if err := json.Unmarshal(p.Delta, &d); err != nil {
+				return nil, err
+			}
+		}
+		if p.Usage != nil {
+			s.usage.OutputTokens = p.Usage.OutputTokens
+		}
+		usage := toSchemaUsage(s.usage)
+		ev := schema.StreamEvent{
+			Type:  schema.StreamEventDone,
+			Usage: &usage,
+			Model: s.model,
+		}
+		if d.StopReason != "" {
+			fr := toSchemaFinishReason(d.StopReason)
+			ev.FinishReason = &fr
+		}
+		return []schema.StreamEvent{ev}, nil
+
+	case eventMessageStop:
+		s.done = true
+		return []schema.StreamEvent{{Type: schema.StreamEventDone}}, nil
+
+	case eventError:
+		var e wireError
+		if p.Error != nil {
+			e = *p.Error
+		}
+		return nil, toStreamAPIError(s.provider, e, slices.Clone(rawBytes))
+
+	case eventPing:
+		return nil, nil
+
+	default:
+		// 忽略未来新增的事件类型
+		return nil, nil
+	}
+}
+
+// Close 可与 Recv 并发调用，关闭 body 以中断阻塞中的读取
+func (s *stream) Close() error {
+	if !s.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	return s.body.Close()
+}
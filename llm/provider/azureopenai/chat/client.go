@@ -0,0 +1,95 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm"
+	openaiCompatChat "github.com/lgc202/go-kit/llm/internal/openai_compat/chat"
+	"github.com/lgc202/go-kit/llm/provider/base"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// DefaultAPIVersion 默认的 api-version 查询参数
+const DefaultAPIVersion = "2024-10-21"
+
+var _ llm.ChatModel = (*Client)(nil)
+var _ llm.ProviderNamer = (*Client)(nil)
+
+type BaseConfig = base.Config
+
+type Config struct {
+	// BaseURL 为资源端点，如 "https://{resource}.openai.azure.com"；
+	// APIKey 以 api-key 请求头发送，使用 Microsoft Entra ID 时留空并通过 DefaultHeaders 设置 Authorization
+	BaseConfig
+
+	// Deployment 部署名称（必填），请求发往 /openai/deployments/{Deployment}/chat/completions
+	Deployment string
+
+	// APIVersion api-version 查询参数，默认 DefaultAPIVersion
+	APIVersion string
+
+	// DefaultOptions 客户端级别的默认请求选项
+	DefaultOptions []llm.ChatOption
+}
+
+// Client Azure OpenAI chat completions 客户端。
+//
+// 复用 OpenAI 兼容协议，并通过 hook 将 prompt_filter_results / content_filter_results 写入 ExtraFields。
+type Client struct {
+	inner *openaiCompatChat.Client
+}
+
+func New(cfg Config) (*Client, error) {
+	baseURL := strings.TrimSpace(cfg.BaseURL)
+	if baseURL == "" {
+		return nil, fmt.Errorf("%s: base url required (https://{resource}.openai.azure.com)", llm.ProviderAzureOpenAI)
+	}
+	deployment := strings.TrimSpace(cfg.Deployment)
+	if deployment == "" {
+		return nil, fmt.Errorf("%s: deployment required", llm.ProviderAzureOpenAI)
+	}
+	apiVersion := strings.TrimSpace(cfg.APIVersion)
+	if apiVersion == "" {
+		apiVersion = DefaultAPIVersion
+	}
+
+	// Azure 以部署名确定模型，请求体中的 model 仅作记录，默认取部署名
+	defaults := []llm.ChatOption{
+		llm.WithModel(deployment),
+		llm.WithResponseHook(contentFilterResponseHook),
+		llm.WithStreamEventHook(contentFilterStreamEventHook),
+	}
+
+	inner, err := openaiCompatChat.New(openaiCompatChat.Config{
+		Provider:       llm.ProviderAzureOpenAI,
+		BaseURL:        baseURL,
+		Path:           "/openai/deployments/" + url.PathEscape(deployment) + "/chat/completions",
+		APIKey:         cfg.APIKey,
+		APIKeyHeader:   "api-key",
+		HTTPClient:     cfg.HTTPClient,
+		HTTPX:          cfg.HTTPX,
+		HTTPXOptions:   cfg.HTTPXOptions,
+		DefaultHeaders: cfg.DefaultHeaders,
+		DefaultQuery:   url.Values{"api-version": {apiVersion}},
+		DefaultOptions: slices.Concat(defaults, cfg.DefaultOptions),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{inner: inner}, nil
+}
+
+func (*Client) Provider() llm.Provider { return llm.ProviderAzureOpenAI }
+
+func (c *Client) Chat(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+	return c.inner.Chat(ctx, messages, opts...)
+}
+
+func (c *Client) ChatStream(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (llm.Stream, error) {
+	return c.inner.ChatStream(ctx, messages, opts...)
+}
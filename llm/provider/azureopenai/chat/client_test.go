@@ -0,0 +1,184 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func newTestClient(t *testing.T, status int, contentType, body string, inspect func(*http.Request, map[string]any)) *Client {
+	t.Helper()
+
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			var got map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+			if inspect != nil {
+				inspect(r, got)
+			}
+			h := make(http.Header)
+			h.Set("Content-Type", contentType)
+			return &http.Response{
+				StatusCode: status,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header:     h,
+				Request:    r,
+			}, nil
+		}),
+	}
+
+	c, err := New(Config{
+		BaseConfig: BaseConfig{
+			BaseURL:    "https://res.openai.azure.com",
+			APIKey:     "az-key",
+			HTTPClient: httpClient,
+		},
+		Deployment: "gpt-4o-prod",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+// TestChat_DeploymentURLAndFilterResults 测试部署 URL、api-key 认证与内容过滤结果映射
+func TestChat_DeploymentURLAndFilterResults(t *testing.T) {
+	t.Parallel()
+
+	var gotURL, gotKey, gotAuth string
+	var gotReq map[string]any
+	c := newTestClient(t, http.StatusOK, "application/json", `{
+  "id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o-2024-08-06",
+  "prompt_filter_results":[{"prompt_index":0,"content_filter_results":{"hate":{"filtered":false,"severity":"safe"}}}],
+  "choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"hi"},
+    "content_filter_results":{"hate":{"filtered":false,"severity":"safe"}}}],
+  "usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}
+}`, func(r *http.Request, req map[string]any) {
+		gotURL = r.URL.String()
+		gotKey = r.Header.Get("api-key")
+		gotAuth = r.Header.Get("Authorization")
+		gotReq = req
+	})
+
+	resp, err := c.Chat(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if gotURL != "https://res.openai.azure.com/openai/deployments/gpt-4o-prod/chat/completions?api-version="+DefaultAPIVersion {
+		t.Errorf("url = %q", gotURL)
+	}
+	if gotKey != "az-key" || gotAuth != "" {
+		t.Errorf("api-key = %q, Authorization = %q", gotKey, gotAuth)
+	}
+	if gotReq["model"] != "gpt-4o-prod" {
+		t.Errorf("model = %v, want deployment name", gotReq["model"])
+	}
+
+	if resp.Choices[0].Message.Text() != "hi" {
+		t.Errorf("Text() = %q", resp.Choices[0].Message.Text())
+	}
+	pfr, ok := resp.ExtraFields[ExtraPromptFilterResults].([]any)
+	if !ok || len(pfr) != 1 {
+		t.Errorf("prompt_filter_results = %#v", resp.ExtraFields[ExtraPromptFilterResults])
+	}
+	cfr, ok := resp.ExtraFields[ExtraContentFilterResults].([]any)
+	if !ok || len(cfr) != 1 {
+		t.Fatalf("content_filter_results = %#v", resp.ExtraFields[ExtraContentFilterResults])
+	}
+	if hate := cfr[0].(map[string]any)["hate"].(map[string]any); hate["severity"] != "safe" {
+		t.Errorf("content_filter_results[0] = %#v", cfr[0])
+	}
+}
+
+// TestChatStream_FilterResults 测试流式首个仅含 prompt_filter_results 的数据块
+func TestChatStream_FilterResults(t *testing.T) {
+	t.Parallel()
+
+	body := `data: {"id":"","object":"","created":0,"model":"","choices":[],"prompt_filter_results":[{"prompt_index":0,"content_filter_results":{}}]}
+
+data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"},"finish_reason":null,"content_filter_results":{"hate":{"filtered":false,"severity":"safe"}}}]}
+
+data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`
+	c := newTestClient(t, http.StatusOK, "text/event-stream", body, nil)
+
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var text string
+	var sawPrompt, sawContent bool
+	for {
+		ev, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		text += ev.Delta
+		if _, ok := ev.ExtraFields[ExtraPromptFilterResults]; ok {
+			sawPrompt = true
+		}
+		if _, ok := ev.ExtraFields[ExtraContentFilterResults].(map[string]any); ok {
+			sawContent = true
+		}
+	}
+
+	if text != "Hello" {
+		t.Errorf("text = %q", text)
+	}
+	if !sawPrompt || !sawContent {
+		t.Errorf("sawPrompt = %v, sawContent = %v", sawPrompt, sawContent)
+	}
+}
+
+// TestChat_ContentFilterError 测试内容过滤错误信封
+func TestChat_ContentFilterError(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, http.StatusBadRequest, "application/json", `{"error":{
+  "message":"The response was filtered due to the prompt triggering Azure OpenAI's content management policy.",
+  "type":null,"param":"prompt","code":"content_filter","status":400,
+  "innererror":{"code":"ResponsibleAIPolicyViolation","content_filter_result":{
+    "hate":{"filtered":false,"severity":"safe"},"violence":{"filtered":true,"severity":"medium"}}}
+}}`, nil)
+
+	_, err := c.Chat(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	ae, ok := llm.AsAPIError(err)
+	if !ok {
+		t.Fatalf("Chat() error = %v, want *llm.APIError", err)
+	}
+	if ae.Provider != llm.ProviderAzureOpenAI || ae.Code != "content_filter" || ae.Type != "ResponsibleAIPolicyViolation" {
+		t.Errorf("APIError = %+v", ae)
+	}
+	if !IsContentFilter(err) {
+		t.Error("IsContentFilter() should return true")
+	}
+	result, ok := ContentFilterResult(err)
+	if !ok {
+		t.Fatal("ContentFilterResult() ok = false")
+	}
+	if v := result["violence"].(map[string]any); v["filtered"] != true {
+		t.Errorf("violence = %#v", v)
+	}
+}
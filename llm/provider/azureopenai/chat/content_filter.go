@@ -0,0 +1,120 @@
+package chat
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// ExtraFields 中的内容过滤字段键
+const (
+	// ExtraPromptFilterResults 对应响应顶层的 prompt_filter_results（[]any）
+	ExtraPromptFilterResults = "prompt_filter_results"
+	// ExtraContentFilterResults 对应 choices[].content_filter_results：
+	// ChatResponse 中为与 Choices 一一对应的 []any；StreamEvent 中为该事件所属 choice 的结果（map[string]any）
+	ExtraContentFilterResults = "content_filter_results"
+)
+
+// errCodeContentFilter Azure 内容过滤错误的 error.code
+const errCodeContentFilter = "content_filter"
+
+type contentFilterEnvelope struct {
+	PromptFilterResults json.RawMessage `json:"prompt_filter_results"`
+	Choices             []struct {
+		Index                int             `json:"index"`
+		ContentFilterResults json.RawMessage `json:"content_filter_results"`
+	} `json:"choices"`
+}
+
+func contentFilterResponseHook(dst *schema.ChatResponse, raw json.RawMessage) error {
+	var env contentFilterEnvelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil
+	}
+
+	if v, ok := decodeAny(env.PromptFilterResults); ok {
+		setExtra(&dst.ExtraFields, ExtraPromptFilterResults, v)
+	}
+
+	results := make([]any, len(env.Choices))
+	var found bool
+	for i, c := range env.Choices {
+		if v, ok := decodeAny(c.ContentFilterResults); ok {
+			results[i] = v
+			found = true
+		}
+	}
+	if found {
+		setExtra(&dst.ExtraFields, ExtraContentFilterResults, results)
+	}
+	return nil
+}
+
+func contentFilterStreamEventHook(dst *schema.StreamEvent, raw json.RawMessage) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	var env contentFilterEnvelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil
+	}
+
+	if v, ok := decodeAny(env.PromptFilterResults); ok {
+		setExtra(&dst.ExtraFields, ExtraPromptFilterResults, v)
+	}
+	for _, c := range env.Choices {
+		if c.Index != dst.ChoiceIndex {
+			continue
+		}
+		if v, ok := decodeAny(c.ContentFilterResults); ok {
+			setExtra(&dst.ExtraFields, ExtraContentFilterResults, v)
+		}
+	}
+	return nil
+}
+
+func decodeAny(raw json.RawMessage) (any, bool) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, false
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+func setExtra(m *map[string]any, k string, v any) {
+	if *m == nil {
+		*m = make(map[string]any)
+	}
+	(*m)[k] = v
+}
+
+// IsContentFilter 判断错误是否为 Azure 内容过滤拒绝（error.code 为 "content_filter"）
+func IsContentFilter(err error) bool {
+	ae, ok := llm.AsAPIError(err)
+	return ok && strings.EqualFold(ae.Code, errCodeContentFilter)
+}
+
+// ContentFilterResult 从内容过滤错误中提取 error.innererror.content_filter_result
+func ContentFilterResult(err error) (map[string]any, bool) {
+	ae, ok := llm.AsAPIError(err)
+	if !ok || len(ae.Raw) == 0 {
+		return nil, false
+	}
+	var env struct {
+		Error struct {
+			InnerError struct {
+				ContentFilterResult map[string]any `json:"content_filter_result"`
+			} `json:"innererror"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(ae.Raw, &env); err != nil {
+		return nil, false
+	}
+	r := env.Error.InnerError.ContentFilterResult
+	return r, r != nil
+}
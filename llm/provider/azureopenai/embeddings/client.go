@@ -0,0 +1,80 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm"
+	openaiCompatEmbeddings "github.com/lgc202/go-kit/llm/internal/openai_compat/embeddings"
+	"github.com/lgc202/go-kit/llm/provider/base"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// DefaultAPIVersion 默认的 api-version 查询参数
+const DefaultAPIVersion = "2024-10-21"
+
+var _ llm.Embedder = (*Client)(nil)
+var _ llm.ProviderNamer = (*Client)(nil)
+
+type BaseConfig = base.Config
+
+type Config struct {
+	// BaseURL 为资源端点，如 "https://{resource}.openai.azure.com"；APIKey 以 api-key 请求头发送
+	BaseConfig
+
+	// Deployment 部署名称（必填），请求发往 /openai/deployments/{Deployment}/embeddings
+	Deployment string
+
+	// APIVersion api-version 查询参数，默认 DefaultAPIVersion
+	APIVersion string
+
+	// DefaultOptions 客户端级别的默认请求选项
+	DefaultOptions []llm.EmbeddingOption
+}
+
+type Client struct {
+	inner *openaiCompatEmbeddings.Client
+}
+
+func New(cfg Config) (*Client, error) {
+	baseURL := strings.TrimSpace(cfg.BaseURL)
+	if baseURL == "" {
+		return nil, fmt.Errorf("%s: base url required (https://{resource}.openai.azure.com)", llm.ProviderAzureOpenAI)
+	}
+	deployment := strings.TrimSpace(cfg.Deployment)
+	if deployment == "" {
+		return nil, fmt.Errorf("%s: deployment required", llm.ProviderAzureOpenAI)
+	}
+	apiVersion := strings.TrimSpace(cfg.APIVersion)
+	if apiVersion == "" {
+		apiVersion = DefaultAPIVersion
+	}
+
+	inner, err := openaiCompatEmbeddings.New(openaiCompatEmbeddings.Config{
+		Provider:       llm.ProviderAzureOpenAI,
+		BaseURL:        baseURL,
+		Path:           "/openai/deployments/" + url.PathEscape(deployment) + "/embeddings",
+		APIKey:         cfg.APIKey,
+		APIKeyHeader:   "api-key",
+		HTTPClient:     cfg.HTTPClient,
+		HTTPX:          cfg.HTTPX,
+		HTTPXOptions:   cfg.HTTPXOptions,
+		DefaultHeaders: cfg.DefaultHeaders,
+		DefaultQuery:   url.Values{"api-version": {apiVersion}},
+		DefaultOptions: slices.Concat([]llm.EmbeddingOption{llm.WithModel(deployment)}, cfg.DefaultOptions),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{inner: inner}, nil
+}
+
+func (*Client) Provider() llm.Provider { return llm.ProviderAzureOpenAI }
+
+func (c *Client) Embed(ctx context.Context, inputs []string, opts ...llm.EmbeddingOption) (schema.EmbeddingResponse, error) {
+	return c.inner.Embed(ctx, inputs, opts...)
+}
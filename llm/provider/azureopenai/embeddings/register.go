@@ -0,0 +1,25 @@
+package embeddings
+
+import (
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/provider/base"
+)
+
+// init 注册到 llm；llm.Config.Params 支持 "deployment"（默认取 Model）与 "api-version"
+func init() {
+	llm.RegisterEmbedder(llm.ProviderAzureOpenAI, func(cfg llm.Config) (llm.Embedder, error) {
+		return New(Config{
+			BaseConfig:     base.FromConfig(cfg),
+			Deployment:     deployment(cfg),
+			APIVersion:     cfg.Param("api-version"),
+			DefaultOptions: cfg.DefaultEmbeddingOptions(),
+		})
+	})
+}
+
+func deployment(cfg llm.Config) string {
+	if d := cfg.Param("deployment"); d != "" {
+		return d
+	}
+	return cfg.Model
+}
@@ -1,6 +1,11 @@
 package base
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/lgc202/go-kit/httpx"
+	"github.com/lgc202/go-kit/llm"
+)
 
 // Config 是 provider 侧通用的基础配置（BaseURL/APIKey/HTTPClient/DefaultHeaders）。
 type Config struct {
@@ -8,6 +13,26 @@ type Config struct {
 	APIKey     string
 	HTTPClient *http.Client
 
+	// HTTPX 设置后所有请求经由该 httpx.Client 发出，获得重试、Retry-After、X-Request-ID、限流与 Before/After 钩子。
+	// 优先级高于 HTTPClient 与 HTTPXOptions。
+	HTTPX *httpx.Client
+
+	// HTTPXOptions 未设置 HTTPX 时，用这些选项构建内部 httpx.Client（复用 HTTPClient 的 Transport）。
+	// 注意：Client 级总超时默认关闭；POST 默认不重试，需通过 httpx.WithRetry 显式开启。
+	HTTPXOptions []httpx.Option
+
 	// DefaultHeaders 默认请求头，会被请求级别的 headers 覆盖
 	DefaultHeaders http.Header
 }
+
+// FromConfig 将 llm.Config 转换为 provider 基础配置，供注册到 llm 的工厂使用
+func FromConfig(cfg llm.Config) Config {
+	return Config{
+		BaseURL:        cfg.BaseURL,
+		APIKey:         cfg.APIKey,
+		HTTPClient:     cfg.HTTPClient,
+		HTTPX:          cfg.HTTPX,
+		HTTPXOptions:   cfg.HTTPXOptions,
+		DefaultHeaders: cfg.DefaultHeaders,
+	}
+}
@@ -0,0 +1,15 @@
+package chat
+
+import (
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/provider/base"
+)
+
+func init() {
+	llm.RegisterChat(llm.ProviderDeepSeek, func(cfg llm.Config) (llm.ChatModel, error) {
+		return New(Config{
+			BaseConfig:     base.FromConfig(cfg),
+			DefaultOptions: cfg.DefaultChatOptions(),
+		})
+	})
+}
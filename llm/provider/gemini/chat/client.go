@@ -0,0 +1,230 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/internal/openai_compat/transport"
+	"github.com/lgc202/go-kit/llm/provider/base"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+const DefaultBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+const (
+	httpAcceptJSON = "application/json"
+	httpAcceptSSE  = "text/event-stream"
+)
+
+var _ llm.ChatModel = (*Client)(nil)
+var _ llm.ProviderNamer = (*Client)(nil)
+
+type BaseConfig = base.Config
+
+type Config struct {
+	BaseConfig
+
+	// DefaultOptions 客户端级别的默认请求选项
+	DefaultOptions []llm.ChatOption
+}
+
+// Client Gemini generateContent / streamGenerateContent 原生客户端
+type Client struct {
+	provider string
+
+	t *transport.Client
+
+	defaultOpts []llm.ChatOption
+}
+
+func New(cfg Config) (*Client, error) {
+	baseURL := strings.TrimSpace(cfg.BaseURL)
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	t, err := transport.New(transport.Config{
+		Provider:       llm.ProviderGemini,
+		BaseURL:        baseURL,
+		APIKey:         cfg.APIKey,
+		APIKeyHeader:   "x-goog-api-key",
+		HTTPClient:     cfg.HTTPClient,
+		HTTPX:          cfg.HTTPX,
+		HTTPXOptions:   cfg.HTTPXOptions,
+		DefaultHeaders: cfg.DefaultHeaders,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		provider:    t.Provider(),
+		t:           t,
+		defaultOpts: slices.Clone(cfg.DefaultOptions),
+	}, nil
+}
+
+func (*Client) Provider() llm.Provider { return llm.ProviderGemini }
+
+func (c *Client) Chat(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+	reqCfg := llm.ApplyChatOptions(slices.Concat(c.defaultOpts, opts)...)
+
+	payload, err := c.buildRequest(messages, reqCfg)
+	if err != nil {
+		return schema.ChatResponse{}, err
+	}
+
+	resp, err := c.t.PostJSON(ctx, payload, transport.RequestConfig{
+		Timeout:    reqCfg.Timeout,
+		Headers:    reqCfg.Headers,
+		ErrorHooks: reqCfg.ErrorHooks,
+		Path:       modelPath(reqCfg.Model, "generateContent"),
+	}, httpAcceptJSON)
+	if err != nil {
+		return schema.ChatResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return schema.ChatResponse{}, fmt.Errorf("%s: read response: %w", c.provider, err)
+	}
+
+	var in generateContentResponse
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return schema.ChatResponse{}, fmt.Errorf("%s: decode response: %w", c.provider, err)
+	}
+
+	out := toSchemaChatResponse(in)
+	if out.Model == "" {
+		out.Model = reqCfg.Model
+	}
+	if reqCfg.KeepRaw {
+		out.Raw = json.RawMessage(raw)
+	}
+	for _, h := range reqCfg.ResponseHooks {
+		if h == nil {
+			continue
+		}
+		if err := h(&out, json.RawMessage(raw)); err != nil {
+			return schema.ChatResponse{}, err
+		}
+	}
+	return out, nil
+}
+
+func (c *Client) ChatStream(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (llm.Stream, error) {
+	reqCfg := llm.ApplyChatOptions(slices.Concat(c.defaultOpts, opts)...)
+
+	payload, err := c.buildRequest(messages, reqCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.t.PostJSON(ctx, payload, transport.RequestConfig{
+		Timeout:    reqCfg.Timeout,
+		Headers:    reqCfg.Headers,
+		ErrorHooks: reqCfg.ErrorHooks,
+		Path:       modelPath(reqCfg.Model, "streamGenerateContent"),
+		Query:      url.Values{"alt": {"sse"}},
+
+		Stream:            true,
+		StreamIdleTimeout: reqCfg.StreamIdleTimeout,
+		FirstTokenTimeout: reqCfg.FirstTokenTimeout,
+	}, httpAcceptSSE)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.WatchStream(resp.Body, newStream(c.provider, resp.Body, reqCfg.KeepRaw, reqCfg.StreamEventHooks)), nil
+}
+
+// modelPath 构建 /models/{model}:{method}，model 可带或不带 "models/" 前缀
+func modelPath(model, method string) string {
+	model = strings.TrimPrefix(strings.TrimSpace(model), "models/")
+	return "/models/" + url.PathEscape(model) + ":" + method
+}
+
+// buildRequest 构建 generateContent 请求。
+//
+// Gemini 不支持的选项（LogitBias、ServiceTier、Metadata、User、StreamOptions 等）会被忽略；
+// ParallelToolCalls 由模型自行决定，同样被忽略。
+func (c *Client) buildRequest(messages []schema.Message, cfg llm.ChatConfig) (generateContentRequest, error) {
+	if len(messages) == 0 {
+		return generateContentRequest{}, fmt.Errorf("%s: messages required", c.provider)
+	}
+	if strings.TrimSpace(cfg.Model) == "" {
+		return generateContentRequest{}, fmt.Errorf("%s: model required (use llm.WithModel)", c.provider)
+	}
+
+	system, contents, err := toWireContents(c.provider, messages)
+	if err != nil {
+		return generateContentRequest{}, err
+	}
+	if len(contents) == 0 {
+		return generateContentRequest{}, fmt.Errorf("%s: at least one non-system message required", c.provider)
+	}
+
+	req := generateContentRequest{
+		provider:          c.provider,
+		Contents:          contents,
+		SystemInstruction: system,
+	}
+
+	if len(cfg.Tools) > 0 {
+		tools, err := toWireTools(c.provider, cfg.Tools)
+		if err != nil {
+			return generateContentRequest{}, err
+		}
+		req.Tools = tools
+	}
+	if cfg.ToolChoice != nil {
+		req.ToolConfig = toWireToolConfig(*cfg.ToolChoice)
+	}
+
+	gen := generationConfig{
+		CandidateCount:   cfg.N,
+		Temperature:      cfg.Temperature,
+		TopP:             cfg.TopP,
+		Seed:             cfg.Seed,
+		PresencePenalty:  cfg.PresencePenalty,
+		FrequencyPenalty: cfg.FrequencyPenalty,
+		ResponseLogprobs: cfg.Logprobs,
+		Logprobs:         cfg.TopLogprobs,
+	}
+	if cfg.MaxCompletionTokens != nil {
+		gen.MaxOutputTokens = cfg.MaxCompletionTokens
+	} else {
+		gen.MaxOutputTokens = cfg.MaxTokens
+	}
+	if cfg.Stop != nil {
+		gen.StopSequences = *cfg.Stop
+	}
+	if rf := cfg.ResponseFormat; rf != nil {
+		switch rf.Type {
+		case "json_object":
+			gen.ResponseMIMEType = "application/json"
+		case "json_schema":
+			rs, err := toResponseSchema(rf.JSONSchema)
+			if err != nil {
+				return generateContentRequest{}, fmt.Errorf("%s: invalid response_format.json_schema JSON: %w", c.provider, err)
+			}
+			gen.ResponseMIMEType = "application/json"
+			gen.ResponseSchema = rs
+		}
+	}
+	if !gen.isZero() {
+		req.GenerationConfig = &gen
+	}
+
+	req.extra = cfg.ExtraFields
+	req.allowExtraFieldOverride = cfg.AllowExtraFieldOverride
+
+	return req, nil
+}
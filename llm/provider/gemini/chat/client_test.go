@@ -0,0 +1,336 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func newTestClient(t *testing.T, status int, contentType, body string, inspect func(*http.Request, map[string]any)) *Client {
+	t.Helper()
+
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			var got map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+			if inspect != nil {
+				inspect(r, got)
+			}
+			h := make(http.Header)
+			h.Set("Content-Type", contentType)
+			return &http.Response{
+				StatusCode: status,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header:     h,
+				Request:    r,
+			}, nil
+		}),
+	}
+
+	c, err := New(Config{
+		BaseConfig: BaseConfig{
+			APIKey:     "g-key",
+			HTTPClient: httpClient,
+		},
+		DefaultOptions: []llm.ChatOption{llm.WithModel("gemini-2.5-flash")},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+// TestChat_RequestMapping 测试 systemInstruction、functionCall/functionResponse、generationConfig 的映射
+func TestChat_RequestMapping(t *testing.T) {
+	t.Parallel()
+
+	var gotReq map[string]any
+	var gotURL string
+	var gotKey string
+	c := newTestClient(t, http.StatusOK, "application/json", `{
+  "candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP","index":0}],
+  "usageMetadata":{"promptTokenCount":3,"candidatesTokenCount":1,"totalTokenCount":4}
+}`, func(r *http.Request, req map[string]any) {
+		gotReq = req
+		gotURL = r.URL.String()
+		gotKey = r.Header.Get("x-goog-api-key")
+	})
+
+	messages := []schema.Message{
+		schema.SystemMessage("You are terse."),
+		{
+			Role: schema.RoleUser,
+			Content: []schema.ContentPart{
+				schema.TextPart("Weather in Paris?"),
+				schema.BinaryPart("image/png", []byte{0x89, 0x50}),
+			},
+		},
+		{
+			Role: schema.RoleAssistant,
+			ToolCalls: []schema.ToolCall{{
+				ID:       "gemini-call-0",
+				Type:     schema.ToolCallTypeFunction,
+				Function: schema.ToolFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+			}},
+		},
+		schema.ToolResultMessage("gemini-call-0", "sunny"),
+	}
+
+	tool, err := schema.NewFunctionTool("get_weather", "Get weather", map[string]any{"type": "object"})
+	if err != nil {
+		t.Fatalf("NewFunctionTool() error = %v", err)
+	}
+	_, err = c.Chat(context.Background(), messages,
+		llm.WithTemperature(0.2),
+		llm.WithMaxTokens(128),
+		llm.WithStop("END"),
+		llm.WithTools(tool),
+		llm.WithToolChoice(schema.ToolChoice{FunctionName: "get_weather"}),
+		WithTopK(20),
+		WithThinking(1024, true),
+	)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if gotURL != "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash:generateContent" {
+		t.Errorf("url = %q", gotURL)
+	}
+	if gotKey != "g-key" {
+		t.Errorf("x-goog-api-key = %q", gotKey)
+	}
+
+	sys := gotReq["systemInstruction"].(map[string]any)["parts"].([]any)
+	if sys[0].(map[string]any)["text"] != "You are terse." {
+		t.Errorf("systemInstruction = %#v", sys)
+	}
+
+	contents := gotReq["contents"].([]any)
+	if len(contents) != 3 {
+		t.Fatalf("contents len = %d, want 3: %#v", len(contents), contents)
+	}
+	userParts := contents[0].(map[string]any)["parts"].([]any)
+	if blob := userParts[1].(map[string]any)["inlineData"].(map[string]any); blob["mimeType"] != "image/png" || blob["data"] != "iVA=" {
+		t.Errorf("inlineData = %#v", blob)
+	}
+	model := contents[1].(map[string]any)
+	fc := model["parts"].([]any)[0].(map[string]any)["functionCall"].(map[string]any)
+	if model["role"] != "model" || fc["name"] != "get_weather" || fc["args"].(map[string]any)["city"] != "Paris" {
+		t.Errorf("model content = %#v", model)
+	}
+	if _, ok := fc["id"]; ok {
+		t.Errorf("synthetic call id should not be sent: %#v", fc)
+	}
+	fr := contents[2].(map[string]any)["parts"].([]any)[0].(map[string]any)["functionResponse"].(map[string]any)
+	if fr["name"] != "get_weather" || fr["response"].(map[string]any)["content"] != "sunny" {
+		t.Errorf("functionResponse = %#v", fr)
+	}
+
+	gen := gotReq["generationConfig"].(map[string]any)
+	if gen["temperature"] != 0.2 || gen["maxOutputTokens"] != float64(128) || gen["topK"] != float64(20) {
+		t.Errorf("generationConfig = %#v", gen)
+	}
+	if stops := gen["stopSequences"].([]any); len(stops) != 1 || stops[0] != "END" {
+		t.Errorf("stopSequences = %#v", stops)
+	}
+	if tc := gen["thinkingConfig"].(map[string]any); tc["thinkingBudget"] != float64(1024) || tc["includeThoughts"] != true {
+		t.Errorf("thinkingConfig = %#v", tc)
+	}
+
+	fcc := gotReq["toolConfig"].(map[string]any)["functionCallingConfig"].(map[string]any)
+	if fcc["mode"] != "ANY" || fcc["allowedFunctionNames"].([]any)[0] != "get_weather" {
+		t.Errorf("toolConfig = %#v", fcc)
+	}
+	decl := gotReq["tools"].([]any)[0].(map[string]any)["functionDeclarations"].([]any)[0].(map[string]any)
+	if decl["name"] != "get_weather" || decl["parametersJsonSchema"] == nil {
+		t.Errorf("functionDeclarations[0] = %#v", decl)
+	}
+}
+
+// TestChat_ResponseMapping 测试思考摘要、functionCall 与用量的映射
+func TestChat_ResponseMapping(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, http.StatusOK, "application/json", `{
+  "candidates":[{
+    "content":{"role":"model","parts":[
+      {"text":"Need weather.","thought":true},
+      {"text":"Checking."},
+      {"functionCall":{"name":"get_weather","args":{"city":"Paris"}}}
+    ]},
+    "finishReason":"STOP","index":0
+  }],
+  "usageMetadata":{"promptTokenCount":30,"candidatesTokenCount":7,"thoughtsTokenCount":5,"cachedContentTokenCount":20,"totalTokenCount":42},
+  "modelVersion":"gemini-2.5-flash",
+  "responseId":"resp_1"
+}`, nil)
+
+	resp, err := c.Chat(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if resp.ID != "resp_1" || resp.Model != "gemini-2.5-flash" {
+		t.Errorf("ID = %q, Model = %q", resp.ID, resp.Model)
+	}
+	msg := resp.Choices[0].Message
+	if msg.ReasoningContent != "Need weather." || msg.Text() != "Checking." {
+		t.Errorf("ReasoningContent = %q, Text() = %q", msg.ReasoningContent, msg.Text())
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].ID != "gemini-call-0" || msg.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("ToolCalls = %#v", msg.ToolCalls)
+	}
+	if resp.Choices[0].FinishReason != schema.FinishReasonToolCalls {
+		t.Errorf("FinishReason = %q", resp.Choices[0].FinishReason)
+	}
+	if resp.Usage.PromptTokens != 30 || resp.Usage.CompletionTokens != 12 || resp.Usage.TotalTokens != 42 ||
+		resp.Usage.PromptCacheHitTokens != 20 || resp.Usage.PromptCacheMissTokens != 10 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+	if resp.Usage.CompletionTokensDetails == nil || resp.Usage.CompletionTokensDetails.ReasoningTokens != 5 {
+		t.Errorf("CompletionTokensDetails = %+v", resp.Usage.CompletionTokensDetails)
+	}
+}
+
+// TestChatStream_SSE 测试 streamGenerateContent?alt=sse 的映射
+func TestChatStream_SSE(t *testing.T) {
+	t.Parallel()
+
+	body := `data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Hmm.","thought":true}]},"index":0}]}
+
+data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]},"index":0}]}
+
+data: {"candidates":[{"content":{"role":"model","parts":[{"text":"lo"}]},"finishReason":"STOP","index":0}],"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":2,"totalTokenCount":6},"modelVersion":"gemini-2.5-flash-001"}
+
+`
+	var gotURL string
+	c := newTestClient(t, http.StatusOK, "text/event-stream", body, func(r *http.Request, _ map[string]any) {
+		gotURL = r.URL.String()
+	})
+
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	if !strings.HasSuffix(gotURL, "/models/gemini-2.5-flash:streamGenerateContent?alt=sse") {
+		t.Errorf("url = %q", gotURL)
+	}
+
+	var text, reasoning string
+	var finish *schema.FinishReason
+	var usage *schema.Usage
+	var model string
+	var dones int
+	for {
+		ev, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		text += ev.Delta
+		reasoning += ev.Reasoning
+		if ev.Type == schema.StreamEventDone {
+			dones++
+			if ev.FinishReason != nil {
+				finish, usage, model = ev.FinishReason, ev.Usage, ev.Model
+			}
+		}
+	}
+
+	if text != "Hello" || reasoning != "Hmm." {
+		t.Errorf("text = %q, reasoning = %q", text, reasoning)
+	}
+	if model != "gemini-2.5-flash-001" {
+		t.Errorf("Model = %q", model)
+	}
+	if finish == nil || *finish != schema.FinishReasonStop {
+		t.Errorf("FinishReason = %v", finish)
+	}
+	if usage == nil || usage.TotalTokens != 6 {
+		t.Errorf("Usage = %+v", usage)
+	}
+	if dones != 2 {
+		t.Errorf("done events = %d, want 2 (candidate finish + end of stream)", dones)
+	}
+}
+
+// TestChat_APIErrorResponse 测试 Google 错误信封（数字 code + status）
+func TestChat_APIErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, http.StatusTooManyRequests, "application/json",
+		`{"error":{"code":429,"message":"Resource has been exhausted","status":"RESOURCE_EXHAUSTED"}}`, nil)
+
+	_, err := c.Chat(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	ae, ok := llm.AsAPIError(err)
+	if !ok {
+		t.Fatalf("Chat() error = %v, want *llm.APIError", err)
+	}
+	if ae.Provider != llm.ProviderGemini || ae.Type != "RESOURCE_EXHAUSTED" || ae.Code != "429" || ae.Message != "Resource has been exhausted" {
+		t.Errorf("APIError = %+v", ae)
+	}
+	if !llm.IsRateLimit(err) {
+		t.Error("IsRateLimit() should return true")
+	}
+}
+
+// TestThoughtSignature_RoundTrip 测试流式函数调用的 thoughtSignature 被保留并在下一轮请求中回传
+func TestThoughtSignature_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	body := `data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Need weather.","thought":true}]},"index":0}]}
+
+data: {"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":{"city":"Paris"}},"thoughtSignature":"CiQBsig"}]},"finishReason":"STOP","index":0}]}
+
+`
+	c := newTestClient(t, http.StatusOK, "text/event-stream", body, nil)
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Weather?")})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	resp, err := llm.Accumulate(stream)
+	if err != nil {
+		t.Fatalf("Accumulate() error = %v", err)
+	}
+	msg := resp.Choices[0].Message
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Signature != "CiQBsig" {
+		t.Fatalf("ToolCalls = %+v", msg.ToolCalls)
+	}
+
+	var gotReq map[string]any
+	c = newTestClient(t, http.StatusOK, "application/json",
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":"Sunny."}]},"finishReason":"STOP","index":0}]}`,
+		func(_ *http.Request, req map[string]any) { gotReq = req })
+	history := []schema.Message{
+		schema.UserMessage("Weather?"),
+		msg,
+		schema.ToolResultMessage(msg.ToolCalls[0].ID, `{"temp":20}`),
+	}
+	if _, err := c.Chat(context.Background(), history); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	model := gotReq["contents"].([]any)[1].(map[string]any)
+	b, _ := json.Marshal(model["parts"])
+	want := `[{"functionCall":{"args":{"city":"Paris"},"name":"get_weather"},"thoughtSignature":"CiQBsig"}]`
+	if string(b) != want {
+		t.Errorf("model parts =\n%s\nwant\n%s", b, want)
+	}
+}
@@ -0,0 +1,336 @@
+package chat
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// syntheticCallIDPrefix 标记本地生成的工具调用 ID（Gemini 未返回 functionCall.id 时），
+// 这类 ID 不会回传给 API
+const syntheticCallIDPrefix = "gemini-call-"
+
+// toWireContents 将 schema 消息拆分为 systemInstruction 与 contents。
+//
+// RoleTool 消息转换为 user 角色的 functionResponse，函数名取 Message.Name，
+// 为空时根据 ToolCallID 从前序 assistant 消息的 ToolCalls 中查找；相邻同角色消息会合并。
+func toWireContents(provider string, messages []schema.Message) (*wireContent, []wireContent, error) {
+	var system *wireContent
+	out := make([]wireContent, 0, len(messages))
+	callNames := make(map[string]string)
+
+	for _, m := range messages {
+		var role string
+		var parts []wirePart
+		var err error
+
+		switch m.Role {
+		case schema.RoleSystem:
+			text := m.Text()
+			if text == "" {
+				continue
+			}
+			if system == nil {
+				system = &wireContent{}
+			}
+			system.Parts = append(system.Parts, wirePart{Text: text})
+			continue
+		case schema.RoleUser:
+			role = wireRoleUser
+			parts, err = toWireParts(provider, m.Content)
+		case schema.RoleAssistant:
+			role = wireRoleModel
+			parts, err = toWireParts(provider, m.Content)
+			for _, tc := range m.ToolCalls {
+				callNames[tc.ID] = tc.Function.Name
+				args := json.RawMessage(strings.TrimSpace(tc.Function.Arguments))
+				if len(args) == 0 {
+					args = json.RawMessage("{}")
+				}
+				if !json.Valid(args) {
+					return nil, nil, fmt.Errorf("%s: invalid tool call arguments JSON for %q", provider, tc.Function.Name)
+				}
+				parts = append(parts, wirePart{
+					FunctionCall: &wireFunctionCall{
+						ID:   wireCallID(tc.ID),
+						Name: tc.Function.Name,
+						Args: args,
+					},
+					// 思考模型要求函数调用的 thoughtSignature 原样回传
+					ThoughtSignature: tc.Signature,
+				})
+			}
+		case schema.RoleTool:
+			role = wireRoleUser
+			name := m.Name
+			if name == "" {
+				name = callNames[m.ToolCallID]
+			}
+			if name == "" {
+				return nil, nil, fmt.Errorf("%s: tool message %q requires a function name (set Message.Name or include the preceding assistant tool call)", provider, m.ToolCallID)
+			}
+			parts = []wirePart{{FunctionResponse: &wireFunctionResponse{
+				ID:       wireCallID(m.ToolCallID),
+				Name:     name,
+				Response: toFunctionResponse(m.Text()),
+			}}}
+		default:
+			return nil, nil, fmt.Errorf("%s: unsupported message role %q", provider, m.Role)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		if n := len(out); n > 0 && out[n-1].Role == role {
+			out[n-1].Parts = append(out[n-1].Parts, parts...)
+			continue
+		}
+		out = append(out, wireContent{Role: role, Parts: parts})
+	}
+
+	return system, out, nil
+}
+
+func wireCallID(id string) string {
+	if strings.HasPrefix(id, syntheticCallIDPrefix) {
+		return ""
+	}
+	return id
+}
+
+// toFunctionResponse 将工具结果包装为 functionResponse.response（必须是 JSON 对象）：
+// 结果本身是 JSON 对象时原样使用，否则包装为 {"content": text}
+func toFunctionResponse(text string) json.RawMessage {
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+	b, _ := json.Marshal(map[string]string{"content": text})
+	return b
+}
+
+func toWireParts(provider string, content []schema.ContentPart) ([]wirePart, error) {
+	parts := make([]wirePart, 0, len(content))
+	for _, p := range content {
+		switch part := p.(type) {
+		case schema.TextContent:
+			if part.Text == "" {
+				continue
+			}
+			parts = append(parts, wirePart{Text: part.Text})
+		case schema.ImageURLContent:
+			wp, err := imageURLPart(provider, part.URL)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, wp)
+		case schema.BinaryContent:
+			if strings.TrimSpace(part.MIMEType) == "" {
+				return nil, fmt.Errorf("%s: binary mime type required", provider)
+			}
+			if len(part.Data) == 0 {
+				return nil, fmt.Errorf("%s: binary data required", provider)
+			}
+			parts = append(parts, wirePart{InlineData: &wireBlob{
+				MIMEType: part.MIMEType,
+				Data:     base64.StdEncoding.EncodeToString(part.Data),
+			}})
+		default:
+			return nil, fmt.Errorf("%s: unsupported message.content part type %T", provider, p)
+		}
+	}
+	return parts, nil
+}
+
+// imageURLPart data URL 转为 inlineData，其余 URL（如 Files API / gs://）转为 fileData
+func imageURLPart(provider, u string) (wirePart, error) {
+	u = strings.TrimSpace(u)
+	if u == "" {
+		return wirePart{}, fmt.Errorf("%s: image url required", provider)
+	}
+	rest, ok := strings.CutPrefix(u, "data:")
+	if !ok {
+		return wirePart{FileData: &wireFileData{FileURI: u}}, nil
+	}
+	meta, data, ok := strings.Cut(rest, ",")
+	mimeType, isBase64 := strings.CutSuffix(meta, ";base64")
+	if !ok || !isBase64 || mimeType == "" {
+		return wirePart{}, fmt.Errorf("%s: unsupported data url, want data:<mime>;base64,<data>", provider)
+	}
+	return wirePart{InlineData: &wireBlob{MIMEType: mimeType, Data: data}}, nil
+}
+
+func toWireTools(provider string, tools []schema.Tool) ([]wireTool, error) {
+	decls := make([]wireFunctionDeclaration, 0, len(tools))
+	for _, t := range tools {
+		if t.Type != schema.ToolTypeFunction {
+			continue
+		}
+		if len(t.Function.Parameters) > 0 && !json.Valid(t.Function.Parameters) {
+			return nil, fmt.Errorf("%s: invalid tool parameters JSON for %q", provider, t.Function.Name)
+		}
+		decls = append(decls, wireFunctionDeclaration{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			Parameters:  t.Function.Parameters,
+		})
+	}
+	if len(decls) == 0 {
+		return nil, nil
+	}
+	return []wireTool{{FunctionDeclarations: decls}}, nil
+}
+
+func toWireToolConfig(tc schema.ToolChoice) *wireToolConfig {
+	out := &wireToolConfig{}
+	switch {
+	case tc.Mode == schema.ToolChoiceNone:
+		out.FunctionCallingConfig.Mode = "NONE"
+	case tc.Mode == schema.ToolChoiceAuto:
+		out.FunctionCallingConfig.Mode = "AUTO"
+	case tc.FunctionName != "":
+		out.FunctionCallingConfig.Mode = "ANY"
+		out.FunctionCallingConfig.AllowedFunctionNames = []string{tc.FunctionName}
+	default:
+		out.FunctionCallingConfig.Mode = "AUTO"
+	}
+	return out
+}
+
+// toResponseSchema 提取 json_schema 的 schema 本体：
+// 兼容 OpenAI 风格的 {"name":...,"schema":{...}} 包装与直接传入的 schema
+func toResponseSchema(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var wrapper struct {
+		Schema json.RawMessage `json:"schema"`
+	}
+	if err := json.Unmarshal(raw, &wrapper); err != nil {
+		return nil, err
+	}
+	if len(wrapper.Schema) > 0 {
+		return wrapper.Schema, nil
+	}
+	return raw, nil
+}
+
+func toSchemaFinishReason(reason string, hasToolCalls bool) schema.FinishReason {
+	switch reason {
+	case "STOP":
+		if hasToolCalls {
+			return schema.FinishReasonToolCalls
+		}
+		return schema.FinishReasonStop
+	case "MAX_TOKENS":
+		return schema.FinishReasonLength
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return schema.FinishReasonContentFilter
+	case "":
+		return ""
+	default:
+		return schema.FinishReason(strings.ToLower(reason))
+	}
+}
+
+// toSchemaUsage 将 usageMetadata 映射为 schema.Usage。
+//
+// Gemini 的 candidatesTokenCount 不含思考 token，这里 CompletionTokens 计入思考 token，
+// 并通过 CompletionTokensDetails.ReasoningTokens 单独给出，与 OpenAI 口径一致。
+func toSchemaUsage(u *wireUsageMetadata) schema.Usage {
+	if u == nil {
+		return schema.Usage{}
+	}
+	prompt := u.PromptTokenCount + u.ToolUsePromptTokenCount
+	out := schema.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: u.CandidatesTokenCount + u.ThoughtsTokenCount,
+		TotalTokens:      u.TotalTokenCount,
+	}
+	if out.TotalTokens == 0 {
+		out.TotalTokens = out.PromptTokens + out.CompletionTokens
+	}
+	if u.CachedContentTokenCount > 0 {
+		out.PromptCacheHitTokens = u.CachedContentTokenCount
+		out.PromptCacheMissTokens = prompt - u.CachedContentTokenCount
+	}
+	if u.ThoughtsTokenCount > 0 {
+		out.CompletionTokensDetails = &schema.CompletionTokensDetails{ReasoningTokens: u.ThoughtsTokenCount}
+	}
+	return out
+}
+
+// toSchemaParts 将候选内容拆分为正文、推理内容与工具调用。
+// callSeq 用于在 Gemini 未返回 functionCall.id 时生成本地 ID。
+func toSchemaParts(parts []wirePart, callSeq *int) (content []schema.ContentPart, reasoning string, calls []schema.ToolCall) {
+	for _, p := range parts {
+		switch {
+		case p.FunctionCall != nil:
+			id := p.FunctionCall.ID
+			if id == "" {
+				id = syntheticCallIDPrefix + strconv.Itoa(*callSeq)
+			}
+			*callSeq++
+			args := string(p.FunctionCall.Args)
+			if args == "" || args == "null" {
+				args = "{}"
+			}
+			calls = append(calls, schema.ToolCall{
+				ID:   id,
+				Type: schema.ToolCallTypeFunction,
+				Function: schema.ToolFunction{
+					Name:      p.FunctionCall.Name,
+					Arguments: args,
+				},
+				Signature: p.ThoughtSignature,
+			})
+		case p.InlineData != nil:
+			data, err := base64.StdEncoding.DecodeString(p.InlineData.Data)
+			if err != nil {
+				continue
+			}
+			content = append(content, schema.BinaryContent{MIMEType: p.InlineData.MIMEType, Data: data})
+		case p.FileData != nil:
+			content = append(content, schema.ImageURLContent{URL: p.FileData.FileURI})
+		case p.Thought:
+			reasoning += p.Text
+		case p.Text != "":
+			content = append(content, schema.TextContent{Text: p.Text})
+		}
+	}
+	return content, reasoning, calls
+}
+
+func toSchemaChatResponse(in generateContentResponse) schema.ChatResponse {
+	out := schema.ChatResponse{
+		ID:    in.ResponseID,
+		Model: in.ModelVersion,
+		Usage: toSchemaUsage(in.UsageMetadata),
+	}
+
+	out.Choices = make([]schema.Choice, 0, len(in.Candidates))
+	var callSeq int
+	for _, c := range in.Candidates {
+		msg := schema.Message{Role: schema.RoleAssistant}
+		if c.Content != nil {
+			msg.Content, msg.ReasoningContent, msg.ToolCalls = toSchemaParts(c.Content.Parts, &callSeq)
+		}
+		out.Choices = append(out.Choices, schema.Choice{
+			Index:        c.Index,
+			Message:      msg,
+			FinishReason: toSchemaFinishReason(c.FinishReason, len(msg.ToolCalls) > 0),
+		})
+	}
+
+	if in.PromptFeedback != nil && in.PromptFeedback.BlockReason != "" {
+		out.ExtraFields = map[string]any{"block_reason": in.PromptFeedback.BlockReason}
+	}
+	return out
+}
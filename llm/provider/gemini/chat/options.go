@@ -0,0 +1,52 @@
+package chat
+
+import "github.com/lgc202/go-kit/llm"
+
+// 扩展字段键，用于 llm.WithExtraField()
+// generationConfig 下的字段会被自动合并到 generationConfig 中
+const (
+	extTopK               = "topK"
+	extThinkingConfig     = "thinkingConfig"
+	extResponseModalities = "responseModalities"
+	extSafetySettings     = "safetySettings"
+	extCachedContent      = "cachedContent"
+)
+
+// SafetySetting 安全过滤设置
+type SafetySetting struct {
+	// Category 如 "HARM_CATEGORY_HARASSMENT"、"HARM_CATEGORY_DANGEROUS_CONTENT"
+	Category string `json:"category"`
+	// Threshold 如 "BLOCK_NONE"、"BLOCK_ONLY_HIGH"、"BLOCK_MEDIUM_AND_ABOVE"
+	Threshold string `json:"threshold"`
+}
+
+// WithTopK 只从概率最高的 K 个 token 中采样（generationConfig.topK）
+func WithTopK(k int) llm.ChatOption {
+	return llm.WithExtraField(extTopK, k)
+}
+
+// WithThinking 设置思考配置（generationConfig.thinkingConfig）
+// budgetTokens: 思考 token 预算，0 表示关闭思考，-1 表示动态预算
+// includeThoughts: 是否返回思考摘要（通过 ReasoningContent / StreamEvent.Reasoning 获取）
+func WithThinking(budgetTokens int, includeThoughts bool) llm.ChatOption {
+	return llm.WithExtraField(extThinkingConfig, map[string]any{
+		"thinkingBudget":  budgetTokens,
+		"includeThoughts": includeThoughts,
+	})
+}
+
+// WithResponseModalities 设置输出模态（generationConfig.responseModalities），如 "TEXT"、"IMAGE"
+// 图片输出以 schema.BinaryContent 返回
+func WithResponseModalities(modalities ...string) llm.ChatOption {
+	return llm.WithExtraField(extResponseModalities, modalities)
+}
+
+// WithSafetySettings 设置安全过滤阈值
+func WithSafetySettings(settings ...SafetySetting) llm.ChatOption {
+	return llm.WithExtraField(extSafetySettings, settings)
+}
+
+// WithCachedContent 使用已创建的上下文缓存，name 形如 "cachedContents/xxx"
+func WithCachedContent(name string) llm.ChatOption {
+	return llm.WithExtraField(extCachedContent, name)
+}
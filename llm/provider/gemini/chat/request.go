@@ -0,0 +1,206 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+)
+
+const (
+	wireRoleUser  = "user"
+	wireRoleModel = "model"
+)
+
+const extGenerationConfig = "generationConfig"
+
+// generationConfigKeys 是 generationConfig 下的字段名；
+// 通过 llm.WithExtraField 传入这些键时会合并到 generationConfig 而非请求顶层
+var generationConfigKeys = map[string]bool{
+	"stopSequences":              true,
+	"responseMimeType":           true,
+	"responseSchema":             true,
+	"responseJsonSchema":         true,
+	"responseModalities":         true,
+	"candidateCount":             true,
+	"maxOutputTokens":            true,
+	"temperature":                true,
+	"topP":                       true,
+	"topK":                       true,
+	"seed":                       true,
+	"presencePenalty":            true,
+	"frequencyPenalty":           true,
+	"responseLogprobs":           true,
+	"logprobs":                   true,
+	"enableEnhancedCivicAnswers": true,
+	"speechConfig":               true,
+	"thinkingConfig":             true,
+	"mediaResolution":            true,
+}
+
+type generateContentRequest struct {
+	provider string `json:"-"`
+
+	Contents          []wireContent     `json:"contents"`
+	SystemInstruction *wireContent      `json:"systemInstruction,omitempty"`
+	Tools             []wireTool        `json:"tools,omitempty"`
+	ToolConfig        *wireToolConfig   `json:"toolConfig,omitempty"`
+	GenerationConfig  *generationConfig `json:"generationConfig,omitempty"`
+
+	extra                   map[string]any `json:"-"`
+	allowExtraFieldOverride bool           `json:"-"`
+}
+
+// MarshalJSON 合并 ExtraFields：generationConfig 字段（或 "generationConfig" 键下的 map）
+// 合并进 generationConfig，其余字段（如 safetySettings、cachedContent）合并进请求顶层。
+func (r generateContentRequest) MarshalJSON() ([]byte, error) {
+	type alias generateContentRequest
+	base, err := json.Marshal(alias(r))
+	if err != nil {
+		return nil, err
+	}
+	if len(r.extra) == 0 {
+		return base, nil
+	}
+
+	top := make(map[string]any, len(r.extra))
+	gen := make(map[string]any)
+	for k, v := range r.extra {
+		switch {
+		case k == extGenerationConfig:
+			m, ok := v.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: extra field %q must be a map[string]any", r.provider, k)
+			}
+			maps.Copy(gen, m)
+		case generationConfigKeys[k]:
+			gen[k] = v
+		default:
+			top[k] = v
+		}
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(base, &obj); err != nil {
+		return nil, err
+	}
+	if err := mergeExtra(r.provider, obj, top, r.allowExtraFieldOverride, ""); err != nil {
+		return nil, err
+	}
+
+	if len(gen) > 0 {
+		genObj := make(map[string]json.RawMessage)
+		if raw, ok := obj[extGenerationConfig]; ok {
+			if err := json.Unmarshal(raw, &genObj); err != nil {
+				return nil, err
+			}
+		}
+		if err := mergeExtra(r.provider, genObj, gen, r.allowExtraFieldOverride, extGenerationConfig+"."); err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(genObj)
+		if err != nil {
+			return nil, err
+		}
+		obj[extGenerationConfig] = b
+	}
+
+	return json.Marshal(obj)
+}
+
+func mergeExtra(provider string, obj map[string]json.RawMessage, extra map[string]any, allowOverride bool, prefix string) error {
+	for k, v := range extra {
+		if !allowOverride {
+			if _, exists := obj[k]; exists {
+				return fmt.Errorf("%s: extra field %q conflicts with a built-in option (set llm.WithAllowExtraFieldOverride(true) to override)", provider, prefix+k)
+			}
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		obj[k] = b
+	}
+	return nil
+}
+
+type wireContent struct {
+	Role  string     `json:"role,omitempty"`
+	Parts []wirePart `json:"parts"`
+}
+
+// wirePart 是请求与响应共用的 Part，每个 Part 只设置其中一种数据字段
+type wirePart struct {
+	Text    string `json:"text,omitempty"`
+	Thought bool   `json:"thought,omitempty"`
+
+	InlineData *wireBlob     `json:"inlineData,omitempty"`
+	FileData   *wireFileData `json:"fileData,omitempty"`
+
+	FunctionCall     *wireFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *wireFunctionResponse `json:"functionResponse,omitempty"`
+
+	ThoughtSignature string `json:"thoughtSignature,omitempty"`
+}
+
+type wireBlob struct {
+	MIMEType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type wireFileData struct {
+	MIMEType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+type wireFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type wireFunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+type wireTool struct {
+	FunctionDeclarations []wireFunctionDeclaration `json:"functionDeclarations,omitempty"`
+}
+
+type wireFunctionDeclaration struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parametersJsonSchema,omitempty"`
+}
+
+type wireToolConfig struct {
+	FunctionCallingConfig wireFunctionCallingConfig `json:"functionCallingConfig"`
+}
+
+type wireFunctionCallingConfig struct {
+	Mode                 string   `json:"mode"` // AUTO / ANY / NONE
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+type generationConfig struct {
+	StopSequences    []string        `json:"stopSequences,omitempty"`
+	ResponseMIMEType string          `json:"responseMimeType,omitempty"`
+	ResponseSchema   json.RawMessage `json:"responseJsonSchema,omitempty"`
+	CandidateCount   *int            `json:"candidateCount,omitempty"`
+	MaxOutputTokens  *int            `json:"maxOutputTokens,omitempty"`
+	Temperature      *float64        `json:"temperature,omitempty"`
+	TopP             *float64        `json:"topP,omitempty"`
+	Seed             *int            `json:"seed,omitempty"`
+	PresencePenalty  *float64        `json:"presencePenalty,omitempty"`
+	FrequencyPenalty *float64        `json:"frequencyPenalty,omitempty"`
+	ResponseLogprobs *bool           `json:"responseLogprobs,omitempty"`
+	Logprobs         *int            `json:"logprobs,omitempty"`
+}
+
+func (g generationConfig) isZero() bool {
+	return len(g.StopSequences) == 0 && g.ResponseMIMEType == "" && len(g.ResponseSchema) == 0 &&
+		g.CandidateCount == nil && g.MaxOutputTokens == nil && g.Temperature == nil && g.TopP == nil &&
+		g.Seed == nil && g.PresencePenalty == nil && g.FrequencyPenalty == nil &&
+		g.ResponseLogprobs == nil && g.Logprobs == nil
+}
@@ -0,0 +1,28 @@
+package chat
+
+type generateContentResponse struct {
+	Candidates     []wireCandidate     `json:"candidates"`
+	PromptFeedback *wirePromptFeedback `json:"promptFeedback,omitempty"`
+	UsageMetadata  *wireUsageMetadata  `json:"usageMetadata,omitempty"`
+	ModelVersion   string              `json:"modelVersion,omitempty"`
+	ResponseID     string              `json:"responseId,omitempty"`
+}
+
+type wireCandidate struct {
+	Content      *wireContent `json:"content,omitempty"`
+	FinishReason string       `json:"finishReason,omitempty"`
+	Index        int          `json:"index"`
+}
+
+type wirePromptFeedback struct {
+	BlockReason string `json:"blockReason,omitempty"`
+}
+
+type wireUsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount,omitempty"`
+	ToolUsePromptTokenCount int `json:"toolUsePromptTokenCount,omitempty"`
+}
@@ -0,0 +1,143 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"sync/atomic"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/internal/openai_compat/transport"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// stream 解析 streamGenerateContent?alt=sse 的响应。
+//
+// 每个 SSE data 都是完整的 GenerateContentResponse 片段；Gemini 没有 [DONE] 标记，
+// 底层 body 结束时补发一个 StreamEventDone。
+type stream struct {
+	body io.ReadCloser
+	dec  *transport.SSEDecoder
+
+	provider string
+	keepRaw  bool
+	hooks    []llm.StreamEventHook
+
+	callSeq int
+
+	pending []schema.StreamEvent
+	done    bool
+	closed  atomic.Bool
+}
+
+func newStream(provider string, body io.ReadCloser, keepRaw bool, hooks []llm.StreamEventHook) *stream {
+	return &stream{
+		body:     body,
+		dec:      transport.NewSSEDecoder(body),
+		provider: provider,
+		keepRaw:  keepRaw,
+		hooks:    hooks,
+	}
+}
+
+func (s *stream) Recv() (schema.StreamEvent, error) {
+	for {
+		if len(s.pending) > 0 {
+			ev := s.pending[0]
+			s.pending = s.pending[1:]
+			return ev, nil
+		}
+		if s.done || s.closed.Load() {
+			return schema.StreamEvent{}, io.EOF
+		}
+
+		sse, err := s.dec.Next()
+		if errors.Is(err, io.EOF) {
+			s.done = true
+			return schema.StreamEvent{Type: schema.StreamEventDone}, nil
+		}
+		if err != nil {
+			return schema.StreamEvent{}, err
+		}
+		// 流中途的错误事件（如 {"error":{...}}）转换为 *llm.APIError
+		if err := transport.StreamError(llm.Provider(s.provider), sse); err != nil {
+			return schema.StreamEvent{}, err
+		}
+		data := sse.Data
+
+		rawBytes := []byte(data)
+		var raw json.RawMessage
+		if s.keepRaw || len(s.hooks) > 0 {
+			raw = json.RawMessage(rawBytes)
+			if s.keepRaw {
+				raw = json.RawMessage(slices.Clone(rawBytes))
+			}
+		}
+
+		var chunk generateContentResponse
+		if err := json.Unmarshal(rawBytes, &chunk); err != nil {
+			return schema.StreamEvent{}, err
+		}
+
+		var mapped []schema.StreamEvent
+		for _, c := range chunk.Candidates {
+			var content []schema.ContentPart
+			var reasoning string
+			var calls []schema.ToolCall
+			if c.Content != nil {
+				content, reasoning, calls = toSchemaParts(c.Content.Parts, &s.callSeq)
+			}
+
+			text := schema.Message{Content: content}.Text()
+			if text != "" || reasoning != "" || len(calls) > 0 {
+				mapped = append(mapped, schema.StreamEvent{
+					Type:        schema.StreamEventDelta,
+					ChoiceIndex: c.Index,
+					Delta:       text,
+					Reasoning:   reasoning,
+					ToolCalls:   calls,
+				})
+			}
+
+			if c.FinishReason != "" {
+				fr := toSchemaFinishReason(c.FinishReason, s.callSeq > 0)
+				ev := schema.StreamEvent{
+					Type:         schema.StreamEventDone,
+					ChoiceIndex:  c.Index,
+					FinishReason: &fr,
+				}
+				if chunk.UsageMetadata != nil {
+					usage := toSchemaUsage(chunk.UsageMetadata)
+					ev.Usage = &usage
+					ev.Model = chunk.ModelVersion
+				}
+				mapped = append(mapped, ev)
+			}
+		}
+
+		for i := range mapped {
+			if s.keepRaw {
+				mapped[i].Raw = raw
+			}
+			for _, h := range s.hooks {
+				if h == nil {
+					continue
+				}
+				if err := h(&mapped[i], raw); err != nil {
+					return schema.StreamEvent{}, err
+				}
+			}
+		}
+
+		s.pending = mapped
+	}
+}
+
+// Close 可与 Recv 并发调用，关闭 body 以中断阻塞中的读取
+func (s *stream) Close() error {
+	if !s.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	return s.body.Close()
+}
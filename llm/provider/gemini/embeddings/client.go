@@ -0,0 +1,184 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/internal/openai_compat/transport"
+	"github.com/lgc202/go-kit/llm/provider/base"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+const DefaultBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+const httpAcceptJSON = "application/json"
+
+var _ llm.Embedder = (*Client)(nil)
+var _ llm.ProviderNamer = (*Client)(nil)
+
+type BaseConfig = base.Config
+
+type Config struct {
+	BaseConfig
+
+	// DefaultOptions 客户端级别的默认请求选项
+	DefaultOptions []llm.EmbeddingOption
+}
+
+// Client Gemini batchEmbedContents 原生客户端
+type Client struct {
+	provider string
+
+	t *transport.Client
+
+	defaultOpts []llm.EmbeddingOption
+}
+
+func New(cfg Config) (*Client, error) {
+	baseURL := strings.TrimSpace(cfg.BaseURL)
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	t, err := transport.New(transport.Config{
+		Provider:       llm.ProviderGemini,
+		BaseURL:        baseURL,
+		APIKey:         cfg.APIKey,
+		APIKeyHeader:   "x-goog-api-key",
+		HTTPClient:     cfg.HTTPClient,
+		HTTPX:          cfg.HTTPX,
+		HTTPXOptions:   cfg.HTTPXOptions,
+		DefaultHeaders: cfg.DefaultHeaders,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		provider:    t.Provider(),
+		t:           t,
+		defaultOpts: slices.Clone(cfg.DefaultOptions),
+	}, nil
+}
+
+func (*Client) Provider() llm.Provider { return llm.ProviderGemini }
+
+// Embed 统一使用 batchEmbedContents，单条输入也不例外。
+// ExtraFields（如 taskType、outputDimensionality）会写入每一条子请求。
+func (c *Client) Embed(ctx context.Context, inputs []string, opts ...llm.EmbeddingOption) (schema.EmbeddingResponse, error) {
+	reqCfg := llm.ApplyEmbeddingOptions(slices.Concat(c.defaultOpts, opts)...)
+
+	if len(inputs) == 0 {
+		return schema.EmbeddingResponse{}, fmt.Errorf("%s: inputs required", c.provider)
+	}
+	model := strings.TrimPrefix(strings.TrimSpace(reqCfg.Model), "models/")
+	if model == "" {
+		return schema.EmbeddingResponse{}, fmt.Errorf("%s: model required (use llm.WithModel)", c.provider)
+	}
+
+	req := batchEmbedRequest{Requests: make([]embedContentRequest, 0, len(inputs))}
+	for _, in := range inputs {
+		req.Requests = append(req.Requests, embedContentRequest{
+			provider:                c.provider,
+			Model:                   "models/" + model,
+			Content:                 embedContent{Parts: []embedPart{{Text: in}}},
+			extra:                   reqCfg.ExtraFields,
+			allowExtraFieldOverride: reqCfg.AllowExtraFieldOverride,
+		})
+	}
+
+	resp, err := c.t.PostJSON(ctx, req, transport.RequestConfig{
+		Timeout:    reqCfg.Timeout,
+		Headers:    reqCfg.Headers,
+		ErrorHooks: reqCfg.ErrorHooks,
+		Path:       "/models/" + model + ":batchEmbedContents",
+	}, httpAcceptJSON)
+	if err != nil {
+		return schema.EmbeddingResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return schema.EmbeddingResponse{}, fmt.Errorf("%s: read response: %w", c.provider, err)
+	}
+
+	var in batchEmbedResponse
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return schema.EmbeddingResponse{}, fmt.Errorf("%s: decode response: %w", c.provider, err)
+	}
+
+	out := schema.EmbeddingResponse{
+		Model: model,
+		Data:  make([]schema.Embedding, 0, len(in.Embeddings)),
+	}
+	for i, e := range in.Embeddings {
+		out.Data = append(out.Data, schema.Embedding{Index: i, Vector: e.Values})
+	}
+	if reqCfg.KeepRaw {
+		out.Raw = json.RawMessage(raw)
+	}
+	return out, nil
+}
+
+type batchEmbedRequest struct {
+	Requests []embedContentRequest `json:"requests"`
+}
+
+type embedContentRequest struct {
+	provider string
+
+	Model   string       `json:"model"`
+	Content embedContent `json:"content"`
+
+	extra                   map[string]any
+	allowExtraFieldOverride bool
+}
+
+type embedContent struct {
+	Parts []embedPart `json:"parts"`
+}
+
+type embedPart struct {
+	Text string `json:"text"`
+}
+
+func (r embedContentRequest) MarshalJSON() ([]byte, error) {
+	type alias embedContentRequest
+	base, err := json.Marshal(alias(r))
+	if err != nil {
+		return nil, err
+	}
+	if len(r.extra) == 0 {
+		return base, nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(base, &obj); err != nil {
+		return nil, err
+	}
+	for k, v := range r.extra {
+		if !r.allowExtraFieldOverride {
+			if _, exists := obj[k]; exists {
+				return nil, fmt.Errorf("%s: extra field %q conflicts with a built-in option (set llm.WithAllowExtraFieldOverride(true) to override)", r.provider, k)
+			}
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		obj[k] = b
+	}
+	return json.Marshal(obj)
+}
+
+type batchEmbedResponse struct {
+	Embeddings []struct {
+		Values []float64 `json:"values"`
+	} `json:"embeddings"`
+}
@@ -0,0 +1,76 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lgc202/go-kit/llm"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestEmbed_BatchRequestAndResponse(t *testing.T) {
+	t.Parallel()
+
+	var gotPath, gotKey string
+	var gotReq map[string]any
+
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			gotPath = r.URL.Path
+			gotKey = r.Header.Get("x-goog-api-key")
+			if err := json.NewDecoder(r.Body).Decode(&gotReq); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+			h := make(http.Header)
+			h.Set("Content-Type", "application/json")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3,0.4]}]}`)),
+				Header:     h,
+				Request:    r,
+			}, nil
+		}),
+	}
+
+	c, err := New(Config{
+		BaseConfig:     BaseConfig{APIKey: "g-key", HTTPClient: httpClient},
+		DefaultOptions: []llm.EmbeddingOption{llm.WithModel("models/text-embedding-004")},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	resp, err := c.Embed(context.Background(), []string{"a", "b"}, WithTaskType("RETRIEVAL_QUERY"), WithOutputDimensionality(2))
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	if gotPath != "/v1beta/models/text-embedding-004:batchEmbedContents" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if gotKey != "g-key" {
+		t.Errorf("x-goog-api-key = %q", gotKey)
+	}
+	reqs := gotReq["requests"].([]any)
+	if len(reqs) != 2 {
+		t.Fatalf("requests = %#v", reqs)
+	}
+	first := reqs[0].(map[string]any)
+	if first["model"] != "models/text-embedding-004" || first["taskType"] != "RETRIEVAL_QUERY" || first["outputDimensionality"] != float64(2) {
+		t.Errorf("requests[0] = %#v", first)
+	}
+
+	if resp.Model != "text-embedding-004" || len(resp.Data) != 2 {
+		t.Fatalf("resp = %+v", resp)
+	}
+	if resp.Data[1].Index != 1 || resp.Data[1].Vector[0] != 0.3 {
+		t.Errorf("Data[1] = %+v", resp.Data[1])
+	}
+}
@@ -0,0 +1,25 @@
+package embeddings
+
+import "github.com/lgc202/go-kit/llm"
+
+// 扩展字段键，用于 llm.WithExtraField()
+const (
+	extTaskType             = "taskType"
+	extTitle                = "title"
+	extOutputDimensionality = "outputDimensionality"
+)
+
+// WithTaskType 设置嵌入任务类型，如 "RETRIEVAL_QUERY"、"RETRIEVAL_DOCUMENT"、"SEMANTIC_SIMILARITY"
+func WithTaskType(taskType string) llm.EmbeddingOption {
+	return llm.WithExtraField(extTaskType, taskType)
+}
+
+// WithTitle 设置文档标题，仅在 taskType 为 "RETRIEVAL_DOCUMENT" 时生效
+func WithTitle(title string) llm.EmbeddingOption {
+	return llm.WithExtraField(extTitle, title)
+}
+
+// WithOutputDimensionality 截断输出向量维度
+func WithOutputDimensionality(dims int) llm.EmbeddingOption {
+	return llm.WithExtraField(extOutputDimensionality, dims)
+}
@@ -0,0 +1,15 @@
+package embeddings
+
+import (
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/provider/base"
+)
+
+func init() {
+	llm.RegisterEmbedder(llm.ProviderGemini, func(cfg llm.Config) (llm.Embedder, error) {
+		return New(Config{
+			BaseConfig:     base.FromConfig(cfg),
+			DefaultOptions: cfg.DefaultEmbeddingOptions(),
+		})
+	})
+}
@@ -40,6 +40,8 @@ func New(cfg Config) (*Client, error) {
 		Path:           "/chat/completions",
 		APIKey:         cfg.APIKey,
 		HTTPClient:     cfg.HTTPClient,
+		HTTPX:          cfg.HTTPX,
+		HTTPXOptions:   cfg.HTTPXOptions,
 		DefaultHeaders: cfg.DefaultHeaders,
 		DefaultOptions: cfg.DefaultOptions,
 	})
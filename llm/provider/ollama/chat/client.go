@@ -2,16 +2,28 @@ package chat
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
 	"strings"
 
 	"github.com/lgc202/go-kit/llm"
-	openaiCompatChat "github.com/lgc202/go-kit/llm/internal/openai_compat/chat"
+	"github.com/lgc202/go-kit/llm/internal/openai_compat/transport"
 	"github.com/lgc202/go-kit/llm/provider/base"
 	"github.com/lgc202/go-kit/llm/schema"
 )
 
-// DefaultBaseURL Ollama 的 OpenAI 兼容端点（通常为本地地址）
-const DefaultBaseURL = "http://localhost:11434/v1"
+// DefaultBaseURL Ollama 原生 API 地址（通常为本地地址）
+const DefaultBaseURL = "http://localhost:11434"
+
+// DefaultPath Ollama 原生 chat 端点路径
+const DefaultPath = "/api/chat"
+
+const (
+	httpAcceptJSON   = "application/json"
+	httpAcceptNDJSON = "application/x-ndjson"
+)
 
 var _ llm.ChatModel = (*Client)(nil)
 var _ llm.ProviderNamer = (*Client)(nil)
@@ -25,38 +37,193 @@ type Config struct {
 	DefaultOptions []llm.ChatOption
 }
 
+// Client Ollama /api/chat 原生客户端
 type Client struct {
-	inner *openaiCompatChat.Client
+	provider string
+
+	t *transport.Client
+
+	defaultOpts []llm.ChatOption
 }
 
 func New(cfg Config) (*Client, error) {
-	baseURL := strings.TrimSpace(cfg.BaseURL)
-	if baseURL == "" {
-		baseURL = DefaultBaseURL
-	}
-
-	inner, err := openaiCompatChat.New(openaiCompatChat.Config{
+	t, err := transport.New(transport.Config{
 		Provider:       llm.ProviderOllama,
-		BaseURL:        baseURL,
-		Path:           "/chat/completions",
+		BaseURL:        nativeBaseURL(cfg.BaseURL),
+		Path:           DefaultPath,
 		APIKey:         cfg.APIKey,
 		HTTPClient:     cfg.HTTPClient,
+		HTTPX:          cfg.HTTPX,
+		HTTPXOptions:   cfg.HTTPXOptions,
 		DefaultHeaders: cfg.DefaultHeaders,
-		DefaultOptions: cfg.DefaultOptions,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{inner: inner}, nil
+	return &Client{
+		provider:    t.Provider(),
+		t:           t,
+		defaultOpts: slices.Clone(cfg.DefaultOptions),
+	}, nil
+}
+
+// nativeBaseURL 规范化 Ollama 地址：为空时使用 DefaultBaseURL，
+// 去掉 OpenAI 兼容端点的 "/v1" 后缀，以兼容旧配置
+func nativeBaseURL(baseURL string) string {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		return DefaultBaseURL
+	}
+	return strings.TrimSuffix(baseURL, "/v1")
 }
 
 func (*Client) Provider() llm.Provider { return llm.ProviderOllama }
 
 func (c *Client) Chat(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
-	return c.inner.Chat(ctx, messages, opts...)
+	reqCfg := llm.ApplyChatOptions(slices.Concat(c.defaultOpts, opts)...)
+
+	payload, err := c.buildRequest(messages, reqCfg, false)
+	if err != nil {
+		return schema.ChatResponse{}, err
+	}
+
+	resp, err := c.t.PostJSON(ctx, payload, transport.RequestConfig{
+		Timeout:    reqCfg.Timeout,
+		Headers:    reqCfg.Headers,
+		ErrorHooks: reqCfg.ErrorHooks,
+	}, httpAcceptJSON)
+	if err != nil {
+		return schema.ChatResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return schema.ChatResponse{}, fmt.Errorf("%s: read response: %w", c.provider, err)
+	}
+
+	var in chatResponse
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return schema.ChatResponse{}, fmt.Errorf("%s: decode response: %w", c.provider, err)
+	}
+	if strings.TrimSpace(in.Error) != "" {
+		return schema.ChatResponse{}, &llm.APIError{
+			Provider:   llm.ProviderOllama,
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(in.Error),
+			Raw:        raw,
+		}
+	}
+
+	out := toSchemaChatResponse(in)
+	if reqCfg.KeepRaw {
+		out.Raw = json.RawMessage(raw)
+	}
+	for _, h := range reqCfg.ResponseHooks {
+		if h == nil {
+			continue
+		}
+		if err := h(&out, json.RawMessage(raw)); err != nil {
+			return schema.ChatResponse{}, err
+		}
+	}
+	return out, nil
 }
 
 func (c *Client) ChatStream(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (llm.Stream, error) {
-	return c.inner.ChatStream(ctx, messages, opts...)
+	reqCfg := llm.ApplyChatOptions(slices.Concat(c.defaultOpts, opts)...)
+
+	payload, err := c.buildRequest(messages, reqCfg, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.t.PostJSON(ctx, payload, transport.RequestConfig{
+		Timeout:    reqCfg.Timeout,
+		Headers:    reqCfg.Headers,
+		ErrorHooks: reqCfg.ErrorHooks,
+
+		Stream:            true,
+		StreamIdleTimeout: reqCfg.StreamIdleTimeout,
+		FirstTokenTimeout: reqCfg.FirstTokenTimeout,
+	}, httpAcceptNDJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.WatchStream(resp.Body, newStream(c.provider, resp.Body, reqCfg.KeepRaw, reqCfg.StreamEventHooks)), nil
+}
+
+// buildRequest 构建 /api/chat 请求，采样参数映射到 options。
+//
+// Ollama 不支持的选项（N、Logprobs、LogitBias、ToolChoice、ParallelToolCalls、ServiceTier、User 等）会被忽略。
+func (c *Client) buildRequest(messages []schema.Message, cfg llm.ChatConfig, stream bool) (chatRequest, error) {
+	if len(messages) == 0 {
+		return chatRequest{}, fmt.Errorf("%s: messages required", c.provider)
+	}
+	if strings.TrimSpace(cfg.Model) == "" {
+		return chatRequest{}, fmt.Errorf("%s: model required (use llm.WithModel)", c.provider)
+	}
+
+	msgs, err := toWireMessages(c.provider, messages)
+	if err != nil {
+		return chatRequest{}, err
+	}
+
+	req := chatRequest{
+		provider: c.provider,
+		Model:    cfg.Model,
+		Messages: msgs,
+		Stream:   stream,
+	}
+
+	if len(cfg.Tools) > 0 {
+		tools, err := toWireTools(c.provider, cfg.Tools)
+		if err != nil {
+			return chatRequest{}, err
+		}
+		req.Tools = tools
+	}
+
+	if cfg.ResponseFormat != nil {
+		format, err := toWireFormat(*cfg.ResponseFormat)
+		if err != nil {
+			return chatRequest{}, fmt.Errorf("%s: invalid response_format.json_schema JSON: %w", c.provider, err)
+		}
+		req.Format = format
+	}
+
+	opts := wireOptions{}
+	if cfg.Temperature != nil {
+		opts["temperature"] = *cfg.Temperature
+	}
+	if cfg.TopP != nil {
+		opts["top_p"] = *cfg.TopP
+	}
+	if cfg.MaxCompletionTokens != nil {
+		opts["num_predict"] = *cfg.MaxCompletionTokens
+	} else if cfg.MaxTokens != nil {
+		opts["num_predict"] = *cfg.MaxTokens
+	}
+	if cfg.Stop != nil {
+		opts["stop"] = *cfg.Stop
+	}
+	if cfg.Seed != nil {
+		opts["seed"] = *cfg.Seed
+	}
+	if cfg.PresencePenalty != nil {
+		opts["presence_penalty"] = *cfg.PresencePenalty
+	}
+	if cfg.FrequencyPenalty != nil {
+		opts["frequency_penalty"] = *cfg.FrequencyPenalty
+	}
+	if len(opts) > 0 {
+		req.Options = opts
+	}
+
+	req.extra = cfg.ExtraFields
+	req.allowExtraFieldOverride = cfg.AllowExtraFieldOverride
+
+	return req, nil
 }
@@ -0,0 +1,318 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func newTestClient(t *testing.T, status int, contentType, body string, inspect func(*http.Request, map[string]any)) *Client {
+	t.Helper()
+
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			var got map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+			if inspect != nil {
+				inspect(r, got)
+			}
+			h := make(http.Header)
+			h.Set("Content-Type", contentType)
+			return &http.Response{
+				StatusCode: status,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header:     h,
+				Request:    r,
+			}, nil
+		}),
+	}
+
+	c, err := New(Config{
+		BaseConfig: BaseConfig{
+			// 旧配置中的 /v1 后缀会被去掉
+			BaseURL:    "http://localhost:11434/v1",
+			HTTPClient: httpClient,
+		},
+		DefaultOptions: []llm.ChatOption{llm.WithModel("qwen3")},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+// TestChat_RequestMapping 测试 options 合并、Ollama 特有字段与工具消息的映射
+func TestChat_RequestMapping(t *testing.T) {
+	t.Parallel()
+
+	var gotURL string
+	var gotReq map[string]any
+	c := newTestClient(t, http.StatusOK, "application/json",
+		`{"model":"qwen3","message":{"role":"assistant","content":"ok"},"done":true,"done_reason":"stop"}`,
+		func(r *http.Request, req map[string]any) {
+			gotURL = r.URL.String()
+			gotReq = req
+		})
+
+	messages := []schema.Message{
+		schema.SystemMessage("You are terse."),
+		{
+			Role: schema.RoleUser,
+			Content: []schema.ContentPart{
+				schema.TextPart("What is this?"),
+				schema.BinaryPart("image/png", []byte{0x89, 0x50}),
+			},
+		},
+		{
+			Role: schema.RoleAssistant,
+			ToolCalls: []schema.ToolCall{{
+				ID:       "ollama-call-0",
+				Type:     schema.ToolCallTypeFunction,
+				Function: schema.ToolFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+			}},
+		},
+		schema.ToolResultMessage("ollama-call-0", "sunny"),
+	}
+
+	_, err := c.Chat(context.Background(), messages,
+		llm.WithTemperature(0.2),
+		llm.WithMaxTokens(64),
+		WithOptions(map[string]any{"num_ctx": 8192}),
+		WithKeepAlive("30m"),
+		WithThink(true),
+		WithFormat(map[string]any{"type": "object"}),
+	)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if gotURL != "http://localhost:11434/api/chat" {
+		t.Errorf("url = %q", gotURL)
+	}
+	if gotReq["stream"] != false || gotReq["keep_alive"] != "30m" || gotReq["think"] != true {
+		t.Errorf("request = %#v", gotReq)
+	}
+	if f := gotReq["format"].(map[string]any); f["type"] != "object" {
+		t.Errorf("format = %#v", f)
+	}
+	opts := gotReq["options"].(map[string]any)
+	if opts["temperature"] != 0.2 || opts["num_predict"] != float64(64) || opts["num_ctx"] != float64(8192) {
+		t.Errorf("options = %#v", opts)
+	}
+
+	msgs := gotReq["messages"].([]any)
+	user := msgs[1].(map[string]any)
+	if user["content"] != "What is this?" || user["images"].([]any)[0] != "iVA=" {
+		t.Errorf("user message = %#v", user)
+	}
+	tc := msgs[2].(map[string]any)["tool_calls"].([]any)[0].(map[string]any)
+	if _, ok := tc["id"]; ok {
+		t.Errorf("synthetic call id should not be sent: %#v", tc)
+	}
+	if fn := tc["function"].(map[string]any); fn["name"] != "get_weather" || fn["arguments"].(map[string]any)["city"] != "Paris" {
+		t.Errorf("tool call = %#v", tc)
+	}
+	tool := msgs[3].(map[string]any)
+	if tool["role"] != "tool" || tool["tool_name"] != "get_weather" || tool["content"] != "sunny" {
+		t.Errorf("tool message = %#v", tool)
+	}
+}
+
+// TestChat_OptionsConflict 测试 WithOptions 与标准选项冲突
+func TestChat_OptionsConflict(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, http.StatusOK, "application/json", `{}`, nil)
+
+	_, err := c.Chat(context.Background(), []schema.Message{schema.UserMessage("Hi")},
+		llm.WithTemperature(0.2),
+		WithOptions(map[string]any{"temperature": 0.9}),
+	)
+	if err == nil || !strings.Contains(err.Error(), `"options.temperature"`) {
+		t.Fatalf("Chat() error = %v, want options.temperature conflict", err)
+	}
+}
+
+// TestChat_ResponseMapping 测试思考内容、工具调用与生成统计的映射
+func TestChat_ResponseMapping(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, http.StatusOK, "application/json", `{
+  "model":"qwen3","created_at":"2025-01-02T03:04:05.000000Z",
+  "message":{"role":"assistant","content":"","thinking":"Need weather.",
+    "tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Paris"}}}]},
+  "done":true,"done_reason":"stop",
+  "total_duration":5000000000,"load_duration":1000000,"prompt_eval_count":26,
+  "prompt_eval_duration":130000000,"eval_count":12,"eval_duration":260000000
+}`, nil)
+
+	resp, err := c.Chat(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	msg := resp.Choices[0].Message
+	if msg.ReasoningContent != "Need weather." {
+		t.Errorf("ReasoningContent = %q", msg.ReasoningContent)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].ID != "ollama-call-0" || msg.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("ToolCalls = %#v", msg.ToolCalls)
+	}
+	if resp.Choices[0].FinishReason != schema.FinishReasonToolCalls {
+		t.Errorf("FinishReason = %q", resp.Choices[0].FinishReason)
+	}
+	if resp.Usage.PromptTokens != 26 || resp.Usage.CompletionTokens != 12 || resp.Usage.TotalTokens != 38 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+	if resp.ExtraFields[ExtraTotalDuration] != 5*time.Second || resp.ExtraFields[ExtraEvalCount] != 12 {
+		t.Errorf("ExtraFields = %#v", resp.ExtraFields)
+	}
+	if resp.CreatedAt.IsZero() {
+		t.Error("CreatedAt should be parsed")
+	}
+}
+
+// TestChatStream_NDJSON 测试 NDJSON 流式响应
+func TestChatStream_NDJSON(t *testing.T) {
+	t.Parallel()
+
+	body := `{"model":"qwen3","message":{"role":"assistant","content":"","thinking":"Hmm."},"done":false}
+{"model":"qwen3","message":{"role":"assistant","content":"Hel"},"done":false}
+
+{"model":"qwen3","message":{"role":"assistant","content":"lo"},"done":false}
+{"model":"qwen3","message":{"role":"assistant","content":""},"done":true,"done_reason":"length","prompt_eval_count":4,"eval_count":2,"eval_duration":1000}
+`
+	c := newTestClient(t, http.StatusOK, "application/x-ndjson", body, func(r *http.Request, req map[string]any) {
+		if req["stream"] != true {
+			t.Errorf("stream = %v, want true", req["stream"])
+		}
+	})
+
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var text, reasoning string
+	var finish *schema.FinishReason
+	var usage *schema.Usage
+	var extra map[string]any
+	var model string
+	var dones int
+	for {
+		ev, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		text += ev.Delta
+		reasoning += ev.Reasoning
+		if ev.Type == schema.StreamEventDone {
+			dones++
+			if ev.FinishReason != nil {
+				finish, usage, extra, model = ev.FinishReason, ev.Usage, ev.ExtraFields, ev.Model
+			}
+		}
+	}
+
+	if text != "Hello" || reasoning != "Hmm." {
+		t.Errorf("text = %q, reasoning = %q", text, reasoning)
+	}
+	if finish == nil || *finish != schema.FinishReasonLength {
+		t.Errorf("FinishReason = %v", finish)
+	}
+	if usage == nil || usage.TotalTokens != 6 {
+		t.Errorf("Usage = %+v", usage)
+	}
+	if model != "qwen3" {
+		t.Errorf("Model = %q", model)
+	}
+	if extra[ExtraEvalDuration] != time.Microsecond {
+		t.Errorf("ExtraFields = %#v", extra)
+	}
+	if dones != 2 {
+		t.Errorf("done events = %d, want 2", dones)
+	}
+}
+
+// TestChatStream_ErrorLine 测试流中的 {"error":"..."} 转换为 *llm.APIError
+func TestChatStream_ErrorLine(t *testing.T) {
+	t.Parallel()
+
+	body := `{"model":"qwen3","message":{"role":"assistant","content":"Hi"},"done":false}
+{"error":"an error was encountered while running the model"}
+`
+	c := newTestClient(t, http.StatusOK, "application/x-ndjson", body, nil)
+
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	if ev, err := stream.Recv(); err != nil || ev.Delta != "Hi" {
+		t.Fatalf("Recv() = %+v, %v", ev, err)
+	}
+	_, err = stream.Recv()
+	ae, ok := llm.AsAPIError(err)
+	if !ok {
+		t.Fatalf("Recv() error = %v, want *llm.APIError", err)
+	}
+	if ae.Provider != llm.ProviderOllama || ae.Message != "an error was encountered while running the model" {
+		t.Errorf("APIError = %+v", ae)
+	}
+}
+
+// TestChatStream_Truncated 测试未收到 done 分片即结束的流返回 io.ErrUnexpectedEOF，而不是正常结束
+func TestChatStream_Truncated(t *testing.T) {
+	t.Parallel()
+
+	body := `{"model":"qwen3","message":{"role":"assistant","content":"Hel"},"done":false}
+`
+	c := newTestClient(t, http.StatusOK, "application/x-ndjson", body, nil)
+
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	resp, err := llm.Accumulate(stream)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Accumulate() error = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if resp.Choices[0].Message.Text() != "Hel" {
+		t.Errorf("partial text = %q", resp.Choices[0].Message.Text())
+	}
+}
+
+// TestChat_APIErrorResponse 测试 {"error":"..."} 字符串错误响应
+func TestChat_APIErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, http.StatusNotFound, "application/json", `{"error":"model \"qwen3\" not found, try pulling it first"}`, nil)
+
+	_, err := c.Chat(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	ae, ok := llm.AsAPIError(err)
+	if !ok {
+		t.Fatalf("Chat() error = %v, want *llm.APIError", err)
+	}
+	if ae.StatusCode != http.StatusNotFound || ae.Message != `model "qwen3" not found, try pulling it first` {
+		t.Errorf("APIError = %+v", ae)
+	}
+}
@@ -0,0 +1,237 @@
+package chat
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// syntheticCallIDPrefix 标记本地生成的工具调用 ID（Ollama 未返回 id 时），这类 ID 不会回传给 API
+const syntheticCallIDPrefix = "ollama-call-"
+
+// ExtraFields 中的生成统计键，耗时为 time.Duration，计数为 int
+const (
+	ExtraTotalDuration      = "total_duration"
+	ExtraLoadDuration       = "load_duration"
+	ExtraPromptEvalCount    = "prompt_eval_count"
+	ExtraPromptEvalDuration = "prompt_eval_duration"
+	ExtraEvalCount          = "eval_count"
+	ExtraEvalDuration       = "eval_duration"
+)
+
+// toWireMessages 转换消息。
+//
+// RoleTool 消息的 tool_name 取 Message.Name，为空时根据 ToolCallID 从前序 assistant 消息中查找。
+func toWireMessages(provider string, messages []schema.Message) ([]wireMessage, error) {
+	out := make([]wireMessage, 0, len(messages))
+	callNames := make(map[string]string)
+
+	for _, m := range messages {
+		wm := wireMessage{Role: string(m.Role)}
+
+		switch m.Role {
+		case schema.RoleSystem, schema.RoleUser, schema.RoleAssistant:
+		case schema.RoleTool:
+			wm.ToolName = m.Name
+			if wm.ToolName == "" {
+				wm.ToolName = callNames[m.ToolCallID]
+			}
+		default:
+			return nil, fmt.Errorf("%s: unsupported message role %q", provider, m.Role)
+		}
+
+		var text strings.Builder
+		for _, p := range m.Content {
+			switch part := p.(type) {
+			case schema.TextContent:
+				text.WriteString(part.Text)
+			case schema.ImageURLContent:
+				img, err := imageURLData(provider, part.URL)
+				if err != nil {
+					return nil, err
+				}
+				wm.Images = append(wm.Images, img)
+			case schema.BinaryContent:
+				if len(part.Data) == 0 {
+					return nil, fmt.Errorf("%s: binary data required", provider)
+				}
+				if !strings.HasPrefix(part.MIMEType, "image/") {
+					return nil, fmt.Errorf("%s: unsupported binary mime type %q (only images are supported)", provider, part.MIMEType)
+				}
+				wm.Images = append(wm.Images, base64.StdEncoding.EncodeToString(part.Data))
+			default:
+				return nil, fmt.Errorf("%s: unsupported message.content part type %T", provider, p)
+			}
+		}
+		wm.Content = text.String()
+
+		if m.Role == schema.RoleAssistant {
+			wm.Thinking = m.ReasoningContent
+			for _, tc := range m.ToolCalls {
+				callNames[tc.ID] = tc.Function.Name
+				args := json.RawMessage(strings.TrimSpace(tc.Function.Arguments))
+				if len(args) == 0 {
+					args = json.RawMessage("{}")
+				}
+				if !json.Valid(args) {
+					return nil, fmt.Errorf("%s: invalid tool call arguments JSON for %q", provider, tc.Function.Name)
+				}
+				id := tc.ID
+				if strings.HasPrefix(id, syntheticCallIDPrefix) {
+					id = ""
+				}
+				wm.ToolCalls = append(wm.ToolCalls, wireToolCall{
+					ID:       id,
+					Function: wireToolFunction{Name: tc.Function.Name, Arguments: args},
+				})
+			}
+		}
+
+		out = append(out, wm)
+	}
+	return out, nil
+}
+
+// imageURLData Ollama 只接受 base64 图片，仅支持 data URL
+func imageURLData(provider, u string) (string, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(u), "data:")
+	if !ok {
+		return "", fmt.Errorf("%s: only data URLs are supported for images", provider)
+	}
+	meta, data, ok := strings.Cut(rest, ",")
+	if !ok || !strings.HasSuffix(meta, ";base64") {
+		return "", fmt.Errorf("%s: unsupported data url, want data:<mime>;base64,<data>", provider)
+	}
+	return data, nil
+}
+
+func toWireTools(provider string, tools []schema.Tool) ([]wireTool, error) {
+	out := make([]wireTool, 0, len(tools))
+	for _, t := range tools {
+		if t.Type != schema.ToolTypeFunction {
+			continue
+		}
+		if len(t.Function.Parameters) > 0 && !json.Valid(t.Function.Parameters) {
+			return nil, fmt.Errorf("%s: invalid tool parameters JSON for %q", provider, t.Function.Name)
+		}
+		out = append(out, wireTool{
+			Type: string(schema.ToolTypeFunction),
+			Function: wireFunctionDefinition{
+				Name:        t.Function.Name,
+				Description: t.Function.Description,
+				Parameters:  t.Function.Parameters,
+			},
+		})
+	}
+	return out, nil
+}
+
+// toWireFormat 将 response_format 映射为 format："json" 或 JSON Schema
+func toWireFormat(rf schema.ResponseFormat) (any, error) {
+	switch rf.Type {
+	case "json_object":
+		return "json", nil
+	case "json_schema":
+		if len(rf.JSONSchema) == 0 {
+			return "json", nil
+		}
+		var wrapper struct {
+			Schema json.RawMessage `json:"schema"`
+		}
+		if err := json.Unmarshal(rf.JSONSchema, &wrapper); err != nil {
+			return nil, err
+		}
+		if len(wrapper.Schema) > 0 {
+			return wrapper.Schema, nil
+		}
+		return rf.JSONSchema, nil
+	default:
+		return nil, nil
+	}
+}
+
+func toSchemaToolCalls(calls []wireToolCall, callSeq *int) []schema.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	out := make([]schema.ToolCall, 0, len(calls))
+	for _, tc := range calls {
+		id := tc.ID
+		if id == "" {
+			id = syntheticCallIDPrefix + strconv.Itoa(*callSeq)
+		}
+		*callSeq++
+		args := string(tc.Function.Arguments)
+		if args == "" || args == "null" {
+			args = "{}"
+		}
+		out = append(out, schema.ToolCall{
+			ID:       id,
+			Type:     schema.ToolCallTypeFunction,
+			Function: schema.ToolFunction{Name: tc.Function.Name, Arguments: args},
+		})
+	}
+	return out
+}
+
+func toSchemaFinishReason(reason string, hasToolCalls bool) schema.FinishReason {
+	if hasToolCalls {
+		return schema.FinishReasonToolCalls
+	}
+	switch reason {
+	case "", "stop":
+		return schema.FinishReasonStop
+	case "length":
+		return schema.FinishReasonLength
+	default:
+		return schema.FinishReason(reason)
+	}
+}
+
+func toSchemaUsage(m wireMetrics) schema.Usage {
+	return schema.Usage{
+		PromptTokens:     m.PromptEvalCount,
+		CompletionTokens: m.EvalCount,
+		TotalTokens:      m.PromptEvalCount + m.EvalCount,
+	}
+}
+
+func toExtraFields(m wireMetrics) map[string]any {
+	return map[string]any{
+		ExtraTotalDuration:      time.Duration(m.TotalDuration),
+		ExtraLoadDuration:       time.Duration(m.LoadDuration),
+		ExtraPromptEvalCount:    m.PromptEvalCount,
+		ExtraPromptEvalDuration: time.Duration(m.PromptEvalDuration),
+		ExtraEvalCount:          m.EvalCount,
+		ExtraEvalDuration:       time.Duration(m.EvalDuration),
+	}
+}
+
+func toSchemaChatResponse(in chatResponse) schema.ChatResponse {
+	var callSeq int
+	msg := schema.Message{
+		Role:             schema.RoleAssistant,
+		ReasoningContent: in.Message.Thinking,
+		ToolCalls:        toSchemaToolCalls(in.Message.ToolCalls, &callSeq),
+	}
+	if in.Message.Content != "" {
+		msg.Content = []schema.ContentPart{schema.TextContent{Text: in.Message.Content}}
+	}
+
+	return schema.ChatResponse{
+		Model:     in.Model,
+		CreatedAt: in.CreatedAt,
+		Choices: []schema.Choice{{
+			Index:        0,
+			Message:      msg,
+			FinishReason: toSchemaFinishReason(in.DoneReason, len(msg.ToolCalls) > 0),
+		}},
+		Usage:       toSchemaUsage(in.wireMetrics),
+		ExtraFields: toExtraFields(in.wireMetrics),
+	}
+}
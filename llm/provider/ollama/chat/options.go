@@ -25,7 +25,7 @@ func WithKeepAlive(duration string) llm.ChatOption {
 }
 
 // WithOptions 设置 Ollama 模型运行选项
-// 这些选项直接传递给 Ollama 的 options 参数
+// 这些选项与标准选项（WithTemperature、WithMaxTokens 等）映射出的 options 合并，同名键需 llm.WithAllowExtraFieldOverride(true)
 // 常用选项包括: temperature, top_k, top_p, num_ctx, num_predict, repeat_penalty, stop 等
 func WithOptions(options map[string]any) llm.ChatOption {
 	return llm.WithExtraField(extOptions, options)
@@ -0,0 +1,108 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// chatRequest /api/chat 请求体
+type chatRequest struct {
+	provider string
+
+	Model    string        `json:"model"`
+	Messages []wireMessage `json:"messages"`
+	Tools    []wireTool    `json:"tools,omitempty"`
+	Format   any           `json:"format,omitempty"`
+	Options  wireOptions   `json:"options,omitempty"`
+	Stream   bool          `json:"stream"`
+
+	extra                   map[string]any
+	allowExtraFieldOverride bool
+}
+
+// MarshalJSON 合并扩展字段：extOptions（map）合并进 options，其余键写入顶层
+func (r chatRequest) MarshalJSON() ([]byte, error) {
+	type alias chatRequest
+	base, err := json.Marshal(alias(r))
+	if err != nil {
+		return nil, err
+	}
+	if len(r.extra) == 0 {
+		return base, nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(base, &obj); err != nil {
+		return nil, err
+	}
+
+	for k, v := range r.extra {
+		if k == extOptions {
+			if m, ok := v.(map[string]any); ok {
+				opts := make(map[string]any, len(r.Options)+len(m))
+				for key, ov := range r.Options {
+					opts[key] = ov
+				}
+				for key, ov := range m {
+					if _, exists := opts[key]; exists && !r.allowExtraFieldOverride {
+						return nil, fmt.Errorf("%s: extra field %q conflicts with a built-in option (set llm.WithAllowExtraFieldOverride(true) to override)", r.provider, extOptions+"."+key)
+					}
+					opts[key] = ov
+				}
+				b, err := json.Marshal(opts)
+				if err != nil {
+					return nil, err
+				}
+				obj[extOptions] = b
+				continue
+			}
+		}
+
+		if !r.allowExtraFieldOverride {
+			if _, exists := obj[k]; exists {
+				return nil, fmt.Errorf("%s: extra field %q conflicts with a built-in option (set llm.WithAllowExtraFieldOverride(true) to override)", r.provider, k)
+			}
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		obj[k] = b
+	}
+
+	return json.Marshal(obj)
+}
+
+// wireOptions 模型运行参数（options），由标准选项映射而来
+type wireOptions map[string]any
+
+type wireMessage struct {
+	Role      string         `json:"role"`
+	Content   string         `json:"content"`
+	Thinking  string         `json:"thinking,omitempty"`
+	Images    []string       `json:"images,omitempty"`
+	ToolCalls []wireToolCall `json:"tool_calls,omitempty"`
+	ToolName  string         `json:"tool_name,omitempty"`
+}
+
+type wireToolCall struct {
+	ID       string           `json:"id,omitempty"`
+	Function wireToolFunction `json:"function"`
+}
+
+type wireToolFunction struct {
+	Index     *int            `json:"index,omitempty"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type wireTool struct {
+	Type     string                 `json:"type"`
+	Function wireFunctionDefinition `json:"function"`
+}
+
+type wireFunctionDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
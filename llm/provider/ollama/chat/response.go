@@ -0,0 +1,27 @@
+package chat
+
+import "time"
+
+// chatResponse /api/chat 的响应，非流式为完整响应，流式为每行一个分片
+type chatResponse struct {
+	Model      string      `json:"model"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Message    wireMessage `json:"message"`
+	Done       bool        `json:"done"`
+	DoneReason string      `json:"done_reason,omitempty"`
+
+	wireMetrics
+
+	// Error 流式过程中出错时返回 {"error":"..."}
+	Error string `json:"error,omitempty"`
+}
+
+// wireMetrics 生成统计，耗时单位为纳秒
+type wireMetrics struct {
+	TotalDuration      int64 `json:"total_duration,omitempty"`
+	LoadDuration       int64 `json:"load_duration,omitempty"`
+	PromptEvalCount    int   `json:"prompt_eval_count,omitempty"`
+	PromptEvalDuration int64 `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int   `json:"eval_count,omitempty"`
+	EvalDuration       int64 `json:"eval_duration,omitempty"`
+}
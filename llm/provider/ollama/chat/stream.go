@@ -0,0 +1,138 @@
+package chat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// stream 解析 /api/chat 的 NDJSON 流式响应。
+//
+// 每行一个 chatResponse 分片；done 为 true 的分片携带结束原因与生成统计，
+// 随后补发一个最终的 StreamEventDone。流中的 {"error":"..."} 以 *llm.APIError 返回，
+// 未收到 done 分片即结束时返回 io.ErrUnexpectedEOF。
+type stream struct {
+	body io.ReadCloser
+	r    *bufio.Reader
+
+	provider string
+	keepRaw  bool
+	hooks    []llm.StreamEventHook
+
+	callSeq int
+
+	pending  []schema.StreamEvent
+	finished bool
+	closed   atomic.Bool
+}
+
+func newStream(provider string, body io.ReadCloser, keepRaw bool, hooks []llm.StreamEventHook) *stream {
+	return &stream{
+		body:     body,
+		r:        bufio.NewReader(body),
+		provider: provider,
+		keepRaw:  keepRaw,
+		hooks:    hooks,
+	}
+}
+
+func (s *stream) Recv() (schema.StreamEvent, error) {
+	for {
+		if len(s.pending) > 0 {
+			ev := s.pending[0]
+			s.pending = s.pending[1:]
+			return ev, nil
+		}
+		if s.finished || s.closed.Load() {
+			return schema.StreamEvent{}, io.EOF
+		}
+
+		line, err := s.r.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			if errors.Is(err, io.EOF) {
+				// 未收到 done 分片即结束，响应不完整（如连接被中断、代理超时）
+				return schema.StreamEvent{}, io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				return schema.StreamEvent{}, err
+			}
+			continue
+		}
+
+		var raw json.RawMessage
+		if s.keepRaw || len(s.hooks) > 0 {
+			raw = json.RawMessage(slices.Clone(line))
+		}
+
+		var chunk chatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return schema.StreamEvent{}, err
+		}
+		if strings.TrimSpace(chunk.Error) != "" {
+			return schema.StreamEvent{}, &llm.APIError{
+				Provider: llm.Provider(s.provider),
+				Message:  strings.TrimSpace(chunk.Error),
+				Raw:      slices.Clone(line),
+			}
+		}
+
+		var mapped []schema.StreamEvent
+		calls := toSchemaToolCalls(chunk.Message.ToolCalls, &s.callSeq)
+		if chunk.Message.Content != "" || chunk.Message.Thinking != "" || len(calls) > 0 {
+			mapped = append(mapped, schema.StreamEvent{
+				Type:      schema.StreamEventDelta,
+				Delta:     chunk.Message.Content,
+				Reasoning: chunk.Message.Thinking,
+				ToolCalls: calls,
+			})
+		}
+		if chunk.Done {
+			fr := toSchemaFinishReason(chunk.DoneReason, s.callSeq > 0)
+			usage := toSchemaUsage(chunk.wireMetrics)
+			mapped = append(mapped, schema.StreamEvent{
+				Type:         schema.StreamEventDone,
+				FinishReason: &fr,
+				Usage:        &usage,
+				Model:        chunk.Model,
+				ExtraFields:  toExtraFields(chunk.wireMetrics),
+			})
+		}
+
+		for i := range mapped {
+			if s.keepRaw {
+				mapped[i].Raw = raw
+			}
+			for _, h := range s.hooks {
+				if h == nil {
+					continue
+				}
+				if err := h(&mapped[i], raw); err != nil {
+					return schema.StreamEvent{}, err
+				}
+			}
+		}
+
+		if chunk.Done {
+			s.finished = true
+			mapped = append(mapped, schema.StreamEvent{Type: schema.StreamEventDone})
+		}
+		s.pending = mapped
+	}
+}
+
+// Close 可与 Recv 并发调用，关闭 body 以中断阻塞中的读取
+func (s *stream) Close() error {
+	if !s.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	return s.body.Close()
+}
@@ -2,15 +2,33 @@ package embeddings
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/lgc202/go-kit/llm"
-	openaiCompatEmbeddings "github.com/lgc202/go-kit/llm/internal/openai_compat/embeddings"
+	"github.com/lgc202/go-kit/llm/internal/openai_compat/transport"
 	"github.com/lgc202/go-kit/llm/provider/base"
 	"github.com/lgc202/go-kit/llm/schema"
 )
 
-const DefaultBaseURL = "http://localhost:11434/v1"
+// DefaultBaseURL Ollama 原生 API 地址（通常为本地地址）
+const DefaultBaseURL = "http://localhost:11434"
+
+// DefaultPath Ollama 原生 embeddings 端点路径
+const DefaultPath = "/api/embed"
+
+const httpAcceptJSON = "application/json"
+
+// ExtraFields 中的生成统计键，耗时为 time.Duration，计数为 int
+const (
+	ExtraTotalDuration   = "total_duration"
+	ExtraLoadDuration    = "load_duration"
+	ExtraPromptEvalCount = "prompt_eval_count"
+)
 
 var _ llm.Embedder = (*Client)(nil)
 var _ llm.ProviderNamer = (*Client)(nil)
@@ -24,34 +42,149 @@ type Config struct {
 	DefaultOptions []llm.EmbeddingOption
 }
 
+// Client Ollama /api/embed 原生客户端
 type Client struct {
-	inner *openaiCompatEmbeddings.Client
+	provider string
+
+	t *transport.Client
+
+	defaultOpts []llm.EmbeddingOption
 }
 
 func New(cfg Config) (*Client, error) {
-	baseURL := strings.TrimSpace(cfg.BaseURL)
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
 	if baseURL == "" {
 		baseURL = DefaultBaseURL
 	}
+	// 兼容旧配置中 OpenAI 兼容端点的 "/v1" 后缀
+	baseURL = strings.TrimSuffix(baseURL, "/v1")
 
-	inner, err := openaiCompatEmbeddings.New(openaiCompatEmbeddings.Config{
+	t, err := transport.New(transport.Config{
 		Provider:       llm.ProviderOllama,
 		BaseURL:        baseURL,
-		Path:           "/embeddings",
+		Path:           DefaultPath,
 		APIKey:         cfg.APIKey,
 		HTTPClient:     cfg.HTTPClient,
+		HTTPX:          cfg.HTTPX,
+		HTTPXOptions:   cfg.HTTPXOptions,
 		DefaultHeaders: cfg.DefaultHeaders,
-		DefaultOptions: cfg.DefaultOptions,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{inner: inner}, nil
+	return &Client{
+		provider:    t.Provider(),
+		t:           t,
+		defaultOpts: slices.Clone(cfg.DefaultOptions),
+	}, nil
 }
 
 func (*Client) Provider() llm.Provider { return llm.ProviderOllama }
 
 func (c *Client) Embed(ctx context.Context, inputs []string, opts ...llm.EmbeddingOption) (schema.EmbeddingResponse, error) {
-	return c.inner.Embed(ctx, inputs, opts...)
+	reqCfg := llm.ApplyEmbeddingOptions(slices.Concat(c.defaultOpts, opts)...)
+
+	if len(inputs) == 0 {
+		return schema.EmbeddingResponse{}, fmt.Errorf("%s: inputs required", c.provider)
+	}
+	if strings.TrimSpace(reqCfg.Model) == "" {
+		return schema.EmbeddingResponse{}, fmt.Errorf("%s: model required (use llm.WithModel)", c.provider)
+	}
+
+	req := embedRequest{
+		provider:                c.provider,
+		Model:                   reqCfg.Model,
+		Input:                   slices.Clone(inputs),
+		extra:                   reqCfg.ExtraFields,
+		allowExtraFieldOverride: reqCfg.AllowExtraFieldOverride,
+	}
+
+	resp, err := c.t.PostJSON(ctx, req, transport.RequestConfig{
+		Timeout:    reqCfg.Timeout,
+		Headers:    reqCfg.Headers,
+		ErrorHooks: reqCfg.ErrorHooks,
+	}, httpAcceptJSON)
+	if err != nil {
+		return schema.EmbeddingResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return schema.EmbeddingResponse{}, fmt.Errorf("%s: read response: %w", c.provider, err)
+	}
+
+	var in embedResponse
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return schema.EmbeddingResponse{}, fmt.Errorf("%s: decode response: %w", c.provider, err)
+	}
+
+	out := schema.EmbeddingResponse{
+		Model: in.Model,
+		Data:  make([]schema.Embedding, 0, len(in.Embeddings)),
+		Usage: schema.Usage{
+			PromptTokens: in.PromptEvalCount,
+			TotalTokens:  in.PromptEvalCount,
+		},
+		ExtraFields: map[string]any{
+			ExtraTotalDuration:   time.Duration(in.TotalDuration),
+			ExtraLoadDuration:    time.Duration(in.LoadDuration),
+			ExtraPromptEvalCount: in.PromptEvalCount,
+		},
+	}
+	for i, v := range in.Embeddings {
+		out.Data = append(out.Data, schema.Embedding{Index: i, Vector: v})
+	}
+	if reqCfg.KeepRaw {
+		out.Raw = json.RawMessage(raw)
+	}
+	return out, nil
+}
+
+type embedRequest struct {
+	provider string
+
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+
+	extra                   map[string]any
+	allowExtraFieldOverride bool
+}
+
+func (r embedRequest) MarshalJSON() ([]byte, error) {
+	type alias embedRequest
+	base, err := json.Marshal(alias(r))
+	if err != nil {
+		return nil, err
+	}
+	if len(r.extra) == 0 {
+		return base, nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(base, &obj); err != nil {
+		return nil, err
+	}
+	for k, v := range r.extra {
+		if !r.allowExtraFieldOverride {
+			if _, exists := obj[k]; exists {
+				return nil, fmt.Errorf("%s: extra field %q conflicts with a built-in option (set llm.WithAllowExtraFieldOverride(true) to override)", r.provider, k)
+			}
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		obj[k] = b
+	}
+	return json.Marshal(obj)
+}
+
+type embedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float64 `json:"embeddings"`
+	TotalDuration   int64       `json:"total_duration"`
+	LoadDuration    int64       `json:"load_duration"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
 }
@@ -0,0 +1,68 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestEmbed_NativeRequestAndResponse(t *testing.T) {
+	t.Parallel()
+
+	var gotPath string
+	var gotReq map[string]any
+
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			gotPath = r.URL.Path
+			if err := json.NewDecoder(r.Body).Decode(&gotReq); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+			body := `{"model":"all-minilm","embeddings":[[0.1,0.2],[0.3,0.4]],"total_duration":14143917,"load_duration":1019500,"prompt_eval_count":8}`
+			h := make(http.Header)
+			h.Set("Content-Type", "application/json")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header:     h,
+				Request:    r,
+			}, nil
+		}),
+	}
+
+	c, err := New(Config{
+		BaseConfig:     BaseConfig{HTTPClient: httpClient},
+		DefaultOptions: []llm.EmbeddingOption{llm.WithModel("all-minilm")},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	resp, err := c.Embed(context.Background(), []string{"a", "b"}, WithTruncate(false), WithKeepAlive("5m"))
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	if gotPath != "/api/embed" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if gotReq["truncate"] != false || gotReq["keep_alive"] != "5m" || len(gotReq["input"].([]any)) != 2 {
+		t.Errorf("request = %#v", gotReq)
+	}
+	if len(resp.Data) != 2 || resp.Data[1].Index != 1 || resp.Data[1].Vector[1] != 0.4 {
+		t.Errorf("Data = %+v", resp.Data)
+	}
+	if resp.Usage.PromptTokens != 8 || resp.ExtraFields[ExtraLoadDuration] != time.Duration(1019500) {
+		t.Errorf("Usage = %+v, ExtraFields = %#v", resp.Usage, resp.ExtraFields)
+	}
+}
@@ -0,0 +1,31 @@
+package embeddings
+
+import "github.com/lgc202/go-kit/llm"
+
+// 扩展字段键，用于 llm.WithExtraField()
+const (
+	extTruncate   = "truncate"
+	extDimensions = "dimensions"
+	extKeepAlive  = "keep_alive"
+	extOptions    = "options"
+)
+
+// WithTruncate 输入超出上下文长度时是否截断，false 时返回错误（Ollama 默认 true）
+func WithTruncate(enabled bool) llm.EmbeddingOption {
+	return llm.WithExtraField(extTruncate, enabled)
+}
+
+// WithDimensions 设置输出向量维度（仅部分模型支持）
+func WithDimensions(dims int) llm.EmbeddingOption {
+	return llm.WithExtraField(extDimensions, dims)
+}
+
+// WithKeepAlive 设置模型在内存中保持加载的时间，例如 "5m"、"24h"
+func WithKeepAlive(duration string) llm.EmbeddingOption {
+	return llm.WithExtraField(extKeepAlive, duration)
+}
+
+// WithOptions 设置 Ollama 模型运行选项（如 num_ctx）
+func WithOptions(options map[string]any) llm.EmbeddingOption {
+	return llm.WithExtraField(extOptions, options)
+}
@@ -0,0 +1,207 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/internal/openai_compat/transport"
+	"github.com/lgc202/go-kit/llm/provider/base"
+)
+
+// DefaultBaseURL Ollama 原生 API 地址（通常为本地地址）
+const DefaultBaseURL = "http://localhost:11434"
+
+const (
+	httpAcceptJSON   = "application/json"
+	httpAcceptNDJSON = "application/x-ndjson"
+)
+
+const pullStatusSuccess = "success"
+
+type BaseConfig = base.Config
+
+type Config struct {
+	BaseConfig
+}
+
+// ModelManager Ollama 模型管理客户端：列出、查看、拉取、删除本地模型及查看已加载模型
+type ModelManager struct {
+	provider string
+
+	t *transport.Client
+}
+
+func New(cfg Config) (*ModelManager, error) {
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	// 兼容旧配置中 OpenAI 兼容端点的 "/v1" 后缀
+	baseURL = strings.TrimSuffix(baseURL, "/v1")
+
+	t, err := transport.New(transport.Config{
+		Provider:       llm.ProviderOllama,
+		BaseURL:        baseURL,
+		APIKey:         cfg.APIKey,
+		HTTPClient:     cfg.HTTPClient,
+		HTTPX:          cfg.HTTPX,
+		HTTPXOptions:   cfg.HTTPXOptions,
+		DefaultHeaders: cfg.DefaultHeaders,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ModelManager{provider: t.Provider(), t: t}, nil
+}
+
+func (*ModelManager) Provider() llm.Provider { return llm.ProviderOllama }
+
+// List 列出本地模型（GET /api/tags）
+func (m *ModelManager) List(ctx context.Context) ([]Model, error) {
+	var out struct {
+		Models []Model `json:"models"`
+	}
+	if err := m.doJSON(ctx, http.MethodGet, "/api/tags", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Models, nil
+}
+
+// Running 列出当前已加载到内存的模型（GET /api/ps）
+func (m *ModelManager) Running(ctx context.Context) ([]RunningModel, error) {
+	var out struct {
+		Models []RunningModel `json:"models"`
+	}
+	if err := m.doJSON(ctx, http.MethodGet, "/api/ps", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Models, nil
+}
+
+// Show 查看模型详情（POST /api/show），模型不存在时返回 404 的 *llm.APIError
+func (m *ModelManager) Show(ctx context.Context, model string) (ModelInfo, error) {
+	if strings.TrimSpace(model) == "" {
+		return ModelInfo{}, fmt.Errorf("%s: model required", m.provider)
+	}
+	var out ModelInfo
+	if err := m.doJSON(ctx, http.MethodPost, "/api/show", map[string]any{"model": model}, &out); err != nil {
+		return ModelInfo{}, err
+	}
+	return out, nil
+}
+
+// Delete 删除本地模型（DELETE /api/delete）
+func (m *ModelManager) Delete(ctx context.Context, model string) error {
+	if strings.TrimSpace(model) == "" {
+		return fmt.Errorf("%s: model required", m.provider)
+	}
+	return m.doJSON(ctx, http.MethodDelete, "/api/delete", map[string]any{"model": model}, nil)
+}
+
+// Pull 拉取模型（POST /api/pull），每收到一个进度事件调用一次 progress（可为 nil），
+// 直到拉取成功或出错
+func (m *ModelManager) Pull(ctx context.Context, model string, progress func(PullProgress)) error {
+	for p, err := range m.PullProgress(ctx, model) {
+		if err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(p)
+		}
+	}
+	return nil
+}
+
+// PullProgress 以迭代器形式返回拉取进度；提前退出循环会中止读取并关闭连接。
+// 流中的 {"error":"..."} 以 *llm.APIError 返回，流在 "success" 之前结束视为错误。
+func (m *ModelManager) PullProgress(ctx context.Context, model string) iter.Seq2[PullProgress, error] {
+	return func(yield func(PullProgress, error) bool) {
+		if strings.TrimSpace(model) == "" {
+			yield(PullProgress{}, fmt.Errorf("%s: model required", m.provider))
+			return
+		}
+
+		resp, err := m.t.DoJSON(ctx, http.MethodPost, map[string]any{"model": model, "stream": true},
+			transport.RequestConfig{Path: "/api/pull"}, httpAcceptNDJSON)
+		if err != nil {
+			yield(PullProgress{}, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		r := bufio.NewReader(resp.Body)
+		for {
+			line, rerr := r.ReadBytes('\n')
+			line = bytes.TrimSpace(line)
+			if len(line) > 0 {
+				var ev struct {
+					PullProgress
+					Error string `json:"error"`
+				}
+				if err := json.Unmarshal(line, &ev); err != nil {
+					yield(PullProgress{}, fmt.Errorf("%s: decode pull progress: %w", m.provider, err))
+					return
+				}
+				if strings.TrimSpace(ev.Error) != "" {
+					yield(PullProgress{}, &llm.APIError{
+						Provider: llm.ProviderOllama,
+						Message:  strings.TrimSpace(ev.Error),
+						Raw:      slices.Clone(line),
+					})
+					return
+				}
+				if !yield(ev.PullProgress, nil) || ev.Done() {
+					return
+				}
+			}
+			if errors.Is(rerr, io.EOF) {
+				yield(PullProgress{}, fmt.Errorf("%s: pull %q: stream ended before success", m.provider, model))
+				return
+			}
+			if rerr != nil {
+				yield(PullProgress{}, fmt.Errorf("%s: read pull progress: %w", m.provider, rerr))
+				return
+			}
+		}
+	}
+}
+
+// Ensure 确保模型已存在于本地：Show 返回 404 时拉取模型，progress 可为 nil
+func (m *ModelManager) Ensure(ctx context.Context, model string, progress func(PullProgress)) error {
+	_, err := m.Show(ctx, model)
+	if err == nil {
+		return nil
+	}
+	if ae, ok := llm.AsAPIError(err); !ok || ae.StatusCode != http.StatusNotFound {
+		return err
+	}
+	return m.Pull(ctx, model, progress)
+}
+
+func (m *ModelManager) doJSON(ctx context.Context, method, path string, payload, out any) error {
+	resp, err := m.t.DoJSON(ctx, method, payload, transport.RequestConfig{Path: path}, httpAcceptJSON)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s: decode response: %w", m.provider, err)
+	}
+	return nil
+}
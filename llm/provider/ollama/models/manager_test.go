@@ -0,0 +1,188 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lgc202/go-kit/llm"
+)
+
+// newTestServer 模拟 Ollama 模型管理 API，installed 为本地已有的模型
+func newTestServer(t *testing.T, installed map[string]bool) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"models":[{"name":"qwen3:latest","model":"qwen3:latest","modified_at":"2025-05-04T17:37:44Z","size":5225388164,"digest":"abc","details":{"format":"gguf","family":"qwen3","parameter_size":"8.2B","quantization_level":"Q4_K_M"}}]}`)
+	})
+	mux.HandleFunc("GET /api/ps", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"models":[{"name":"qwen3:latest","model":"qwen3:latest","size":6654289920,"size_vram":6654289920,"digest":"abc","expires_at":"2025-05-04T18:00:00Z"}]}`)
+	})
+	mux.HandleFunc("POST /api/show", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Model string }
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !installed[req.Model] {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"error":"model '%s' not found"}`, req.Model)
+			return
+		}
+		fmt.Fprint(w, `{"template":"{{ .Prompt }}","details":{"family":"qwen3"},"capabilities":["completion","tools"]}`)
+	})
+	mux.HandleFunc("POST /api/pull", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model  string
+			Stream bool
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Model == "broken" {
+			fmt.Fprint(w, `{"status":"pulling manifest"}`+"\n"+`{"error":"pull model manifest: file does not exist"}`+"\n")
+			return
+		}
+		if !req.Stream {
+			t.Errorf("pull stream = false, want true")
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprint(w, `{"status":"pulling manifest"}
+{"status":"pulling sha256:aaa","digest":"sha256:aaa","total":100,"completed":40}
+{"status":"pulling sha256:aaa","digest":"sha256:aaa","total":100,"completed":100}
+{"status":"verifying sha256 digest"}
+{"status":"success"}
+`)
+		installed[req.Model] = true
+	})
+	mux.HandleFunc("DELETE /api/delete", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Model string }
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !installed[req.Model] {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"model not found"}`)
+			return
+		}
+		delete(installed, req.Model)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestManager(t *testing.T, srv *httptest.Server) *ModelManager {
+	t.Helper()
+	m, err := New(Config{BaseConfig: BaseConfig{BaseURL: srv.URL, HTTPClient: srv.Client()}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return m
+}
+
+func TestModelManager_ListRunningShowDelete(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t, map[string]bool{"qwen3": true})
+	m := newTestManager(t, srv)
+	ctx := context.Background()
+
+	list, err := m.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "qwen3:latest" || list[0].Details.QuantizationLevel != "Q4_K_M" {
+		t.Errorf("List() = %+v", list)
+	}
+
+	running, err := m.Running(ctx)
+	if err != nil {
+		t.Fatalf("Running() error = %v", err)
+	}
+	if len(running) != 1 || running[0].SizeVRAM != 6654289920 || running[0].ExpiresAt.IsZero() {
+		t.Errorf("Running() = %+v", running)
+	}
+
+	info, err := m.Show(ctx, "qwen3")
+	if err != nil {
+		t.Fatalf("Show() error = %v", err)
+	}
+	if info.Details.Family != "qwen3" || len(info.Capabilities) != 2 {
+		t.Errorf("Show() = %+v", info)
+	}
+
+	_, err = m.Show(ctx, "missing")
+	if ae, ok := llm.AsAPIError(err); !ok || ae.StatusCode != http.StatusNotFound || ae.Message != "model 'missing' not found" {
+		t.Errorf("Show(missing) error = %v", err)
+	}
+
+	if err := m.Delete(ctx, "qwen3"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := m.Delete(ctx, "qwen3"); err == nil {
+		t.Error("Delete() of a removed model should fail")
+	}
+}
+
+func TestModelManager_PullProgress(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t, map[string]bool{})
+	m := newTestManager(t, srv)
+
+	var events []PullProgress
+	for p, err := range m.PullProgress(context.Background(), "llama3.2") {
+		if err != nil {
+			t.Fatalf("PullProgress() error = %v", err)
+		}
+		events = append(events, p)
+	}
+
+	if len(events) != 5 || !events[4].Done() {
+		t.Fatalf("events = %+v", events)
+	}
+	if p := events[1]; p.Digest != "sha256:aaa" || p.Total != 100 || p.Completed != 40 || p.Percent() != 40 {
+		t.Errorf("events[1] = %+v", p)
+	}
+}
+
+func TestModelManager_PullStreamError(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t, map[string]bool{})
+	m := newTestManager(t, srv)
+
+	var statuses []string
+	err := m.Pull(context.Background(), "broken", func(p PullProgress) { statuses = append(statuses, p.Status) })
+	ae, ok := llm.AsAPIError(err)
+	if !ok || ae.Message != "pull model manifest: file does not exist" {
+		t.Fatalf("Pull() error = %v, want *llm.APIError", err)
+	}
+	if len(statuses) != 1 || statuses[0] != "pulling manifest" {
+		t.Errorf("statuses = %v", statuses)
+	}
+}
+
+func TestModelManager_Ensure(t *testing.T) {
+	t.Parallel()
+
+	installed := map[string]bool{}
+	srv := newTestServer(t, installed)
+	m := newTestManager(t, srv)
+	ctx := context.Background()
+
+	var pulls int
+	progress := func(p PullProgress) {
+		if p.Done() {
+			pulls++
+		}
+	}
+	if err := m.Ensure(ctx, "qwen3", progress); err != nil {
+		t.Fatalf("Ensure() error = %v", err)
+	}
+	if err := m.Ensure(ctx, "qwen3", progress); err != nil {
+		t.Fatalf("Ensure() second call error = %v", err)
+	}
+	if pulls != 1 {
+		t.Errorf("pulls = %d, want 1 (second Ensure should find the model)", pulls)
+	}
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// Model /api/tags 返回的本地模型
+type Model struct {
+	Name       string       `json:"name"`
+	Model      string       `json:"model"`
+	ModifiedAt time.Time    `json:"modified_at"`
+	Size       int64        `json:"size"`
+	Digest     string       `json:"digest"`
+	Details    ModelDetails `json:"details"`
+}
+
+// ModelDetails 模型格式、家族、参数规模与量化等级
+type ModelDetails struct {
+	ParentModel       string   `json:"parent_model,omitempty"`
+	Format            string   `json:"format,omitempty"`
+	Family            string   `json:"family,omitempty"`
+	Families          []string `json:"families,omitempty"`
+	ParameterSize     string   `json:"parameter_size,omitempty"`
+	QuantizationLevel string   `json:"quantization_level,omitempty"`
+}
+
+// ModelInfo /api/show 返回的模型详情
+type ModelInfo struct {
+	License      string         `json:"license,omitempty"`
+	Modelfile    string         `json:"modelfile,omitempty"`
+	Parameters   string         `json:"parameters,omitempty"`
+	Template     string         `json:"template,omitempty"`
+	System       string         `json:"system,omitempty"`
+	Details      ModelDetails   `json:"details"`
+	ModelInfo    map[string]any `json:"model_info,omitempty"`
+	Capabilities []string       `json:"capabilities,omitempty"`
+	ModifiedAt   time.Time      `json:"modified_at"`
+}
+
+// RunningModel /api/ps 返回的已加载模型
+type RunningModel struct {
+	Name      string       `json:"name"`
+	Model     string       `json:"model"`
+	Size      int64        `json:"size"`
+	SizeVRAM  int64        `json:"size_vram"`
+	Digest    string       `json:"digest"`
+	Details   ModelDetails `json:"details"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
+
+// PullProgress /api/pull 的进度事件
+//
+// 下载层时 Digest、Total、Completed 有值；Status 为 "success" 表示拉取完成。
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// Done 是否为最终的成功事件
+func (p PullProgress) Done() bool { return p.Status == pullStatusSuccess }
+
+// Percent 当前层的下载百分比（0-100），Total 未知时返回 0
+func (p PullProgress) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return float64(p.Completed) / float64(p.Total) * 100
+}
@@ -188,6 +188,8 @@ data: {"choices":[{"index":0,"delta":{"content":" World"}}]}
 
 data: {"choices":[{"index":0,"delta":{}}],"finish_reason":"stop"}
 
+data: {"model":"gpt-4o-mini-2024-07-18","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}
+
 data: [DONE]
 
 `
@@ -224,6 +226,7 @@ data: [DONE]
 	defer stream.Close()
 
 	var sb strings.Builder
+	var model string
 	for {
 		event, err := stream.Recv()
 		if err != nil {
@@ -236,6 +239,12 @@ data: [DONE]
 		if event.Type == schema.StreamEventDelta {
 			sb.WriteString(event.Delta)
 		}
+		if event.Usage != nil {
+			model = event.Model
+		}
+	}
+	if model != "gpt-4o-mini-2024-07-18" {
+		t.Errorf("Model = %q", model)
 	}
 
 	if got := sb.String(); got != "Hello World" {
@@ -243,6 +252,55 @@ data: [DONE]
 	}
 }
 
+// TestChatStream_EventsCancel 测试 Events 的 ctx 取消能中断阻塞在读取响应体上的 Recv
+func TestChatStream_EventsCancel(t *testing.T) {
+	t.Parallel()
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go func() {
+		_, _ = io.WriteString(pw, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+	}()
+
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			h := make(http.Header)
+			h.Set("Content-Type", "text/event-stream")
+			return &http.Response{StatusCode: http.StatusOK, Body: pr, Header: h, Request: r}, nil
+		}),
+	}
+	c, err := New(Config{
+		BaseConfig:     BaseConfig{BaseURL: "https://api.openai.com/v1", APIKey: "test-key", HTTPClient: httpClient},
+		DefaultOptions: []llm.ChatOption{llm.WithModel("gpt-4o-mini")},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// 请求使用不会取消的 ctx，只有 Events 的 ctx 被取消
+	stream, err := c.ChatStream(context.Background(), []schema.Message{schema.UserMessage("hi")})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		text string
+		last error
+	)
+	for ev, err := range llm.Events(ctx, stream) {
+		if err != nil {
+			last = err
+			break
+		}
+		text += ev.Delta
+		cancel()
+	}
+	if text != "Hi" || !errors.Is(last, context.Canceled) {
+		t.Errorf("text = %q, err = %v", text, last)
+	}
+}
+
 // TestChat_APIErrorResponse 测试 API 错误响应
 func TestChat_APIErrorResponse(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,71 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// TestOpenDSN_CustomName 测试通过 DSN 打开通用 OpenAI 兼容 provider 并使用自定义名称
+func TestOpenDSN_CustomName(t *testing.T) {
+	t.Parallel()
+
+	var gotPath, gotAuth, gotModel string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		var req map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		gotModel, _ = req["model"].(string)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"1","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	model, err := llm.OpenDSN("openai-compatible+http://:sk-local@" + host + "/v1?model=qwen2.5-7b&name=vllm")
+	if err != nil {
+		t.Fatalf("OpenDSN() error = %v", err)
+	}
+	if got := llm.ProviderOf(model); got != "vllm" {
+		t.Errorf("ProviderOf() = %q, want vllm", got)
+	}
+
+	resp, err := model.Chat(context.Background(), []schema.Message{schema.UserMessage("Hi")})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.Choices[0].Message.Text() != "ok" {
+		t.Errorf("Text() = %q", resp.Choices[0].Message.Text())
+	}
+	if gotPath != "/v1/chat/completions" || gotAuth != "Bearer sk-local" || gotModel != "qwen2.5-7b" {
+		t.Errorf("path = %q, auth = %q, model = %q", gotPath, gotAuth, gotModel)
+	}
+}
+
+// TestOpen_Preset 测试预设服务的默认地址与必填 BaseURL 校验
+func TestOpen_Preset(t *testing.T) {
+	t.Parallel()
+
+	m, err := llm.Open("openrouter", llm.Config{APIKey: "k", Model: "openai/gpt-4o"})
+	if err != nil {
+		t.Fatalf("Open(openrouter) error = %v", err)
+	}
+	if llm.ProviderOf(m) != "openrouter" {
+		t.Errorf("ProviderOf() = %q", llm.ProviderOf(m))
+	}
+
+	if _, err := llm.Open(DefaultName, llm.Config{}); err == nil || !strings.Contains(err.Error(), "base url required") {
+		t.Errorf("Open(openai-compatible) without BaseURL error = %v", err)
+	}
+	if _, err := llm.Open("no-such-provider", llm.Config{}); err == nil {
+		t.Error("Open(unknown) should fail")
+	}
+}
@@ -0,0 +1,33 @@
+package chat
+
+import (
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/provider/base"
+	"github.com/lgc202/go-kit/llm/provider/openaicompat"
+)
+
+func init() {
+	// "openai-compatible" 需要 BaseURL；llm.Config.Params["name"] 可指定自定义 provider 名称
+	llm.RegisterChat(DefaultName, func(cfg llm.Config) (llm.ChatModel, error) {
+		return New(fromLLMConfig(llm.Provider(cfg.Param("name")), "", cfg))
+	})
+	for _, p := range openaicompat.Presets {
+		llm.RegisterChat(p.Name, func(cfg llm.Config) (llm.ChatModel, error) {
+			return New(fromLLMConfig(p.Name, p.BaseURL, cfg))
+		})
+	}
+}
+
+func fromLLMConfig(name llm.Provider, defaultBaseURL string, cfg llm.Config) Config {
+	c := Config{
+		BaseConfig:     base.FromConfig(cfg),
+		Name:           name,
+		Path:           cfg.Param("path"),
+		APIKeyHeader:   cfg.Param("api_key_header"),
+		DefaultOptions: cfg.DefaultChatOptions(),
+	}
+	if c.BaseURL == "" {
+		c.BaseURL = defaultBaseURL
+	}
+	return c
+}
@@ -0,0 +1,79 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm"
+	openaiCompatEmbeddings "github.com/lgc202/go-kit/llm/internal/openai_compat/embeddings"
+	"github.com/lgc202/go-kit/llm/provider/base"
+	"github.com/lgc202/go-kit/llm/provider/openaicompat"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// DefaultName 未指定 Name 时使用的 provider 名称
+const DefaultName = openaicompat.DefaultName
+
+var _ llm.Embedder = (*Client)(nil)
+var _ llm.ProviderNamer = (*Client)(nil)
+
+type BaseConfig = base.Config
+
+type Config struct {
+	// BaseURL 必填，如 "http://localhost:8000/v1"
+	BaseConfig
+
+	// Name provider 名称（如 "vllm"、"siliconflow"），默认 DefaultName
+	Name llm.Provider
+
+	// Path embeddings 端点路径，默认 "/embeddings"
+	Path string
+
+	// APIKeyHeader 非空时以该请求头原样携带 APIKey，默认 "Authorization: Bearer"
+	APIKeyHeader string
+
+	// DefaultOptions 客户端级别的默认请求选项
+	DefaultOptions []llm.EmbeddingOption
+}
+
+// Client 通用的 OpenAI 兼容 embeddings 客户端
+type Client struct {
+	name  llm.Provider
+	inner *openaiCompatEmbeddings.Client
+}
+
+func New(cfg Config) (*Client, error) {
+	name := llm.Provider(strings.TrimSpace(string(cfg.Name)))
+	if name == "" {
+		name = DefaultName
+	}
+	baseURL := strings.TrimSpace(cfg.BaseURL)
+	if baseURL == "" {
+		return nil, fmt.Errorf("%s: base url required", name)
+	}
+
+	inner, err := openaiCompatEmbeddings.New(openaiCompatEmbeddings.Config{
+		Provider:       name,
+		BaseURL:        baseURL,
+		Path:           cfg.Path,
+		APIKey:         cfg.APIKey,
+		APIKeyHeader:   cfg.APIKeyHeader,
+		HTTPClient:     cfg.HTTPClient,
+		HTTPX:          cfg.HTTPX,
+		HTTPXOptions:   cfg.HTTPXOptions,
+		DefaultHeaders: cfg.DefaultHeaders,
+		DefaultOptions: cfg.DefaultOptions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{name: name, inner: inner}, nil
+}
+
+func (c *Client) Provider() llm.Provider { return c.name }
+
+func (c *Client) Embed(ctx context.Context, inputs []string, opts ...llm.EmbeddingOption) (schema.EmbeddingResponse, error) {
+	return c.inner.Embed(ctx, inputs, opts...)
+}
@@ -0,0 +1,32 @@
+package embeddings
+
+import (
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/provider/base"
+	"github.com/lgc202/go-kit/llm/provider/openaicompat"
+)
+
+func init() {
+	llm.RegisterEmbedder(DefaultName, func(cfg llm.Config) (llm.Embedder, error) {
+		return New(fromLLMConfig(llm.Provider(cfg.Param("name")), "", cfg))
+	})
+	for _, p := range openaicompat.Presets {
+		llm.RegisterEmbedder(p.Name, func(cfg llm.Config) (llm.Embedder, error) {
+			return New(fromLLMConfig(p.Name, p.BaseURL, cfg))
+		})
+	}
+}
+
+func fromLLMConfig(name llm.Provider, defaultBaseURL string, cfg llm.Config) Config {
+	c := Config{
+		BaseConfig:     base.FromConfig(cfg),
+		Name:           name,
+		Path:           cfg.Param("path"),
+		APIKeyHeader:   cfg.Param("api_key_header"),
+		DefaultOptions: cfg.DefaultEmbeddingOptions(),
+	}
+	if c.BaseURL == "" {
+		c.BaseURL = defaultBaseURL
+	}
+	return c
+}
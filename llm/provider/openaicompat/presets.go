@@ -0,0 +1,22 @@
+// Package openaicompat 提供通用的 OpenAI 兼容 provider（chat 与 embeddings 子包）及常见服务预设
+package openaicompat
+
+import "github.com/lgc202/go-kit/llm"
+
+// DefaultName 未指定名称时使用的 provider 名称
+const DefaultName llm.Provider = "openai-compatible"
+
+// Preset 常见 OpenAI 兼容服务的名称与默认地址
+type Preset struct {
+	Name    llm.Provider
+	BaseURL string
+}
+
+// Presets 由 chat / embeddings 子包注册到 llm 的常见服务，BaseURL 可通过 llm.Config.BaseURL 覆盖
+var Presets = []Preset{
+	{Name: "vllm", BaseURL: "http://localhost:8000/v1"},
+	{Name: "lmstudio", BaseURL: "http://localhost:1234/v1"},
+	{Name: "openrouter", BaseURL: "https://openrouter.ai/api/v1"},
+	{Name: "siliconflow", BaseURL: "https://api.siliconflow.cn/v1"},
+	{Name: "zhipu", BaseURL: "https://open.bigmodel.cn/api/paas/v4"},
+}
@@ -0,0 +1,239 @@
+package llm
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lgc202/go-kit/httpx"
+)
+
+// Config 通过 Open / OpenDSN 创建模型时使用的通用配置
+type Config struct {
+	// BaseURL 为空时使用 provider 的默认地址
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+
+	// HTTPX 与 HTTPXOptions 见 provider/base.Config
+	HTTPX        *httpx.Client
+	HTTPXOptions []httpx.Option
+
+	DefaultHeaders http.Header
+
+	// Model 默认模型，等价于在默认选项最前面加上 WithModel(Model)
+	Model string
+
+	// Params provider 特定参数（如 Azure 的 "deployment"、"api-version"），
+	// OpenDSN 会将 DSN 中未识别的查询参数放入这里
+	Params map[string]string
+
+	// ChatOptions / EmbeddingOptions 客户端级别的默认请求选项
+	ChatOptions      []ChatOption
+	EmbeddingOptions []EmbeddingOption
+}
+
+// Param 读取 provider 特定参数，不存在时返回空字符串
+func (c Config) Param(key string) string {
+	return c.Params[key]
+}
+
+// DefaultChatOptions 返回 Model 与 ChatOptions 组合而成的默认 chat 选项
+func (c Config) DefaultChatOptions() []ChatOption {
+	if strings.TrimSpace(c.Model) == "" {
+		return slices.Clone(c.ChatOptions)
+	}
+	return slices.Concat([]ChatOption{WithModel(c.Model)}, c.ChatOptions)
+}
+
+// DefaultEmbeddingOptions 返回 Model 与 EmbeddingOptions 组合而成的默认 embeddings 选项
+func (c Config) DefaultEmbeddingOptions() []EmbeddingOption {
+	if strings.TrimSpace(c.Model) == "" {
+		return slices.Clone(c.EmbeddingOptions)
+	}
+	return slices.Concat([]EmbeddingOption{WithModel(c.Model)}, c.EmbeddingOptions)
+}
+
+// ChatFactory 根据通用配置创建 ChatModel
+type ChatFactory func(cfg Config) (ChatModel, error)
+
+// EmbedderFactory 根据通用配置创建 Embedder
+type EmbedderFactory func(cfg Config) (Embedder, error)
+
+var registry = struct {
+	mu        sync.RWMutex
+	chat      map[Provider]ChatFactory
+	embedders map[Provider]EmbedderFactory
+}{
+	chat:      make(map[Provider]ChatFactory),
+	embedders: make(map[Provider]EmbedderFactory),
+}
+
+// RegisterChat 以 name 注册 ChatModel 工厂，通常在 provider 包的 init 中调用。
+// name 为空、factory 为 nil 或重复注册时 panic。
+func RegisterChat(name Provider, factory ChatFactory) {
+	if factory == nil {
+		panic("llm: RegisterChat factory is nil for " + string(name))
+	}
+	registerFactory(registry.chat, "RegisterChat", name, factory)
+}
+
+// RegisterEmbedder 以 name 注册 Embedder 工厂，通常在 provider 包的 init 中调用。
+// name 为空、factory 为 nil 或重复注册时 panic。
+func RegisterEmbedder(name Provider, factory EmbedderFactory) {
+	if factory == nil {
+		panic("llm: RegisterEmbedder factory is nil for " + string(name))
+	}
+	registerFactory(registry.embedders, "RegisterEmbedder", name, factory)
+}
+
+func registerFactory[F any](m map[Provider]F, fn string, name Provider, factory F) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if strings.TrimSpace(string(name)) == "" {
+		panic("llm: " + fn + " with empty provider name")
+	}
+	if _, dup := m[name]; dup {
+		panic("llm: " + fn + " called twice for " + string(name))
+	}
+	m[name] = factory
+}
+
+// Providers 返回已注册 ChatModel 的 provider 名称（排序后）
+func Providers() []Provider {
+	return sortedKeys(registry.chat)
+}
+
+// EmbedderProviders 返回已注册 Embedder 的 provider 名称（排序后）
+func EmbedderProviders() []Provider {
+	return sortedKeys(registry.embedders)
+}
+
+func sortedKeys[F any](m map[Provider]F) []Provider {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	out := make([]Provider, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Open 使用已注册的工厂创建 ChatModel。
+//
+// provider 需要先被导入（如 import _ "github.com/lgc202/go-kit/llm/provider/all"）。
+func Open(name Provider, cfg Config) (ChatModel, error) {
+	registry.mu.RLock()
+	f, ok := registry.chat[name]
+	registry.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("llm: unknown chat provider %q (forgotten import?)", name)
+	}
+	return f(cfg)
+}
+
+// OpenEmbedder 使用已注册的工厂创建 Embedder
+func OpenEmbedder(name Provider, cfg Config) (Embedder, error) {
+	registry.mu.RLock()
+	f, ok := registry.embedders[name]
+	registry.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("llm: unknown embedder provider %q (forgotten import?)", name)
+	}
+	return f(cfg)
+}
+
+// OpenDSN 解析 DSN 并创建 ChatModel，DSN 格式见 ParseDSN
+func OpenDSN(dsn string) (ChatModel, error) {
+	name, cfg, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return Open(name, cfg)
+}
+
+// OpenEmbedderDSN 解析 DSN 并创建 Embedder，DSN 格式见 ParseDSN
+func OpenEmbedderDSN(dsn string) (Embedder, error) {
+	name, cfg, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return OpenEmbedder(name, cfg)
+}
+
+// ParseDSN 解析形如 "<provider>[+http|+https]://[:<api_key>@]<host>[/path]?model=<model>&..." 的 DSN。
+//
+//   - scheme 为 provider 名称；"+http" 后缀表示使用明文 HTTP（如本地 vLLM），默认 HTTPS
+//   - host 为空时（如 "deepseek://?model=deepseek-chat"）使用 provider 的默认地址
+//   - API Key 取自 userinfo 的密码部分或 api_key 查询参数
+//   - model 查询参数对应 Config.Model，其余查询参数放入 Config.Params
+func ParseDSN(dsn string) (Provider, Config, error) {
+	u, err := url.Parse(strings.TrimSpace(dsn))
+	if err != nil {
+		return "", Config{}, fmt.Errorf("llm: parse dsn: %w", err)
+	}
+	if u.Scheme == "" || u.Opaque != "" {
+		return "", Config{}, fmt.Errorf("llm: dsn %q has no provider scheme (want <provider>://...)", redactDSN(u))
+	}
+
+	name, transport := u.Scheme, "https"
+	if i := strings.LastIndex(name, "+"); i >= 0 {
+		name, transport = name[:i], name[i+1:]
+		if transport != "http" && transport != "https" {
+			return "", Config{}, fmt.Errorf("llm: dsn %q: unsupported transport %q", redactDSN(u), transport)
+		}
+	}
+
+	var cfg Config
+	if u.Host != "" {
+		cfg.BaseURL = (&url.URL{Scheme: transport, Host: u.Host, Path: u.Path}).String()
+	}
+	if u.User != nil {
+		if pw, ok := u.User.Password(); ok {
+			cfg.APIKey = pw
+		} else {
+			cfg.APIKey = u.User.Username()
+		}
+	}
+
+	for k, vs := range u.Query() {
+		if len(vs) == 0 {
+			continue
+		}
+		v := vs[len(vs)-1]
+		switch k {
+		case "model":
+			cfg.Model = v
+		case "api_key":
+			cfg.APIKey = v
+		default:
+			if cfg.Params == nil {
+				cfg.Params = make(map[string]string)
+			}
+			cfg.Params[k] = v
+		}
+	}
+
+	return Provider(name), cfg, nil
+}
+
+// redactDSN 隐藏 DSN 中的密钥，用于错误信息
+func redactDSN(u *url.URL) string {
+	c := *u
+	if c.User != nil {
+		c.User = url.User("xxxxx")
+	}
+	q := c.Query()
+	if q.Has("api_key") {
+		q.Set("api_key", "xxxxx")
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
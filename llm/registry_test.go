@@ -0,0 +1,82 @@
+package llm
+
+import "testing"
+
+func TestParseDSN(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		dsn       string
+		name      Provider
+		baseURL   string
+		apiKey    string
+		model     string
+		params    map[string]string
+		wantError bool
+	}{
+		{
+			dsn:     "openai-compatible://api.example.com/v1?model=x",
+			name:    "openai-compatible",
+			baseURL: "https://api.example.com/v1",
+			model:   "x",
+		},
+		{
+			dsn:     "vllm+http://:sk-1@localhost:8000/v1?model=qwen&name=local",
+			name:    "vllm",
+			baseURL: "http://localhost:8000/v1",
+			apiKey:  "sk-1",
+			model:   "qwen",
+			params:  map[string]string{"name": "local"},
+		},
+		{
+			dsn:    "deepseek://?model=deepseek-chat&api_key=sk-2",
+			name:   "deepseek",
+			apiKey: "sk-2",
+			model:  "deepseek-chat",
+		},
+		{
+			dsn:    "azure-openai://res.openai.azure.com?deployment=gpt4o&api-version=2024-10-21",
+			name:   "azure-openai",
+			params: map[string]string{"deployment": "gpt4o", "api-version": "2024-10-21"},
+		},
+		{dsn: "localhost:8000/v1", wantError: true},
+		{dsn: "vllm+ftp://host", wantError: true},
+	}
+
+	for _, tt := range tests {
+		name, cfg, err := ParseDSN(tt.dsn)
+		if tt.wantError {
+			if err == nil {
+				t.Errorf("ParseDSN(%q) error = nil, want error", tt.dsn)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDSN(%q) error = %v", tt.dsn, err)
+			continue
+		}
+		if name != tt.name || cfg.APIKey != tt.apiKey || cfg.Model != tt.model {
+			t.Errorf("ParseDSN(%q) = %q, %+v", tt.dsn, name, cfg)
+		}
+		if tt.baseURL != "" && cfg.BaseURL != tt.baseURL {
+			t.Errorf("ParseDSN(%q) BaseURL = %q, want %q", tt.dsn, cfg.BaseURL, tt.baseURL)
+		}
+		for k, v := range tt.params {
+			if cfg.Param(k) != v {
+				t.Errorf("ParseDSN(%q) Param(%q) = %q, want %q", tt.dsn, k, cfg.Param(k), v)
+			}
+		}
+	}
+}
+
+func TestRegisterChat_PanicsOnDuplicate(t *testing.T) {
+	factory := func(Config) (ChatModel, error) { return nil, nil }
+	RegisterChat("test-dup", factory)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterChat() twice should panic")
+		}
+	}()
+	RegisterChat("test-dup", factory)
+}
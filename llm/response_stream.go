@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"io"
+	"sync"
+
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// ResponseEvents 将完整的响应拆分为流式事件，是 Accumulator 的逆过程，累积后得到等价的响应。
+//
+// 每个 choice 依次产生推理内容与文本的 delta 事件（size > 0 时按 size 个 rune 拆分，否则各一个）、
+// 每个推理块与每个工具调用各一个事件，以及带 FinishReason 的 done 事件；
+// 响应的 ExtraFields 位于第一个事件，Usage 与 Model 位于最后一个事件
+func ResponseEvents(resp schema.ChatResponse, size int) []schema.StreamEvent {
+	var events []schema.StreamEvent
+	for _, ch := range resp.Choices {
+		msg := ch.Message
+		for _, s := range splitRunes(msg.ReasoningContent, size) {
+			events = append(events, schema.StreamEvent{Type: schema.StreamEventDelta, ChoiceIndex: ch.Index, Reasoning: s})
+		}
+		for _, s := range splitRunes(msg.Text(), size) {
+			events = append(events, schema.StreamEvent{Type: schema.StreamEventDelta, ChoiceIndex: ch.Index, Delta: s})
+		}
+		for _, b := range msg.ReasoningBlocks {
+			events = append(events, schema.StreamEvent{Type: schema.StreamEventDelta, ChoiceIndex: ch.Index, ReasoningBlock: &b})
+		}
+		for i, tc := range msg.ToolCalls {
+			tc.Index = &i
+			events = append(events, schema.StreamEvent{Type: schema.StreamEventDelta, ChoiceIndex: ch.Index, ToolCalls: []schema.ToolCall{tc}})
+		}
+		fr := ch.FinishReason
+		events = append(events, schema.StreamEvent{Type: schema.StreamEventDone, ChoiceIndex: ch.Index, FinishReason: &fr})
+	}
+	if len(events) == 0 {
+		events = append(events, schema.StreamEvent{Type: schema.StreamEventDone})
+	}
+
+	events[0].ExtraFields = resp.ExtraFields
+	last := &events[len(events)-1]
+	u := resp.Usage
+	last.Usage = &u
+	last.Model = resp.Model
+	return events
+}
+
+// ResponseStream 返回依次产生 ResponseEvents(resp, size) 的 Stream，用于回放缓存或测试中的固定响应
+func ResponseStream(resp schema.ChatResponse, size int) Stream {
+	return &eventStream{events: ResponseEvents(resp, size)}
+}
+
+type eventStream struct {
+	mu     sync.Mutex
+	events []schema.StreamEvent
+}
+
+func (s *eventStream) Recv() (schema.StreamEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.events) == 0 {
+		return schema.StreamEvent{}, io.EOF
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func (s *eventStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = nil
+	return nil
+}
+
+// splitRunes 按 size 个 rune 拆分 s，size <= 0 时不拆分；s 为空时返回 nil
+func splitRunes(s string, size int) []string {
+	if s == "" {
+		return nil
+	}
+	if size <= 0 {
+		return []string{s}
+	}
+	var out []string
+	r := []rune(s)
+	for len(r) > 0 {
+		n := min(size, len(r))
+		out = append(out, string(r[:n]))
+		r = r[n:]
+	}
+	return out
+}
@@ -0,0 +1,306 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/lgc202/go-kit/httpx"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// RetryConfig 重试中间件的配置
+type RetryConfig struct {
+	// MaxAttempts 最大尝试次数（含首次），<= 0 时为 3
+	MaxAttempts int
+
+	// MaxElapsed 全部尝试（含等待）的总时间预算，下一次等待会超出预算时不再重试；0 表示不限制
+	MaxElapsed time.Duration
+
+	// Backoff 计算重试前的等待时间，默认 httpx.DefaultBackoff()（指数退避 + 抖动）
+	Backoff httpx.Backoff
+
+	// MaxRetryAfter APIError.RetryAfter 的上限，超过时按上限等待；0 表示不限制
+	MaxRetryAfter time.Duration
+
+	// Retryable 判断错误是否可重试，默认 IsRetryable
+	Retryable func(err error) bool
+
+	// OnAttempt 每次尝试结束后调用（包括成功的尝试）
+	OnAttempt func(a RetryAttempt)
+}
+
+// RetryAttempt 一次尝试的结果
+type RetryAttempt struct {
+	// Attempt 从 1 开始
+	Attempt int
+	// Err 本次尝试的错误，成功时为 nil
+	Err error
+	// Duration 本次尝试的耗时
+	Duration time.Duration
+	// Delay 下一次尝试前的等待时间，不再重试时为 0
+	Delay time.Duration
+}
+
+// RetryError 多次尝试后仍失败，记录每次尝试的错误。Unwrap 返回最后一次的错误
+type RetryError struct {
+	Errors []error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("llm: giving up after %d attempts: %v", len(e.Errors), e.Errors[len(e.Errors)-1])
+}
+
+func (e *RetryError) Unwrap() error { return e.Errors[len(e.Errors)-1] }
+
+// APIErrors 返回每次尝试的 *APIError，非 API 错误（如网络错误）的位置为 nil
+func (e *RetryError) APIErrors() []*APIError {
+	out := make([]*APIError, len(e.Errors))
+	for i, err := range e.Errors {
+		out[i], _ = AsAPIError(err)
+	}
+	return out
+}
+
+// IsRetryable 默认的可重试判断：IsTemporary 的 API 错误（408、429、5xx、529），
+// 以及超时、连接重置、响应意外中断等网络错误
+func IsRetryable(err error) bool {
+	if IsTemporary(err) {
+		return true
+	}
+	if _, ok := AsAPIError(err); ok {
+		return false
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Retry 返回自动重试的中间件。
+//
+// 仅重试 Retryable 判定为可重试的错误；APIError.RetryAfter 非零时按其等待，否则按 Backoff 退避。
+// 流式调用仅在尚未向调用方返回任何事件时重试（包括建立流失败与首个 Recv 出错），之后的错误原样返回。
+// 发生过重试且最终失败时返回 *RetryError。
+func Retry(cfg RetryConfig) ChatMiddleware {
+	cfg = cfg.withDefaults()
+	return ChatMiddlewareFuncs{
+		Chat: func(ctx context.Context, next ChatModel, messages []schema.Message, opts ...ChatOption) (schema.ChatResponse, error) {
+			var resp schema.ChatResponse
+			err := cfg.do(ctx, newRetryState(), func() error {
+				var err error
+				resp, err = next.Chat(ctx, messages, opts...)
+				return err
+			})
+			return resp, err
+		},
+		ChatStream: func(ctx context.Context, next ChatModel, messages []schema.Message, opts ...ChatOption) (Stream, error) {
+			s := &retryStream{cfg: cfg, state: newRetryState(), ctx: ctx, open: func() (Stream, error) {
+				return next.ChatStream(ctx, messages, opts...)
+			}}
+			if err := s.connect(); err != nil {
+				return nil, err
+			}
+			return s, nil
+		},
+	}.Middleware()
+}
+
+// RetryEmbedder 返回自动重试的 Embedder 中间件，规则同 Retry
+func RetryEmbedder(cfg RetryConfig) EmbedderMiddleware {
+	cfg = cfg.withDefaults()
+	return func(next Embedder) Embedder {
+		return EmbedderFunc(func(ctx context.Context, inputs []string, opts ...EmbeddingOption) (schema.EmbeddingResponse, error) {
+			var resp schema.EmbeddingResponse
+			err := cfg.do(ctx, newRetryState(), func() error {
+				var err error
+				resp, err = next.Embed(ctx, inputs, opts...)
+				return err
+			})
+			return resp, err
+		})
+	}
+}
+
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 3
+	}
+	if c.Backoff == nil {
+		c.Backoff = httpx.DefaultBackoff()
+	}
+	if c.Retryable == nil {
+		c.Retryable = IsRetryable
+	}
+	return c
+}
+
+type retryState struct {
+	start time.Time
+	errs  []error
+}
+
+func newRetryState() *retryState {
+	return &retryState{start: time.Now()}
+}
+
+// do 执行 fn 直到成功、错误不可重试或用尽次数 / 时间预算
+func (c RetryConfig) do(ctx context.Context, st *retryState, fn func() error) error {
+	for {
+		begin := time.Now()
+		err := fn()
+		if err == nil {
+			c.report(len(st.errs)+1, nil, time.Since(begin), 0)
+			return nil
+		}
+		if err := c.wait(ctx, st, err, time.Since(begin)); err != nil {
+			return err
+		}
+	}
+}
+
+// wait 记录一次失败；可以重试时等待后返回 nil，否则返回最终错误
+func (c RetryConfig) wait(ctx context.Context, st *retryState, err error, took time.Duration) error {
+	st.errs = append(st.errs, err)
+	attempt := len(st.errs)
+
+	delay, ok := c.delay(ctx, st, err, attempt)
+	if !ok {
+		c.report(attempt, err, took, 0)
+		if attempt == 1 {
+			return err
+		}
+		return &RetryError{Errors: st.errs}
+	}
+	c.report(attempt, err, took, delay)
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		st.errs = append(st.errs, ctx.Err())
+		return &RetryError{Errors: st.errs}
+	case <-t.C:
+		return nil
+	}
+}
+
+func (c RetryConfig) delay(ctx context.Context, st *retryState, err error, attempt int) (time.Duration, bool) {
+	if attempt >= c.MaxAttempts || ctx.Err() != nil || !c.Retryable(err) {
+		return 0, false
+	}
+	d := c.Backoff.Next(attempt)
+	if ae, ok := AsAPIError(err); ok && ae.RetryAfter > 0 {
+		d = ae.RetryAfter
+		if c.MaxRetryAfter > 0 && d > c.MaxRetryAfter {
+			d = c.MaxRetryAfter
+		}
+	}
+	if c.MaxElapsed > 0 && time.Since(st.start)+d > c.MaxElapsed {
+		return 0, false
+	}
+	return d, true
+}
+
+func (c RetryConfig) report(attempt int, err error, took, delay time.Duration) {
+	if c.OnAttempt != nil {
+		c.OnAttempt(RetryAttempt{Attempt: attempt, Err: err, Duration: took, Delay: delay})
+	}
+}
+
+// retryStream 在向调用方返回首个事件前，遇到可重试错误时重新建立流
+type retryStream struct {
+	cfg   RetryConfig
+	state *retryState
+	ctx   context.Context
+	open  func() (Stream, error)
+
+	delivered bool
+
+	// mu 保护 cur 与 err，Close 可与 Recv 并发调用
+	mu  sync.Mutex
+	cur Stream
+	err error
+}
+
+func (s *retryStream) connect() error {
+	return s.cfg.do(s.ctx, s.state, func() error {
+		st, err := s.open()
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.cur = st
+		closed := s.err != nil
+		s.mu.Unlock()
+		// 重连期间被 Close，新建的流同样需要关闭
+		if closed {
+			_ = st.Close()
+		}
+		return nil
+	})
+}
+
+func (s *retryStream) Recv() (schema.StreamEvent, error) {
+	for {
+		cur, serr := s.load()
+		if serr != nil {
+			return schema.StreamEvent{}, serr
+		}
+
+		begin := time.Now()
+		ev, err := cur.Recv()
+		if err == nil || s.delivered || errors.Is(err, io.EOF) {
+			s.delivered = true
+			return ev, err
+		}
+
+		// 被 Close 中断的读取不再重试
+		if _, serr := s.load(); serr != nil {
+			return schema.StreamEvent{}, serr
+		}
+		_ = cur.Close()
+		if werr := s.cfg.wait(s.ctx, s.state, err, time.Since(begin)); werr != nil {
+			s.setErr(werr)
+			return schema.StreamEvent{}, werr
+		}
+		if cerr := s.connect(); cerr != nil {
+			s.setErr(cerr)
+			return schema.StreamEvent{}, cerr
+		}
+	}
+}
+
+func (s *retryStream) load() (Stream, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cur, s.err
+}
+
+func (s *retryStream) setErr(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+// Unwrap 返回当前连接的 Stream，供 StatsOf 使用
+func (s *retryStream) Unwrap() Stream {
+	cur, _ := s.load()
+	return cur
+}
+
+func (s *retryStream) Close() error {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = ErrStreamClosed
+	}
+	cur := s.cur
+	s.mu.Unlock()
+	return cur.Close()
+}
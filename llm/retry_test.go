@@ -0,0 +1,250 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// noBackoff 重试前不等待
+type noBackoff struct{}
+
+func (noBackoff) Next(int) time.Duration { return 0 }
+
+// flakyModel 依次返回 errs 中的错误，用完后成功；流式调用依次返回 streams
+type flakyModel struct {
+	errs    []error
+	streams []*sliceStream
+	calls   int
+}
+
+func (m *flakyModel) next() error {
+	m.calls++
+	if len(m.errs) == 0 {
+		return nil
+	}
+	err := m.errs[0]
+	m.errs = m.errs[1:]
+	return err
+}
+
+func (m *flakyModel) Chat(context.Context, []schema.Message, ...llm.ChatOption) (schema.ChatResponse, error) {
+	if err := m.next(); err != nil {
+		return schema.ChatResponse{}, err
+	}
+	return schema.ChatResponse{Choices: []schema.Choice{{Message: schema.AssistantMessage("ok")}}}, nil
+}
+
+func (m *flakyModel) ChatStream(context.Context, []schema.Message, ...llm.ChatOption) (llm.Stream, error) {
+	if err := m.next(); err != nil {
+		return nil, err
+	}
+	s := m.streams[0]
+	m.streams = m.streams[1:]
+	return s, nil
+}
+
+func apiErr(status int) *llm.APIError {
+	return &llm.APIError{Provider: llm.ProviderOpenAI, StatusCode: status, Message: http.StatusText(status)}
+}
+
+func TestRetry_Chat(t *testing.T) {
+	t.Parallel()
+
+	base := &flakyModel{errs: []error{apiErr(503), apiErr(429)}}
+	var attempts []llm.RetryAttempt
+	model := llm.Chain(base, llm.Retry(llm.RetryConfig{
+		Backoff:   noBackoff{},
+		OnAttempt: func(a llm.RetryAttempt) { attempts = append(attempts, a) },
+	}))
+
+	resp, err := model.Chat(context.Background(), nil)
+	if err != nil || resp.Choices[0].Message.Text() != "ok" {
+		t.Fatalf("Chat() = %+v, %v", resp, err)
+	}
+	if base.calls != 3 || len(attempts) != 3 {
+		t.Fatalf("calls = %d, attempts = %+v", base.calls, attempts)
+	}
+	if attempts[0].Attempt != 1 || !llm.IsTemporary(attempts[0].Err) || attempts[2].Err != nil {
+		t.Errorf("attempts = %+v", attempts)
+	}
+}
+
+func TestRetry_GiveUp(t *testing.T) {
+	t.Parallel()
+
+	base := &flakyModel{errs: []error{apiErr(500), apiErr(502), apiErr(503), apiErr(504)}}
+	model := llm.Chain(base, llm.Retry(llm.RetryConfig{Backoff: noBackoff{}}))
+
+	_, err := model.Chat(context.Background(), nil)
+	var re *llm.RetryError
+	if !errors.As(err, &re) || base.calls != 3 {
+		t.Fatalf("err = %v, calls = %d", err, base.calls)
+	}
+	var codes []int
+	for _, ae := range re.APIErrors() {
+		codes = append(codes, ae.StatusCode)
+	}
+	if len(codes) != 3 || codes[0] != 500 || codes[2] != 503 {
+		t.Errorf("APIErrors status = %v", codes)
+	}
+	// Unwrap 为最后一次的错误
+	if ae, ok := llm.AsAPIError(err); !ok || ae.StatusCode != 503 {
+		t.Errorf("AsAPIError() = %v", ae)
+	}
+}
+
+func TestRetry_NotRetryable(t *testing.T) {
+	t.Parallel()
+
+	base := &flakyModel{errs: []error{apiErr(401)}}
+	model := llm.Chain(base, llm.Retry(llm.RetryConfig{Backoff: noBackoff{}}))
+
+	_, err := model.Chat(context.Background(), nil)
+	var re *llm.RetryError
+	if !llm.IsAuth(err) || errors.As(err, &re) || base.calls != 1 {
+		t.Errorf("err = %v, calls = %d", err, base.calls)
+	}
+}
+
+func TestRetry_RetryAfter(t *testing.T) {
+	t.Parallel()
+
+	limited := apiErr(429)
+	limited.RetryAfter = time.Hour
+	var delays []time.Duration
+	cfg := llm.RetryConfig{
+		Backoff:       noBackoff{},
+		MaxRetryAfter: time.Millisecond,
+		OnAttempt:     func(a llm.RetryAttempt) { delays = append(delays, a.Delay) },
+	}
+
+	base := &flakyModel{errs: []error{limited}}
+	if _, err := llm.Chain(base, llm.Retry(cfg)).Chat(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(delays) != 2 || delays[0] != time.Millisecond {
+		t.Errorf("delays = %v, want RetryAfter capped to 1ms", delays)
+	}
+
+	// 等待会超出总时间预算时不再重试
+	cfg.MaxRetryAfter = 0
+	cfg.MaxElapsed = time.Second
+	cfg.OnAttempt = nil
+	base = &flakyModel{errs: []error{limited}}
+	_, err := llm.Chain(base, llm.Retry(cfg)).Chat(context.Background(), nil)
+	if !llm.IsRateLimit(err) || base.calls != 1 {
+		t.Errorf("err = %v, calls = %d", err, base.calls)
+	}
+}
+
+func TestRetry_ContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	base := &flakyModel{errs: []error{apiErr(503), apiErr(503)}}
+	model := llm.Chain(base, llm.Retry(llm.RetryConfig{
+		// 默认退避，在等待期间取消
+		OnAttempt: func(llm.RetryAttempt) { cancel() },
+	}))
+
+	_, err := model.Chat(ctx, nil)
+	if !errors.Is(err, context.Canceled) || base.calls != 1 {
+		t.Errorf("err = %v, calls = %d", err, base.calls)
+	}
+}
+
+func TestRetry_Stream(t *testing.T) {
+	t.Parallel()
+
+	failing := newSliceStream()
+	failing.err = apiErr(502)
+	good := newSliceStream("a", "b")
+	base := &flakyModel{errs: []error{apiErr(503)}, streams: []*sliceStream{failing, good}}
+	model := llm.Chain(base, llm.Retry(llm.RetryConfig{MaxAttempts: 4, Backoff: noBackoff{}}))
+
+	// 建立流失败与首个事件前出错均会重试
+	stream, err := model.ChatStream(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var text string
+	for {
+		ev, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		text += ev.Delta
+	}
+	if text != "ab" || base.calls != 3 || !failing.closed.Load() {
+		t.Errorf("text = %q, calls = %d, failing closed = %v", text, base.calls, failing.closed.Load())
+	}
+}
+
+func TestRetry_StreamAfterFirstEvent(t *testing.T) {
+	t.Parallel()
+
+	partial := newSliceStream("a")
+	partial.err = apiErr(503)
+	base := &flakyModel{streams: []*sliceStream{partial, newSliceStream("x")}}
+	model := llm.Chain(base, llm.Retry(llm.RetryConfig{Backoff: noBackoff{}}))
+
+	stream, err := model.ChatStream(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev, err := stream.Recv(); err != nil || ev.Delta != "a" {
+		t.Fatalf("Recv() = %+v, %v", ev, err)
+	}
+	// 已向调用方返回事件，不再重试
+	if _, err := stream.Recv(); !llm.IsTemporary(err) || base.calls != 1 {
+		t.Errorf("Recv() err = %v, calls = %d", err, base.calls)
+	}
+}
+
+func TestRetryEmbedder(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	base := llm.EmbedderFunc(func(context.Context, []string, ...llm.EmbeddingOption) (schema.EmbeddingResponse, error) {
+		calls++
+		if calls == 1 {
+			return schema.EmbeddingResponse{}, apiErr(529)
+		}
+		return schema.EmbeddingResponse{}, nil
+	})
+	emb := llm.ChainEmbedder(base, llm.RetryEmbedder(llm.RetryConfig{Backoff: noBackoff{}}))
+	if _, err := emb.Embed(context.Background(), []string{"x"}); err != nil || calls != 2 {
+		t.Errorf("Embed() err = %v, calls = %d", err, calls)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{apiErr(429), true},
+		{apiErr(400), false},
+		{io.ErrUnexpectedEOF, true},
+		{errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := llm.IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
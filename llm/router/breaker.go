@@ -0,0 +1,125 @@
+package router
+
+import (
+	"sync"
+	"time"
+)
+
+// State 后端的熔断状态
+type State int
+
+const (
+	// StateClosed 正常，接受请求
+	StateClosed State = iota
+	// StateOpen 连续失败达到阈值，冷却期内不接受请求
+	StateOpen
+	// StateHalfOpen 冷却期已过，允许一个试探请求，成功后恢复，失败后重新熔断
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Health 后端的健康状况
+type Health struct {
+	Name  string
+	State State
+	// Failures 连续失败次数
+	Failures int
+	// OpenUntil 熔断结束时间，仅 StateOpen 时有意义
+	OpenUntil time.Time
+}
+
+// breaker 单个后端的熔断器
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *breaker) state(now time.Time) State {
+	switch {
+	case b.failures < b.threshold:
+		return StateClosed
+	case now.Before(b.openUntil):
+		return StateOpen
+	default:
+		return StateHalfOpen
+	}
+}
+
+// allow 判断是否可以发送请求；半开状态下同一时间只放行一个试探请求，此时 probe 为 true
+func (b *breaker) allow(now time.Time) (ok, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state(now) {
+	case StateClosed:
+		return true, false
+	case StateHalfOpen:
+		if b.probing {
+			return false, false
+		}
+		b.probing = true
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	b.openUntil = time.Time{}
+}
+
+func (b *breaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.failures >= b.threshold {
+		b.openUntil = now.Add(b.cooldown)
+	}
+}
+
+// release 结束请求而不改变健康状况（如调用方取消了请求）；只有试探请求（probe）才结束试探，
+// 熔断前发出、此时才结束的普通请求不影响进行中的试探
+func (b *breaker) release(probe bool) {
+	if !probe {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *breaker) health(name string, now time.Time) Health {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h := Health{Name: name, State: b.state(now), Failures: b.failures}
+	if h.State == StateOpen {
+		h.OpenUntil = b.openUntil
+	}
+	return h
+}
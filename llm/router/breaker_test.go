@@ -0,0 +1,35 @@
+package router
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker_ReleaseOnlyEndsProbe(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	b := &breaker{threshold: 1, cooldown: time.Second}
+
+	// 熔断前放行的普通请求
+	if ok, probe := b.allow(now); !ok || probe {
+		t.Fatalf("allow() = %v, %v, want normal request", ok, probe)
+	}
+	b.failure(now)
+
+	later := now.Add(2 * time.Second)
+	if ok, probe := b.allow(later); !ok || !probe {
+		t.Fatalf("allow() = %v, %v, want probe", ok, probe)
+	}
+
+	// 普通请求被取消，不应结束进行中的试探
+	b.release(false)
+	if ok, _ := b.allow(later); ok {
+		t.Error("second probe allowed after non-probe release")
+	}
+
+	b.release(true)
+	if ok, probe := b.allow(later); !ok || !probe {
+		t.Errorf("allow() after probe release = %v, %v", ok, probe)
+	}
+}
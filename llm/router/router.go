@@ -0,0 +1,367 @@
+// Package router 在多个 ChatModel 之间路由请求，并在出现临时错误时自动切换到下一个后端。
+//
+// 后端可按顺序（主备）或按权重选择；每个后端有独立的熔断器，连续失败达到阈值后在冷却期内跳过。
+// 实际响应的后端通过 Router.Provider（llm.ProviderOf）与响应的 ExtraFields 报告。
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// ExtraFields 中标识实际响应后端的键，值为 string
+const (
+	// ExtraBackend 后端名称（Backend.Name）
+	ExtraBackend = "router_backend"
+	// ExtraProvider 后端的 provider（llm.ProviderOf），即 llm.ExtraProvider，可用 llm.ProviderOfResponse 读取
+	ExtraProvider = llm.ExtraProvider
+)
+
+// 熔断默认值
+const (
+	DefaultFailureThreshold = 3
+	DefaultCooldown         = 30 * time.Second
+)
+
+// ErrUnavailable 全部后端都处于熔断状态，没有发出任何请求
+var ErrUnavailable = errors.New("router: all backends unavailable")
+
+// Strategy 后端的选择方式
+type Strategy int
+
+const (
+	// Ordered 按 Backends 的顺序依次尝试（默认）
+	Ordered Strategy = iota
+	// Weighted 按 Backend.Weight 随机选择首个后端，失败后在其余后端中继续按权重选择
+	Weighted
+)
+
+// Backend 一个后端
+type Backend struct {
+	// Name 后端名称，默认为 provider 名称；同一 Router 内不可重复
+	Name string
+
+	Model llm.ChatModel
+
+	// Weight Weighted 策略下的权重，<= 0 时为 1
+	Weight int
+
+	// Models 模型名映射：请求的模型名（llm.WithModel，未设置时为 ""）到该后端的模型名，
+	// 键 "*" 匹配其余模型名；未命中时原样传递
+	Models map[string]string
+}
+
+type Config struct {
+	Backends []Backend
+
+	Strategy Strategy
+
+	// ShouldFailover 判断错误是否应切换到下一个后端，默认 ShouldFailover。
+	// 调用方的 context 结束后不再切换
+	ShouldFailover func(err error) bool
+
+	// FailureThreshold 连续失败多少次后熔断，默认 DefaultFailureThreshold
+	FailureThreshold int
+
+	// Cooldown 熔断持续时间，之后放行一个试探请求，默认 DefaultCooldown
+	Cooldown time.Duration
+
+	// OnFailover 后端失败并切换时调用
+	OnFailover func(backend string, err error)
+
+	// Rand Weighted 策略使用的随机数源，默认使用全局随机数
+	Rand *rand.Rand
+
+	// Now 返回当前时间，默认 time.Now，用于测试
+	Now func() time.Time
+}
+
+// Router 按策略在多个后端之间路由并自动切换，实现 llm.ChatModel，可并发使用
+type Router struct {
+	cfg      Config
+	backends []*backend
+	served   atomic.Value // llm.Provider
+
+	randMu sync.Mutex
+}
+
+type backend struct {
+	name     string
+	provider llm.Provider
+	model    llm.ChatModel
+	weight   int
+	models   map[string]string
+	breaker  *breaker
+}
+
+var _ llm.ChatModel = (*Router)(nil)
+
+func New(cfg Config) (*Router, error) {
+	if len(cfg.Backends) == 0 {
+		return nil, fmt.Errorf("router: no backends")
+	}
+	if cfg.ShouldFailover == nil {
+		cfg.ShouldFailover = ShouldFailover
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = DefaultFailureThreshold
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = DefaultCooldown
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	r := &Router{cfg: cfg}
+	seen := make(map[string]bool, len(cfg.Backends))
+	for i, b := range cfg.Backends {
+		if b.Model == nil {
+			return nil, fmt.Errorf("router: backend %d: model is nil", i)
+		}
+		p := llm.ProviderOf(b.Model)
+		name := b.Name
+		if name == "" {
+			name = string(p)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("router: duplicate backend name %q", name)
+		}
+		seen[name] = true
+		r.backends = append(r.backends, &backend{
+			name:     name,
+			provider: p,
+			model:    b.Model,
+			weight:   max(b.Weight, 1),
+			models:   b.Models,
+			breaker:  &breaker{threshold: cfg.FailureThreshold, cooldown: cfg.Cooldown},
+		})
+	}
+	r.served.Store(r.backends[0].provider)
+	return r, nil
+}
+
+// ShouldFailover 默认的切换判断：llm.IsRetryable 的错误（临时错误、限流、网络超时等），
+// 以及其他网络错误（如连接被拒绝）和单次请求超时
+func ShouldFailover(err error) bool {
+	if llm.IsRetryable(err) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if _, ok := llm.AsAPIError(err); ok {
+		return false
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// Provider 实现 llm.ProviderNamer，固定返回第一个后端的 provider。
+//
+// ProviderOf 没有调用上下文，无法对应到单次调用；某次调用实际由哪个后端响应，
+// 以 llm.ProviderOfResponse（即响应或流式首个事件的 ExtraFields[ExtraProvider]）为准，
+// usage 等中间件按此记录 provider
+func (r *Router) Provider() llm.Provider {
+	return r.backends[0].provider
+}
+
+// LastProvider 返回最近一次成功响应的后端的 provider，尚无响应时为第一个后端的 provider。
+//
+// 该值在所有调用之间共享，并发调用时不一定对应调用方自己的请求；单次调用请使用 ExtraFields[ExtraProvider]
+func (r *Router) LastProvider() llm.Provider {
+	return r.served.Load().(llm.Provider)
+}
+
+// Health 按 Backends 的顺序返回各后端的健康状况
+func (r *Router) Health() []Health {
+	now := r.cfg.Now()
+	out := make([]Health, len(r.backends))
+	for i, b := range r.backends {
+		out[i] = b.breaker.health(b.name, now)
+	}
+	return out
+}
+
+func (r *Router) Chat(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+	var resp schema.ChatResponse
+	err := r.route(ctx, func(b *backend) error {
+		var err error
+		resp, err = b.model.Chat(ctx, messages, b.options(opts)...)
+		if err == nil {
+			annotate(&resp.ExtraFields, b)
+		}
+		return err
+	})
+	return resp, err
+}
+
+// ChatStream 依次尝试各后端，直到某个后端成功返回首个事件（或正常结束）；
+// 首个事件之后的错误不再切换，原样由 Recv 返回
+func (r *Router) ChatStream(ctx context.Context, messages []schema.Message, opts ...llm.ChatOption) (llm.Stream, error) {
+	var out llm.Stream
+	err := r.route(ctx, func(b *backend) error {
+		s, err := b.model.ChatStream(ctx, messages, b.options(opts)...)
+		if err != nil {
+			return err
+		}
+		ev, err := s.Recv()
+		if err != nil && !errors.Is(err, io.EOF) {
+			_ = s.Close()
+			return err
+		}
+		annotate(&ev.ExtraFields, b)
+		out = &peekStream{Stream: s, first: ev, firstErr: err}
+		return nil
+	})
+	return out, err
+}
+
+// route 按策略依次调用 call，直到成功或错误不应切换
+func (r *Router) route(ctx context.Context, call func(b *backend) error) error {
+	var errs []BackendError
+	for _, b := range r.order() {
+		ok, probe := b.breaker.allow(r.cfg.Now())
+		if !ok {
+			continue
+		}
+		err := call(b)
+		switch {
+		case err == nil:
+			b.breaker.success()
+			r.served.Store(b.provider)
+			return nil
+		case ctx.Err() != nil:
+			b.breaker.release(probe)
+			return err
+		case !r.cfg.ShouldFailover(err):
+			// 后端可用，错误来自请求本身（如参数错误）
+			b.breaker.success()
+			return err
+		}
+
+		b.breaker.failure(r.cfg.Now())
+		errs = append(errs, BackendError{Backend: b.name, Err: err})
+		if r.cfg.OnFailover != nil {
+			r.cfg.OnFailover(b.name, err)
+		}
+	}
+	if len(errs) == 0 {
+		return ErrUnavailable
+	}
+	return &FailoverError{Errors: errs}
+}
+
+// order 返回本次请求尝试后端的顺序
+func (r *Router) order() []*backend {
+	if r.cfg.Strategy != Weighted {
+		return r.backends
+	}
+
+	rest := slices.Clone(r.backends)
+	out := make([]*backend, 0, len(rest))
+	total := 0
+	for _, b := range rest {
+		total += b.weight
+	}
+
+	r.randMu.Lock()
+	defer r.randMu.Unlock()
+	for len(rest) > 0 {
+		n := r.intN(total)
+		i := 0
+		for ; n >= rest[i].weight; i++ {
+			n -= rest[i].weight
+		}
+		out = append(out, rest[i])
+		total -= rest[i].weight
+		rest = slices.Delete(rest, i, i+1)
+	}
+	return out
+}
+
+func (r *Router) intN(n int) int {
+	if r.cfg.Rand != nil {
+		return r.cfg.Rand.IntN(n)
+	}
+	return rand.IntN(n)
+}
+
+// options 按 Models 映射模型名
+func (b *backend) options(opts []llm.ChatOption) []llm.ChatOption {
+	if len(b.models) == 0 {
+		return opts
+	}
+	requested := llm.ApplyChatOptions(opts...).Model
+	m, ok := b.models[requested]
+	if !ok {
+		m, ok = b.models["*"]
+	}
+	if !ok || m == requested {
+		return opts
+	}
+	return append(slices.Clip(opts), llm.WithModel(m))
+}
+
+func annotate(extra *map[string]any, b *backend) {
+	if *extra == nil {
+		*extra = make(map[string]any, 2)
+	}
+	(*extra)[ExtraBackend] = b.name
+	(*extra)[ExtraProvider] = string(b.provider)
+}
+
+// BackendError 某个后端的失败
+type BackendError struct {
+	Backend string
+	Err     error
+}
+
+func (e BackendError) Error() string {
+	return e.Backend + ": " + e.Err.Error()
+}
+
+func (e BackendError) Unwrap() error { return e.Err }
+
+// FailoverError 尝试过的后端全部失败。Unwrap 返回最后一个后端的错误
+type FailoverError struct {
+	Errors []BackendError
+}
+
+func (e *FailoverError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, be := range e.Errors {
+		parts[i] = be.Error()
+	}
+	return fmt.Sprintf("router: all %d backend(s) failed: %s", len(e.Errors), strings.Join(parts, "; "))
+}
+
+func (e *FailoverError) Unwrap() error { return e.Errors[len(e.Errors)-1].Err }
+
+// peekStream 先返回已读取的首个事件
+type peekStream struct {
+	llm.Stream
+	first    schema.StreamEvent
+	firstErr error
+	peeked   bool
+}
+
+// Unwrap 返回被包装的 Stream，供 llm.StatsOf 使用
+func (s *peekStream) Unwrap() llm.Stream { return s.Stream }
+
+func (s *peekStream) Recv() (schema.StreamEvent, error) {
+	if !s.peeked {
+		s.peeked = true
+		return s.first, s.firstErr
+	}
+	return s.Stream.Recv()
+}
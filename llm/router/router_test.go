@@ -0,0 +1,327 @@
+package router_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/router"
+	"github.com/lgc202/go-kit/llm/schema"
+	"github.com/lgc202/go-kit/llm/usage"
+)
+
+// backendModel 返回 err（非 nil 时）或回复 name；记录每次请求的模型名
+type backendModel struct {
+	provider llm.Provider
+	name     string
+	err      error
+	models   []string
+}
+
+func (m *backendModel) Provider() llm.Provider { return m.provider }
+
+func (m *backendModel) Chat(_ context.Context, _ []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+	m.models = append(m.models, llm.ApplyChatOptions(opts...).Model)
+	if m.err != nil {
+		return schema.ChatResponse{}, m.err
+	}
+	return schema.ChatResponse{Choices: []schema.Choice{{Message: schema.AssistantMessage(m.name)}}}, nil
+}
+
+func (m *backendModel) ChatStream(_ context.Context, _ []schema.Message, opts ...llm.ChatOption) (llm.Stream, error) {
+	m.models = append(m.models, llm.ApplyChatOptions(opts...).Model)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &textStream{deltas: []string{m.name, "!"}}, nil
+}
+
+type textStream struct {
+	deltas []string
+	err    error
+}
+
+func (s *textStream) Recv() (schema.StreamEvent, error) {
+	if len(s.deltas) == 0 {
+		if s.err != nil {
+			return schema.StreamEvent{}, s.err
+		}
+		return schema.StreamEvent{}, io.EOF
+	}
+	d := s.deltas[0]
+	s.deltas = s.deltas[1:]
+	return schema.StreamEvent{Type: schema.StreamEventDelta, Delta: d}, nil
+}
+
+func (s *textStream) Close() error { return nil }
+
+func apiErr(status int) error {
+	return &llm.APIError{StatusCode: status, Message: http.StatusText(status)}
+}
+
+func text(resp schema.ChatResponse) string {
+	return resp.Choices[0].Message.Text()
+}
+
+func TestRouter_Failover(t *testing.T) {
+	t.Parallel()
+
+	deepseek := &backendModel{provider: llm.ProviderDeepSeek, name: "deepseek", err: apiErr(503)}
+	qwen := &backendModel{provider: llm.ProviderQwen, name: "qwen"}
+	var failed []string
+	r, err := router.New(router.Config{
+		Backends: []router.Backend{
+			{Model: deepseek},
+			{Model: qwen, Models: map[string]string{"deepseek-chat": "qwen-plus", "*": "qwen-turbo"}},
+		},
+		OnFailover: func(name string, err error) { failed = append(failed, name) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if llm.ProviderOf(r) != llm.ProviderDeepSeek {
+		t.Errorf("initial provider = %s", llm.ProviderOf(r))
+	}
+
+	resp, err := r.Chat(context.Background(), nil, llm.WithModel("deepseek-chat"))
+	if err != nil || text(resp) != "qwen" {
+		t.Fatalf("Chat() = %+v, %v", resp, err)
+	}
+	if resp.ExtraFields[router.ExtraBackend] != "qwen" || resp.ExtraFields[router.ExtraProvider] != "qwen" {
+		t.Errorf("ExtraFields = %v", resp.ExtraFields)
+	}
+	if r.LastProvider() != llm.ProviderQwen {
+		t.Errorf("LastProvider() = %s", r.LastProvider())
+	}
+	if llm.ProviderOf(r) != llm.ProviderDeepSeek {
+		t.Errorf("ProviderOf() = %s, want first backend", llm.ProviderOf(r))
+	}
+	if len(failed) != 1 || failed[0] != "deepseek" {
+		t.Errorf("OnFailover = %v", failed)
+	}
+
+	// 模型名映射："*" 匹配其余模型名，未配置映射的后端原样传递
+	if _, err := r.Chat(context.Background(), nil, llm.WithModel("other")); err != nil {
+		t.Fatal(err)
+	}
+	if got := qwen.models; len(got) != 2 || got[0] != "qwen-plus" || got[1] != "qwen-turbo" {
+		t.Errorf("qwen models = %v", got)
+	}
+	if deepseek.models[0] != "deepseek-chat" {
+		t.Errorf("deepseek models = %v", deepseek.models)
+	}
+}
+
+func TestRouter_NoFailover(t *testing.T) {
+	t.Parallel()
+
+	primary := &backendModel{provider: llm.ProviderDeepSeek, err: apiErr(400)}
+	backup := &backendModel{provider: llm.ProviderKimi}
+	r, _ := router.New(router.Config{Backends: []router.Backend{{Model: primary}, {Model: backup}}})
+
+	_, err := r.Chat(context.Background(), nil)
+	if ae, ok := llm.AsAPIError(err); !ok || ae.StatusCode != 400 || len(backup.models) != 0 {
+		t.Errorf("err = %v, backup calls = %d", err, len(backup.models))
+	}
+	// 请求本身的错误不影响健康状况
+	if h := r.Health()[0]; h.State != router.StateClosed || h.Failures != 0 {
+		t.Errorf("health = %+v", h)
+	}
+}
+
+func TestRouter_AllFailed(t *testing.T) {
+	t.Parallel()
+
+	r, _ := router.New(router.Config{Backends: []router.Backend{
+		{Name: "a", Model: &backendModel{err: apiErr(429)}},
+		{Name: "b", Model: &backendModel{err: &timeoutError{}}},
+	}})
+
+	_, err := r.Chat(context.Background(), nil)
+	var fe *router.FailoverError
+	if !errors.As(err, &fe) || len(fe.Errors) != 2 || fe.Errors[0].Backend != "a" {
+		t.Fatalf("err = %v", err)
+	}
+	if !llm.IsRetryable(err) {
+		t.Errorf("Unwrap should expose the last error: %v", err)
+	}
+}
+
+type timeoutError struct{}
+
+func (*timeoutError) Error() string   { return "i/o timeout" }
+func (*timeoutError) Timeout() bool   { return true }
+func (*timeoutError) Temporary() bool { return true }
+
+func TestRouter_CircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	primary := &backendModel{provider: llm.ProviderDeepSeek, name: "primary", err: apiErr(503)}
+	backup := &backendModel{provider: llm.ProviderOllama, name: "backup"}
+	r, _ := router.New(router.Config{
+		Backends:         []router.Backend{{Model: primary}, {Model: backup}},
+		FailureThreshold: 2,
+		Cooldown:         time.Minute,
+		Now:              func() time.Time { return now },
+	})
+
+	for range 2 {
+		if _, err := r.Chat(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := r.Health()[0]
+	if h.State != router.StateOpen || h.Failures != 2 || !h.OpenUntil.Equal(now.Add(time.Minute)) {
+		t.Fatalf("health = %+v", h)
+	}
+
+	// 熔断期间跳过 primary
+	if _, err := r.Chat(context.Background(), nil); err != nil || len(primary.models) != 2 {
+		t.Fatalf("err = %v, primary calls = %d", err, len(primary.models))
+	}
+
+	// 冷却后放行试探请求，成功则恢复
+	now = now.Add(time.Minute)
+	if r.Health()[0].State != router.StateHalfOpen {
+		t.Errorf("state = %s, want half-open", r.Health()[0].State)
+	}
+	primary.err = nil
+	resp, err := r.Chat(context.Background(), nil)
+	if err != nil || text(resp) != "primary" || r.Health()[0].State != router.StateClosed {
+		t.Errorf("Chat() = %v, %v; health = %+v", resp, err, r.Health()[0])
+	}
+}
+
+func TestRouter_Unavailable(t *testing.T) {
+	t.Parallel()
+
+	r, _ := router.New(router.Config{
+		Backends:         []router.Backend{{Model: &backendModel{provider: llm.ProviderKimi, err: apiErr(502)}}},
+		FailureThreshold: 1,
+	})
+	if _, err := r.Chat(context.Background(), nil); !llm.IsTemporary(err) {
+		t.Fatalf("first err = %v", err)
+	}
+	if _, err := r.Chat(context.Background(), nil); !errors.Is(err, router.ErrUnavailable) {
+		t.Errorf("err = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestRouter_Weighted(t *testing.T) {
+	t.Parallel()
+
+	a := &backendModel{provider: llm.ProviderQwen, name: "a"}
+	b := &backendModel{provider: llm.ProviderKimi, name: "b"}
+	r, _ := router.New(router.Config{
+		Backends: []router.Backend{{Model: a, Weight: 3}, {Model: b, Weight: 1}},
+		Strategy: router.Weighted,
+		Rand:     rand.New(rand.NewPCG(1, 2)),
+	})
+
+	for range 400 {
+		if _, err := r.Chat(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(a.models); n < 250 || n > 350 {
+		t.Errorf("a served %d of 400, want about 300", n)
+	}
+
+	// 被选中的后端失败时切换到另一个
+	a.err = apiErr(503)
+	resp, err := r.Chat(context.Background(), nil)
+	if err != nil || text(resp) != "b" {
+		t.Errorf("Chat() = %+v, %v", resp, err)
+	}
+}
+
+func TestRouter_ChatStream(t *testing.T) {
+	t.Parallel()
+
+	// 建立流失败
+	r1, _ := router.New(router.Config{Backends: []router.Backend{
+		{Model: &backendModel{provider: llm.ProviderDeepSeek, err: apiErr(429)}},
+		{Model: &backendModel{provider: llm.ProviderOllama, name: "local"}},
+	}})
+	// 建立流成功但首个事件前出错
+	r2, _ := router.New(router.Config{Backends: []router.Backend{
+		{Name: "broken", Model: streamOnly{&textStream{err: apiErr(503)}}},
+		{Model: &backendModel{provider: llm.ProviderOllama, name: "local"}},
+	}})
+
+	for _, rt := range []*router.Router{r1, r2} {
+		stream, err := rt.ChatStream(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := llm.Accumulate(stream)
+		if err != nil || text(resp) != "local!" || resp.ExtraFields[router.ExtraBackend] != "ollama" {
+			t.Errorf("Accumulate() = %+v, %v", resp, err)
+		}
+	}
+}
+
+type streamOnly struct{ s llm.Stream }
+
+func (m streamOnly) Chat(context.Context, []schema.Message, ...llm.ChatOption) (schema.ChatResponse, error) {
+	return schema.ChatResponse{}, errors.New("not implemented")
+}
+
+func (m streamOnly) ChatStream(context.Context, []schema.Message, ...llm.ChatOption) (llm.Stream, error) {
+	return m.s, nil
+}
+
+func TestNew_Errors(t *testing.T) {
+	t.Parallel()
+
+	m := &backendModel{provider: llm.ProviderQwen}
+	for _, cfg := range []router.Config{
+		{},
+		{Backends: []router.Backend{{}}},
+		{Backends: []router.Backend{{Model: m}, {Model: m}}},
+	} {
+		if _, err := router.New(cfg); err == nil {
+			t.Errorf("New(%+v) should fail", cfg)
+		}
+	}
+}
+
+// TestRouter_Usage usage 记在实际响应的后端名下，而不是第一个后端
+func TestRouter_Usage(t *testing.T) {
+	t.Parallel()
+
+	deepseek := &backendModel{provider: llm.ProviderDeepSeek, name: "deepseek", err: apiErr(503)}
+	qwen := &backendModel{provider: llm.ProviderQwen, name: "qwen"}
+	r, err := router.New(router.Config{Backends: []router.Backend{{Model: deepseek}, {Model: qwen}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker := usage.New(usage.Config{})
+	model := tracker.Wrap(r)
+	ctx := context.Background()
+
+	if _, err := model.Chat(ctx, nil, llm.WithModel("m")); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := model.ChatStream(ctx, nil, llm.WithModel("m"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := llm.Accumulate(stream); err != nil {
+		t.Fatal(err)
+	}
+
+	s := tracker.Snapshot()
+	if got := s.ByKey[usage.Key{Provider: llm.ProviderQwen, Model: "m"}].Requests; got != 2 || len(s.ByKey) != 1 {
+		t.Errorf("ByKey = %+v", s.ByKey)
+	}
+	if llm.ProviderOf(model) != llm.ProviderDeepSeek {
+		t.Errorf("ProviderOf = %s", llm.ProviderOf(model))
+	}
+}
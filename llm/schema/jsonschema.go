@@ -0,0 +1,189 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// JSONSchemaDialect JSON Schema draft 2020-12 的 $schema URI
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSON Schema 基本类型
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+	TypeNull    = "null"
+)
+
+// JSONSchema 表示一个 JSON Schema（draft 2020-12 中工具参数与结构化输出常用的子集）。
+//
+// 可由 JSONSchemaFor 从 Go 类型反射生成，也可手动构造或从 JSON 解码。
+// 布尔 schema（true / false）通过 TrueSchema / FalseSchema 表示。
+type JSONSchema struct {
+	Schema string                 `json:"$schema,omitempty"`
+	Ref    string                 `json:"$ref,omitempty"`
+	Defs   map[string]*JSONSchema `json:"$defs,omitempty"`
+
+	Type        Type   `json:"type,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Enum        []any  `json:"enum,omitempty"`
+	Const       any    `json:"const,omitempty"`
+
+	// object
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+
+	// array
+	Items    *JSONSchema `json:"items,omitempty"`
+	MinItems *int        `json:"minItems,omitempty"`
+	MaxItems *int        `json:"maxItems,omitempty"`
+
+	// number / integer
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+
+	// string
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+
+	AnyOf []*JSONSchema `json:"anyOf,omitempty"`
+	OneOf []*JSONSchema `json:"oneOf,omitempty"`
+	AllOf []*JSONSchema `json:"allOf,omitempty"`
+
+	// boolean 非 nil 时表示布尔 schema，忽略其余字段
+	boolean *bool
+	// propertyOrder 反射生成时的字段顺序，编码 properties 时优先按此顺序输出
+	propertyOrder []string
+}
+
+// TrueSchema 返回接受任意值的布尔 schema（true）
+func TrueSchema() *JSONSchema { b := true; return &JSONSchema{boolean: &b} }
+
+// FalseSchema 返回拒绝任意值的布尔 schema（false），常用于 additionalProperties
+func FalseSchema() *JSONSchema { b := false; return &JSONSchema{boolean: &b} }
+
+// IsTrue 是否为布尔 schema true
+func (s *JSONSchema) IsTrue() bool { return s != nil && s.boolean != nil && *s.boolean }
+
+// IsFalse 是否为布尔 schema false
+func (s *JSONSchema) IsFalse() bool { return s != nil && s.boolean != nil && !*s.boolean }
+
+// Type JSON Schema 的 type 关键字，单个类型编码为字符串，多个类型编码为数组
+type Type []string
+
+// Has 是否包含类型 name
+func (t Type) Has(name string) bool { return slices.Contains(t, name) }
+
+func (t Type) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *Type) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = Type{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("schema: type must be a string or an array of strings")
+	}
+	*t = many
+	return nil
+}
+
+type jsonSchemaAlias JSONSchema
+
+func (s *JSONSchema) MarshalJSON() ([]byte, error) {
+	if s.boolean != nil {
+		return json.Marshal(*s.boolean)
+	}
+
+	out := struct {
+		*jsonSchemaAlias
+		// 覆盖 jsonSchemaAlias.Properties（输出在最后），按字段声明顺序输出：模型按 schema 顺序生成字段，顺序有意义
+		Properties *orderedProperties `json:"properties,omitempty"`
+	}{jsonSchemaAlias: (*jsonSchemaAlias)(s)}
+	if len(s.Properties) > 0 {
+		out.Properties = &orderedProperties{m: s.Properties, order: s.propertyOrder}
+	}
+	return json.Marshal(out)
+}
+
+func (s *JSONSchema) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch string(data) {
+	case "true":
+		*s = *TrueSchema()
+		return nil
+	case "false":
+		*s = *FalseSchema()
+		return nil
+	}
+	var a jsonSchemaAlias
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*s = JSONSchema(a)
+	return nil
+}
+
+// orderedProperties 先按 order 输出，其余键按字母序输出
+type orderedProperties struct {
+	m     map[string]*JSONSchema
+	order []string
+}
+
+func (p *orderedProperties) MarshalJSON() ([]byte, error) {
+	keys := make([]string, 0, len(p.m))
+	for _, k := range p.order {
+		if _, ok := p.m[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	rest := make([]string, 0, len(p.m)-len(keys))
+	for k := range p.m {
+		if !slices.Contains(keys, k) {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		vb, err := json.Marshal(p.m[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
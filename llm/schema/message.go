@@ -22,32 +22,51 @@ type Message struct {
 	// ReasoningContent 推理内容（DeepSeek 等支持）
 	ReasoningContent string     `json:"reasoning_content,omitempty"`
 	ToolCalls        []ToolCall `json:"tool_calls,omitempty"`
+
+	// ReasoningBlocks 带签名的推理块（如 Anthropic 的 thinking / redacted_thinking），由 provider 在响应中填充。
+	// 将 assistant 消息加入历史时需原样保留，provider 会在下一轮请求中回传
+	ReasoningBlocks []ReasoningBlock `json:"reasoning_blocks,omitempty"`
+}
+
+// ReasoningBlock 一个需要原样回传的推理块
+type ReasoningBlock struct {
+	// Text 推理文本，加密的推理块为空
+	Text string `json:"text,omitempty"`
+
+	// Signature provider 对推理文本的签名
+	Signature string `json:"signature,omitempty"`
+
+	// Redacted 加密的推理内容（Anthropic redacted_thinking 的 data）
+	Redacted string `json:"redacted,omitempty"`
 }
 
-// ContentPart 内容片段接口
+// ContentPart 内容片段接口。
+//
+// JSON 编码为带 "type" 判别字段的对象（"text"、"image_url"、"binary"），Message 解码时据此还原具体类型
 type ContentPart interface {
 	isPart()
 }
 
 // TextContent 文本内容
 type TextContent struct {
-	Text string
+	Text string `json:"text"`
 }
 
 func (TextContent) isPart() {}
 
 // ImageURLContent 图片 URL 内容
 type ImageURLContent struct {
-	URL    string
-	Detail string
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
 }
 
 func (ImageURLContent) isPart() {}
 
 // BinaryContent 二进制内容（如 base64 编码的图片）
 type BinaryContent struct {
-	MIMEType string
-	Data     []byte
+	MIMEType string `json:"mime_type"`
+	// Data JSON 编码为 base64
+	Data []byte `json:"data"`
 }
 
 func (BinaryContent) isPart() {}
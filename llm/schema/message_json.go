@@ -0,0 +1,136 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ContentPartType ContentPart JSON 编码中的类型判别值
+type ContentPartType string
+
+const (
+	ContentPartText     ContentPartType = "text"
+	ContentPartImageURL ContentPartType = "image_url"
+	ContentPartBinary   ContentPartType = "binary"
+)
+
+type textContentAlias TextContent
+
+func (c TextContent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type ContentPartType `json:"type"`
+		textContentAlias
+	}{ContentPartText, textContentAlias(c)})
+}
+
+type imageURLContentAlias ImageURLContent
+
+func (c ImageURLContent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type ContentPartType `json:"type"`
+		imageURLContentAlias
+	}{ContentPartImageURL, imageURLContentAlias(c)})
+}
+
+type binaryContentAlias BinaryContent
+
+func (c BinaryContent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type ContentPartType `json:"type"`
+		binaryContentAlias
+	}{ContentPartBinary, binaryContentAlias(c)})
+}
+
+// UnmarshalContentPart 按 "type" 字段解码单个内容片段
+func UnmarshalContentPart(data []byte) (ContentPart, error) {
+	p, err := decodeContentPart(data)
+	if err != nil {
+		return nil, fmt.Errorf("schema: content part: %w", err)
+	}
+	return p, nil
+}
+
+func decodeContentPart(data []byte) (ContentPart, error) {
+	var head struct {
+		Type ContentPartType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, err
+	}
+
+	var (
+		part ContentPart
+		err  error
+	)
+	switch head.Type {
+	case ContentPartText:
+		var c textContentAlias
+		err = json.Unmarshal(data, &c)
+		part = TextContent(c)
+	case ContentPartImageURL:
+		var c imageURLContentAlias
+		err = json.Unmarshal(data, &c)
+		part = ImageURLContent(c)
+	case ContentPartBinary:
+		var c binaryContentAlias
+		err = json.Unmarshal(data, &c)
+		part = BinaryContent(c)
+	case "":
+		return nil, fmt.Errorf("missing type")
+	default:
+		return nil, fmt.Errorf("unknown type %q", head.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", head.Type, err)
+	}
+	return part, nil
+}
+
+type messageAlias Message
+
+// UnmarshalJSON 还原 Content 中各片段的具体类型；Content 也可以是字符串，视为单个文本片段
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		messageAlias
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	msg := Message(raw.messageAlias)
+
+	content, err := unmarshalContent(raw.Content)
+	if err != nil {
+		return err
+	}
+	msg.Content = content
+	*m = msg
+	return nil
+}
+
+func unmarshalContent(data json.RawMessage) ([]ContentPart, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	if data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return nil, err
+		}
+		return []ContentPart{TextContent{Text: text}}, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("schema: message content must be a string or an array of parts: %w", err)
+	}
+	parts := make([]ContentPart, len(items))
+	for i, item := range items {
+		p, err := decodeContentPart(item)
+		if err != nil {
+			return nil, fmt.Errorf("schema: message content[%d]: %w", i, err)
+		}
+		parts[i] = p
+	}
+	return parts, nil
+}
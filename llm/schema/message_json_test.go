@@ -0,0 +1,149 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// checkGolden 将 v 编码后与 testdata/golden/name 比较，再解码回 T 检查无损往返
+func checkGolden[T any](t *testing.T, name string, v T) {
+	t.Helper()
+
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden (run with -update to create): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch:\n got: %s\nwant: %s", name, got, want)
+	}
+
+	// 缩进会改写 Raw 等 json.RawMessage 字段，往返检查使用紧凑形式
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, want); err != nil {
+		t.Fatal(err)
+	}
+	var back T
+	if err := json.Unmarshal(compact.Bytes(), &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(back, v) {
+		t.Errorf("round trip mismatch:\n got: %#v\nwant: %#v", back, v)
+	}
+}
+
+func goldenMessages() []Message {
+	idx := 0
+	return []Message{
+		SystemMessage("你是一个助手"),
+		{
+			Role: RoleUser,
+			Name: "alice",
+			Content: []ContentPart{
+				TextContent{Text: "这张图片里有什么？"},
+				ImageURLContent{URL: "https://example.com/cat.png", Detail: "high"},
+				BinaryContent{MIMEType: "image/png", Data: []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}},
+			},
+		},
+		{
+			Role:             RoleAssistant,
+			ReasoningContent: "需要查询天气",
+			ToolCalls: []ToolCall{{
+				Index:    &idx,
+				ID:       "call_1",
+				Type:     ToolCallTypeFunction,
+				Function: ToolFunction{Name: "get_weather", Arguments: `{"city":"北京"}`},
+			}},
+		},
+		ToolResultMessage("call_1", `{"temp":22}`),
+		{Role: RoleAssistant, Content: []ContentPart{}},
+	}
+}
+
+func TestMessage_Golden(t *testing.T) {
+	checkGolden(t, "messages.json", goldenMessages())
+}
+
+func TestChatResponse_Golden(t *testing.T) {
+	tier := "default"
+	checkGolden(t, "chat_response.json", ChatResponse{
+		ID:        "chatcmpl-1",
+		Model:     "gpt-4o",
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Choices: []Choice{
+			{Index: 0, Message: goldenMessages()[2], FinishReason: FinishReasonToolCalls},
+			{Index: 1, Message: AssistantMessage("北京今天晴"), FinishReason: FinishReasonStop},
+		},
+		Usage: Usage{
+			PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30,
+			CompletionTokensDetails: &CompletionTokensDetails{ReasoningTokens: 4},
+		},
+		ServiceTier: &tier,
+		ExtraFields: map[string]any{"system_fingerprint": "fp_1", "score": 0.5},
+		Raw:         json.RawMessage(`{"id":"chatcmpl-1"}`),
+	})
+}
+
+func TestStreamEvent_Golden(t *testing.T) {
+	idx := 0
+	reason := FinishReasonStop
+	checkGolden(t, "stream_events.json", []StreamEvent{
+		{Type: StreamEventDelta, Delta: "北京", Reasoning: "想一想"},
+		{Type: StreamEventDelta, ChoiceIndex: 1, ToolCalls: []ToolCall{{Index: &idx, Function: ToolFunction{Arguments: `{"ci`}}}},
+		{Type: StreamEventDone, FinishReason: &reason, Usage: &Usage{TotalTokens: 3}, ExtraFields: map[string]any{"k": "v"}, Raw: json.RawMessage(`{"done":true}`)},
+	})
+}
+
+func TestMessage_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	var m Message
+	if err := json.Unmarshal([]byte(`{"role":"user","content":"你好"}`), &m); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m, UserMessage("你好")) {
+		t.Errorf("string content = %#v", m)
+	}
+
+	if err := json.Unmarshal([]byte(`{"role":"assistant","content":null}`), &m); err != nil || m.Content != nil {
+		t.Errorf("null content = %#v, %v", m.Content, err)
+	}
+
+	tests := map[string]string{
+		`{"role":"user","content":[{"text":"x"}]}`:                     "content[0]: missing type",
+		`{"role":"user","content":[{"type":"text"},{"type":"audio"}]}`: `content[1]: unknown type "audio"`,
+		`{"role":"user","content":[{"type":"binary","data":"%%%"}]}`:   "content[0]: binary:",
+		`{"role":"user","content":{"type":"text"}}`:                    "string or an array of parts",
+	}
+	for in, want := range tests {
+		err := json.Unmarshal([]byte(in), &m)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Unmarshal(%s) error = %v, want containing %q", in, err, want)
+		}
+	}
+
+	p, err := UnmarshalContentPart([]byte(`{"type":"image_url","url":"u"}`))
+	if err != nil || p != (ImageURLContent{URL: "u"}) {
+		t.Errorf("UnmarshalContentPart() = %#v, %v", p, err)
+	}
+}
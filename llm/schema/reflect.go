@@ -0,0 +1,483 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaOption JSON Schema 生成选项
+type SchemaOption func(*schemaGenerator)
+
+// WithStrictSchema 生成符合 OpenAI Structured Outputs "strict" 模式的 schema：
+// 所有 object 设置 additionalProperties:false，所有字段均为必填，
+// 原本可选的字段（指针、omitempty 或 required:"false"）改为可为 null。
+// strict 模式不支持 map 与 interface 类型。
+func WithStrictSchema() SchemaOption {
+	return func(g *schemaGenerator) { g.strict = true }
+}
+
+// WithSchemaDialect 在根节点输出 "$schema"（draft 2020-12）。
+// 多数 provider 的工具参数不需要该字段，默认不输出。
+func WithSchemaDialect() SchemaOption {
+	return func(g *schemaGenerator) { g.dialect = true }
+}
+
+// JSONSchemaFor 通过反射从 T 生成 JSON Schema（draft 2020-12）。
+//
+// 支持的结构体标签：
+//   - json：字段名；"-" 跳过；omitempty / omitzero 表示可选
+//   - description：字段说明
+//   - enum：逗号分隔的枚举值，按字段类型解析（切片字段作用于元素）
+//   - required："true" / "false"，覆盖默认的必填判断（非指针且无 omitempty 即为必填）
+//   - min / max：数值字段对应 minimum / maximum，字符串对应 minLength / maxLength，切片对应 minItems / maxItems
+//   - pattern：字符串正则
+//   - format：字符串格式，如 "email"、"uri"
+//
+// 嵌套结构体内联展开，递归类型通过 $defs 与 $ref 引用；time.Time 生成 date-time 格式的字符串。
+func JSONSchemaFor[T any](opts ...SchemaOption) (*JSONSchema, error) {
+	return JSONSchemaOf(reflect.TypeFor[T](), opts...)
+}
+
+// JSONSchemaOf 同 JSONSchemaFor，类型在运行时给出
+func JSONSchemaOf(t reflect.Type, opts ...SchemaOption) (*JSONSchema, error) {
+	if t == nil {
+		return nil, fmt.Errorf("schema: nil type")
+	}
+	g := &schemaGenerator{
+		root:      deref(t),
+		inStack:   make(map[reflect.Type]bool),
+		recursive: make(map[reflect.Type]bool),
+		defNames:  make(map[reflect.Type]string),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(g)
+		}
+	}
+
+	s, err := g.schemaFor(t, fieldTags{})
+	if err != nil {
+		return nil, fmt.Errorf("schema: %s: %w", t, err)
+	}
+	if len(g.defs) > 0 {
+		s.Defs = g.defs
+	}
+	if g.dialect {
+		s.Schema = JSONSchemaDialect
+	}
+	return s, nil
+}
+
+// NewFunctionToolFor 创建参数 schema 由 T 反射生成的函数调用工具，T 须为结构体或 map。
+// 使用 WithStrictSchema 时同时设置 FunctionDefinition.Strict。
+func NewFunctionToolFor[T any](name, description string, opts ...SchemaOption) (Tool, error) {
+	s, strict, err := objectSchemaFor[T](opts)
+	if err != nil {
+		return Tool{}, err
+	}
+	tool, err := NewFunctionTool(name, description, s)
+	if err != nil {
+		return Tool{}, err
+	}
+	tool.Function.Strict = strict
+	return tool, nil
+}
+
+// ResponseFormatFor 创建 json_schema 类型的 ResponseFormat，schema 由 T 反射生成，T 须为结构体或 map。
+// name 为 schema 名称（OpenAI 要求匹配 ^[a-zA-Z0-9_-]+$）。
+func ResponseFormatFor[T any](name string, opts ...SchemaOption) (ResponseFormat, error) {
+	if name == "" {
+		return ResponseFormat{}, fmt.Errorf("schema name required")
+	}
+	s, strict, err := objectSchemaFor[T](opts)
+	if err != nil {
+		return ResponseFormat{}, err
+	}
+	b, err := json.Marshal(struct {
+		Name   string      `json:"name"`
+		Schema *JSONSchema `json:"schema"`
+		Strict bool        `json:"strict,omitempty"`
+	}{name, s, strict})
+	if err != nil {
+		return ResponseFormat{}, fmt.Errorf("marshal json schema: %w", err)
+	}
+	return ResponseFormat{Type: "json_schema", JSONSchema: b}, nil
+}
+
+func objectSchemaFor[T any](opts []SchemaOption) (*JSONSchema, bool, error) {
+	var g schemaGenerator
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&g)
+		}
+	}
+	s, err := JSONSchemaFor[T](opts...)
+	if err != nil {
+		return nil, false, err
+	}
+	if !s.Type.Has(TypeObject) {
+		return nil, false, fmt.Errorf("schema: %s: root type must be a struct or map", reflect.TypeFor[T]())
+	}
+	return s, g.strict, nil
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+	byteSliceType  = reflect.TypeFor[[]byte]()
+)
+
+type schemaGenerator struct {
+	strict  bool
+	dialect bool
+
+	root      reflect.Type
+	inStack   map[reflect.Type]bool
+	recursive map[reflect.Type]bool
+	defNames  map[reflect.Type]string
+	defs      map[string]*JSONSchema
+}
+
+// fieldTags 从结构体标签解析出的约束
+type fieldTags struct {
+	description string
+	enum        string
+	min, max    string
+	pattern     string
+	format      string
+}
+
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func (g *schemaGenerator) schemaFor(t reflect.Type, tags fieldTags) (*JSONSchema, error) {
+	t = deref(t)
+
+	var s *JSONSchema
+	switch {
+	case t == timeType:
+		s = &JSONSchema{Type: Type{TypeString}, Format: "date-time"}
+	case t == rawMessageType:
+		if g.strict {
+			return nil, fmt.Errorf("json.RawMessage is not supported in strict mode")
+		}
+		s = &JSONSchema{}
+	case t == byteSliceType:
+		// encoding/json 将 []byte 编码为 base64 字符串
+		s = &JSONSchema{Type: Type{TypeString}, Format: "byte"}
+	default:
+		var err error
+		if s, err = g.kindSchema(t, tags); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := g.applyTags(s, t, tags); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (g *schemaGenerator) kindSchema(t reflect.Type, tags fieldTags) (*JSONSchema, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: Type{TypeString}}, nil
+	case reflect.Bool:
+		return &JSONSchema{Type: Type{TypeBoolean}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: Type{TypeInteger}}, nil
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: Type{TypeNumber}}, nil
+	case reflect.Slice, reflect.Array:
+		// enum 作用于元素，min / max 作用于数组长度
+		items, err := g.schemaFor(t.Elem(), fieldTags{enum: tags.enum, pattern: tags.pattern, format: tags.format})
+		if err != nil {
+			return nil, err
+		}
+		return &JSONSchema{Type: Type{TypeArray}, Items: items}, nil
+	case reflect.Map:
+		if g.strict {
+			return nil, fmt.Errorf("map type %s is not supported in strict mode", t)
+		}
+		if k := t.Key(); k.Kind() != reflect.String && !isIntKind(k.Kind()) && !k.Implements(textMarshalerType) {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := g.schemaFor(t.Elem(), fieldTags{})
+		if err != nil {
+			return nil, err
+		}
+		return &JSONSchema{Type: Type{TypeObject}, AdditionalProperties: values}, nil
+	case reflect.Interface:
+		if g.strict {
+			return nil, fmt.Errorf("interface type %s is not supported in strict mode", t)
+		}
+		return &JSONSchema{}, nil
+	case reflect.Struct:
+		return g.structRef(t)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// structRef 生成结构体 schema；递归引用的结构体放入 $defs（根类型引用 "#"）
+func (g *schemaGenerator) structRef(t reflect.Type) (*JSONSchema, error) {
+	if name, ok := g.defNames[t]; ok && g.defs[name] != nil {
+		return &JSONSchema{Ref: "#/$defs/" + name}, nil
+	}
+	if g.inStack[t] {
+		g.recursive[t] = true
+		return &JSONSchema{Ref: g.refFor(t)}, nil
+	}
+
+	g.inStack[t] = true
+	s, err := g.structSchema(t)
+	delete(g.inStack, t)
+	if err != nil {
+		return nil, err
+	}
+
+	if !g.recursive[t] || t == g.root {
+		return s, nil
+	}
+	if g.defs == nil {
+		g.defs = make(map[string]*JSONSchema)
+	}
+	name := g.defName(t)
+	g.defs[name] = s
+	return &JSONSchema{Ref: "#/$defs/" + name}, nil
+}
+
+func (g *schemaGenerator) refFor(t reflect.Type) string {
+	if t == g.root {
+		return "#"
+	}
+	return "#/$defs/" + g.defName(t)
+}
+
+func (g *schemaGenerator) defName(t reflect.Type) string {
+	if name, ok := g.defNames[t]; ok {
+		return name
+	}
+	base := t.Name()
+	if base == "" {
+		base = "Type"
+	}
+	name := base
+	for i := 2; g.nameTaken(name); i++ {
+		name = base + strconv.Itoa(i)
+	}
+	g.defNames[t] = name
+	return name
+}
+
+func (g *schemaGenerator) nameTaken(name string) bool {
+	for _, n := range g.defNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) (*JSONSchema, error) {
+	s := &JSONSchema{
+		Type:       Type{TypeObject},
+		Properties: make(map[string]*JSONSchema),
+	}
+	required := []string{}
+
+	for _, f := range reflect.VisibleFields(t) {
+		name, optional, skip := jsonField(f)
+		if skip {
+			continue
+		}
+
+		tags := fieldTags{
+			description: f.Tag.Get("description"),
+			enum:        f.Tag.Get("enum"),
+			min:         f.Tag.Get("min"),
+			max:         f.Tag.Get("max"),
+			pattern:     f.Tag.Get("pattern"),
+			format:      f.Tag.Get("format"),
+		}
+		fs, err := g.schemaFor(f.Type, tags)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		switch f.Tag.Get("required") {
+		case "true":
+			optional = false
+		case "false":
+			optional = true
+		}
+		if g.strict {
+			if optional {
+				fs = nullable(fs)
+			}
+			optional = false
+		}
+
+		if _, dup := s.Properties[name]; !dup {
+			s.propertyOrder = append(s.propertyOrder, name)
+		}
+		s.Properties[name] = fs
+		if !optional {
+			required = append(required, name)
+		}
+	}
+
+	if len(required) > 0 || g.strict {
+		s.Required = required
+	}
+	if g.strict {
+		s.AdditionalProperties = FalseSchema()
+	}
+	return s, nil
+}
+
+// jsonField 按 encoding/json 的规则解析字段名与可选性
+func jsonField(f reflect.StructField) (name string, optional, skip bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+
+	if f.Anonymous && name == "" && deref(f.Type).Kind() == reflect.Struct {
+		// 未指定名称的嵌入结构体由 VisibleFields 展开其字段
+		return "", false, true
+	}
+	if !f.IsExported() {
+		return "", false, true
+	}
+
+	if name == "" {
+		name = f.Name
+	}
+	for _, o := range strings.Split(opts, ",") {
+		if o == "omitempty" || o == "omitzero" {
+			optional = true
+		}
+	}
+	if f.Type.Kind() == reflect.Pointer {
+		optional = true
+	}
+	return name, optional, false
+}
+
+// nullable 允许 s 取 null
+func nullable(s *JSONSchema) *JSONSchema {
+	if len(s.Type) > 0 && s.Ref == "" {
+		if !s.Type.Has(TypeNull) {
+			s.Type = append(s.Type, TypeNull)
+		}
+		if len(s.Enum) > 0 {
+			s.Enum = append(s.Enum, nil)
+		}
+		return s
+	}
+	return &JSONSchema{AnyOf: []*JSONSchema{s, {Type: Type{TypeNull}}}}
+}
+
+func (g *schemaGenerator) applyTags(s *JSONSchema, t reflect.Type, tags fieldTags) error {
+	if tags.description != "" {
+		s.Description = tags.description
+	}
+	if tags.format != "" && s.Type.Has(TypeString) {
+		s.Format = tags.format
+	}
+	if tags.pattern != "" && s.Type.Has(TypeString) {
+		s.Pattern = tags.pattern
+	}
+
+	if tags.enum != "" && !s.Type.Has(TypeArray) {
+		for _, v := range strings.Split(tags.enum, ",") {
+			ev, err := parseTagValue(strings.TrimSpace(v), t)
+			if err != nil {
+				return fmt.Errorf("enum: %w", err)
+			}
+			s.Enum = append(s.Enum, ev)
+		}
+	}
+
+	for _, b := range []struct {
+		tag   string
+		isMin bool
+	}{{tags.min, true}, {tags.max, false}} {
+		if b.tag == "" {
+			continue
+		}
+		switch {
+		case s.Type.Has(TypeInteger), s.Type.Has(TypeNumber):
+			v, err := strconv.ParseFloat(b.tag, 64)
+			if err != nil {
+				return fmt.Errorf("invalid min/max %q: %w", b.tag, err)
+			}
+			if b.isMin {
+				s.Minimum = &v
+			} else {
+				s.Maximum = &v
+			}
+		case s.Type.Has(TypeString), s.Type.Has(TypeArray):
+			n, err := strconv.Atoi(b.tag)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid min/max length %q", b.tag)
+			}
+			switch {
+			case s.Type.Has(TypeString) && b.isMin:
+				s.MinLength = &n
+			case s.Type.Has(TypeString):
+				s.MaxLength = &n
+			case b.isMin:
+				s.MinItems = &n
+			default:
+				s.MaxItems = &n
+			}
+		default:
+			return fmt.Errorf("min/max is not supported for %s", t)
+		}
+	}
+	return nil
+}
+
+// parseTagValue 按 t 的类型解析标签中的值
+func parseTagValue(v string, t reflect.Type) (any, error) {
+	t = deref(t)
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return parseTagValue(v, t.Elem())
+	case reflect.String:
+		return v, nil
+	case reflect.Bool:
+		return strconv.ParseBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(v, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(v, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return nil, fmt.Errorf("enum is not supported for %s", t)
+	}
+}
+
+var textMarshalerType = reflect.TypeFor[interface{ MarshalText() ([]byte, error) }]()
+
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type reflectAddress struct {
+	City string `json:"city" description:"城市"`
+	Zip  string `json:"zip,omitempty" pattern:"^[0-9]{6}$"`
+}
+
+type reflectPerson struct {
+	Name    string            `json:"name" description:"姓名" min:"1" max:"64"`
+	Age     int               `json:"age" min:"0" max:"150"`
+	Score   float64           `json:"score,omitempty"`
+	Role    string            `json:"role" enum:"admin,user"`
+	Tags    []string          `json:"tags" enum:"a,b" max:"3"`
+	Address *reflectAddress   `json:"address"`
+	Born    time.Time         `json:"born"`
+	Labels  map[string]int    `json:"labels,omitempty"`
+	Nick    string            `json:"nick,omitempty" required:"true"`
+	Email   string            `json:"email" required:"false" format:"email"`
+	Ignored string            `json:"-"`
+	Extra   json.RawMessage   `json:"extra,omitempty"`
+	Meta    map[string]string `json:"-"`
+}
+
+func marshalString(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestJSONSchemaFor(t *testing.T) {
+	t.Parallel()
+
+	s, err := JSONSchemaFor[reflectPerson](WithSchemaDialect())
+	if err != nil {
+		t.Fatalf("JSONSchemaFor() error = %v", err)
+	}
+
+	want := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object",` +
+		`"required":["name","age","role","tags","born","nick"],"properties":{` +
+		`"name":{"type":"string","description":"姓名","minLength":1,"maxLength":64},` +
+		`"age":{"type":"integer","minimum":0,"maximum":150},` +
+		`"score":{"type":"number"},` +
+		`"role":{"type":"string","enum":["admin","user"]},` +
+		`"tags":{"type":"array","items":{"type":"string","enum":["a","b"]},"maxItems":3},` +
+		`"address":{"type":"object","required":["city"],"properties":{"city":{"type":"string","description":"城市"},"zip":{"type":"string","pattern":"^[0-9]{6}$"}}},` +
+		`"born":{"type":"string","format":"date-time"},` +
+		`"labels":{"type":"object","additionalProperties":{"type":"integer"}},` +
+		`"nick":{"type":"string"},` +
+		`"email":{"type":"string","format":"email"},` +
+		`"extra":{}}}`
+	if got := marshalString(t, s); got != want {
+		t.Errorf("schema =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJSONSchemaFor_Strict(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		Title string  `json:"title"`
+		Note  *string `json:"note"`
+		Kind  string  `json:"kind,omitempty" enum:"x,y"`
+	}
+	type order struct {
+		Items []item          `json:"items"`
+		Ship  *reflectAddress `json:"ship,omitempty"`
+	}
+
+	s, err := JSONSchemaFor[order](WithStrictSchema())
+	if err != nil {
+		t.Fatalf("JSONSchemaFor() error = %v", err)
+	}
+	want := `{"type":"object","required":["items","ship"],"additionalProperties":false,"properties":{` +
+		`"items":{"type":"array","items":{"type":"object","required":["title","note","kind"],"additionalProperties":false,"properties":{` +
+		`"title":{"type":"string"},` +
+		`"note":{"type":["string","null"]},` +
+		`"kind":{"type":["string","null"],"enum":["x","y",null]}}}},` +
+		`"ship":{"type":["object","null"],"required":["city","zip"],"additionalProperties":false,"properties":{"city":{"type":"string","description":"城市"},"zip":{"type":["string","null"],"pattern":"^[0-9]{6}$"}}}}}`
+	if got := marshalString(t, s); got != want {
+		t.Errorf("schema =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := JSONSchemaFor[struct {
+		M map[string]int `json:"m"`
+	}](WithStrictSchema()); err == nil || !strings.Contains(err.Error(), "strict") {
+		t.Errorf("map in strict mode error = %v", err)
+	}
+}
+
+type reflectNode struct {
+	Value    int            `json:"value"`
+	Children []*reflectNode `json:"children,omitempty"`
+}
+
+type reflectTree struct {
+	Root  reflectNode  `json:"root"`
+	Other *reflectNode `json:"other,omitempty"`
+}
+
+func TestJSONSchemaFor_Recursive(t *testing.T) {
+	t.Parallel()
+
+	s, err := JSONSchemaFor[reflectNode]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Properties["children"].Items.Ref; got != "#" {
+		t.Errorf("root recursion ref = %q, want #", got)
+	}
+
+	s, err = JSONSchemaFor[reflectTree]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Properties["root"].Ref != "#/$defs/reflectNode" || s.Properties["other"].Ref != "#/$defs/reflectNode" {
+		t.Errorf("refs = %q, %q", s.Properties["root"].Ref, s.Properties["other"].Ref)
+	}
+	def := s.Defs["reflectNode"]
+	if def == nil || def.Properties["children"].Items.Ref != "#/$defs/reflectNode" {
+		t.Errorf("$defs = %s", marshalString(t, s.Defs))
+	}
+}
+
+func TestJSONSchemaFor_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		typ  reflect.Type
+		want string
+	}{
+		{"chan", reflect.TypeFor[struct{ C chan int }](), "unsupported type"},
+		{"bad enum", reflect.TypeFor[struct {
+			N int `enum:"1,x"`
+		}](), "enum"},
+		{"bad min", reflect.TypeFor[struct {
+			N int `min:"abc"`
+		}](), "min/max"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := JSONSchemaOf(tt.typ)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFunctionToolFor(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		Location string `json:"location" description:"城市名称"`
+	}
+	tool, err := NewFunctionToolFor[args]("get_weather", "查询天气", WithStrictSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tool.Type != ToolTypeFunction || tool.Function.Name != "get_weather" || !tool.Function.Strict {
+		t.Errorf("tool = %+v", tool)
+	}
+	want := `{"type":"object","required":["location"],"additionalProperties":false,"properties":{"location":{"type":"string","description":"城市名称"}}}`
+	if string(tool.Function.Parameters) != want {
+		t.Errorf("parameters = %s", tool.Function.Parameters)
+	}
+
+	if _, err := NewFunctionToolFor[[]string]("bad", ""); err == nil {
+		t.Error("non-object root should fail")
+	}
+}
+
+func TestResponseFormatFor(t *testing.T) {
+	t.Parallel()
+
+	type answer struct {
+		Answer string `json:"answer"`
+	}
+	rf, err := ResponseFormatFor[answer]("answer", WithStrictSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"answer","schema":{"type":"object","required":["answer"],"additionalProperties":false,"properties":{"answer":{"type":"string"}}},"strict":true}`
+	if rf.Type != "json_schema" || string(rf.JSONSchema) != want {
+		t.Errorf("ResponseFormat = %s %s", rf.Type, rf.JSONSchema)
+	}
+}
+
+func TestJSONSchema_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	in := `{"type":["string","null"],"additionalProperties":false,"items":true,"anyOf":[{"type":"integer","minimum":1}]}`
+	var s JSONSchema
+	if err := json.Unmarshal([]byte(in), &s); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Type.Has(TypeNull) || !s.AdditionalProperties.IsFalse() || !s.Items.IsTrue() || *s.AnyOf[0].Minimum != 1 {
+		t.Errorf("decoded = %+v", s)
+	}
+	if got := marshalString(t, &s); got != `{"type":["string","null"],"additionalProperties":false,"items":true,"anyOf":[{"type":"integer","minimum":1}]}` {
+		t.Errorf("re-encoded = %s", got)
+	}
+}
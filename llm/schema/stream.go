@@ -28,6 +28,12 @@ type StreamEvent struct {
 	FinishReason *FinishReason `json:"finish_reason,omitempty"`
 	Usage        *Usage        `json:"usage,omitempty"`
 
+	// Model 响应实际使用的模型，provider 在携带 Usage 的事件上给出（可用于计价）
+	Model string `json:"model,omitempty"`
+
+	// ReasoningBlock 在推理块结束时给出的完整推理块（含签名），其文本已通过 Reasoning 增量给出
+	ReasoningBlock *ReasoningBlock `json:"reasoning_block,omitempty"`
+
 	// ExtraFields 是 provider 特定的扩展字段（通常由 stream event hook 从原始事件中提取并填充）
 	ExtraFields map[string]any `json:"extra_fields,omitempty"`
 
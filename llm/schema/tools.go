@@ -11,9 +11,16 @@ const (
 
 // ToolCall 表示模型发起的工具调用
 type ToolCall struct {
+	// Index 流式响应中工具调用片段的下标，同一调用的片段下标相同（ID 通常只出现在第一个片段中）；
+	// 非流式响应中为 nil
+	Index *int `json:"index,omitempty"`
+
 	ID       string       `json:"id"`
 	Type     ToolCallType `json:"type"`
 	Function ToolFunction `json:"function"`
+
+	// Signature provider 附在工具调用上的推理签名（如 Gemini 的 thoughtSignature），回传历史时需原样保留
+	Signature string `json:"signature,omitempty"`
 }
 
 // ToolFunction 表示要调用的函数及其参数
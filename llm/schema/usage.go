@@ -16,3 +16,23 @@ type Usage struct {
 type CompletionTokensDetails struct {
 	ReasoningTokens int `json:"reasoning_tokens,omitempty"` // 推理消耗的 token
 }
+
+// Add 返回 u 与 o 逐项相加的结果，包括缓存命中与完成 token 细分统计，不修改 u 与 o
+func (u Usage) Add(o Usage) Usage {
+	u.PromptTokens += o.PromptTokens
+	u.CompletionTokens += o.CompletionTokens
+	u.TotalTokens += o.TotalTokens
+	u.PromptCacheHitTokens += o.PromptCacheHitTokens
+	u.PromptCacheMissTokens += o.PromptCacheMissTokens
+	if u.CompletionTokensDetails != nil || o.CompletionTokensDetails != nil {
+		var d CompletionTokensDetails
+		if u.CompletionTokensDetails != nil {
+			d = *u.CompletionTokensDetails
+		}
+		if o.CompletionTokensDetails != nil {
+			d.ReasoningTokens += o.CompletionTokensDetails.ReasoningTokens
+		}
+		u.CompletionTokensDetails = &d
+	}
+	return u
+}
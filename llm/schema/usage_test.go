@@ -0,0 +1,34 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestUsageAdd 测试 Usage.Add 累加全部字段且不修改原值
+func TestUsageAdd(t *testing.T) {
+	t.Parallel()
+
+	a := Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3, PromptCacheHitTokens: 4}
+	b := Usage{
+		PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30,
+		PromptCacheHitTokens: 40, PromptCacheMissTokens: 50,
+		CompletionTokensDetails: &CompletionTokensDetails{ReasoningTokens: 6},
+	}
+
+	got := a.Add(b).Add(b)
+	want := Usage{
+		PromptTokens: 21, CompletionTokens: 42, TotalTokens: 63,
+		PromptCacheHitTokens: 84, PromptCacheMissTokens: 100,
+		CompletionTokensDetails: &CompletionTokensDetails{ReasoningTokens: 12},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Add() = %+v, want %+v", got, want)
+	}
+	if a.CompletionTokensDetails != nil || b.CompletionTokensDetails.ReasoningTokens != 6 {
+		t.Errorf("operands modified: a = %+v, b = %+v", a, b)
+	}
+	if got := a.Add(Usage{}); got.CompletionTokensDetails != nil {
+		t.Errorf("Add() without details = %+v", got.CompletionTokensDetails)
+	}
+}
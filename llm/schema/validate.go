@@ -0,0 +1,481 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError 一个校验失败的位置与原因
+type FieldError struct {
+	// Path 失败位置，"$" 表示根，如 "$.items[0].name"
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) String() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError JSON 值不符合 schema，Errors 按出现顺序列出全部失败位置
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.String()
+	}
+	return "schema validation failed: " + strings.Join(msgs, "; ")
+}
+
+// ValidateJSON 解码 data 并按 s 校验，data 不是合法 JSON 时返回解码错误
+func (s *JSONSchema) ValidateJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if dec.More() {
+		return fmt.Errorf("invalid JSON: unexpected data after top-level value")
+	}
+	return s.Validate(v)
+}
+
+// Validate 按 s 校验已解码的 JSON 值（map[string]any、[]any、string、float64 / json.Number、bool、nil），
+// 不符合时返回 *ValidationError。
+//
+// 支持 type、properties、required、additionalProperties、items、enum、const、pattern、
+// 数值（含 exclusiveMinimum / exclusiveMaximum）与长度范围、$ref 以及 anyOf / oneOf / allOf；
+// 数值也可以是 Go 的整数与浮点类型。
+func (s *JSONSchema) Validate(v any) error {
+	vr := validator{root: s}
+	vr.validate(s, v, "$")
+	if len(vr.errs) > 0 {
+		return &ValidationError{Errors: vr.errs}
+	}
+	return nil
+}
+
+// ToolResult 返回可作为工具结果消息发送给模型的 JSON，列出每个失败位置，便于模型修正参数后重新调用
+func (e *ValidationError) ToolResult() string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(struct {
+		Error  string       `json:"error"`
+		Errors []FieldError `json:"errors"`
+	}{"arguments do not match the tool's parameters schema", e.Errors})
+	if err != nil {
+		return e.Error()
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// ValidateAgainst 按 tool 的参数 schema 校验 c 的参数。
+//
+// 参数不合法（包括不是合法 JSON）时返回 *ValidationError，可通过 ToolResult 反馈给模型；
+// 函数名不一致或 tool 的参数 schema 无法解析时返回普通错误。tool 未定义参数 schema 时不做校验。
+func (c ToolCall) ValidateAgainst(tool Tool) error {
+	if c.Function.Name != tool.Function.Name {
+		return fmt.Errorf("schema: tool call %q does not match tool %q", c.Function.Name, tool.Function.Name)
+	}
+	if len(bytes.TrimSpace(tool.Function.Parameters)) == 0 {
+		return nil
+	}
+	var s JSONSchema
+	if err := json.Unmarshal(tool.Function.Parameters, &s); err != nil {
+		return fmt.Errorf("schema: tool %q: invalid parameters schema: %w", tool.Function.Name, err)
+	}
+
+	// 部分模型对无参数的工具返回空字符串
+	args := strings.TrimSpace(c.Function.Arguments)
+	if args == "" {
+		args = "{}"
+	}
+	err := s.ValidateJSON([]byte(args))
+	var ve *ValidationError
+	if err != nil && !errors.As(err, &ve) {
+		return &ValidationError{Errors: []FieldError{{Path: "$", Message: err.Error()}}}
+	}
+	return err
+}
+
+type validator struct {
+	root *JSONSchema
+	errs []FieldError
+	// depth 防止 $ref 循环导致无限递归
+	depth int
+}
+
+const maxValidateDepth = 256
+
+func (vr *validator) fail(path, format string, args ...any) {
+	vr.errs = append(vr.errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (vr *validator) validate(s *JSONSchema, v any, path string) {
+	if s == nil || s.IsTrue() {
+		return
+	}
+	if s.IsFalse() {
+		vr.fail(path, "no value is allowed here")
+		return
+	}
+
+	vr.depth++
+	defer func() { vr.depth-- }()
+	if vr.depth > maxValidateDepth {
+		vr.fail(path, "schema nesting too deep")
+		return
+	}
+
+	if s.Ref != "" {
+		target, err := vr.resolve(s.Ref)
+		if err != nil {
+			vr.fail(path, "%v", err)
+			return
+		}
+		vr.validate(target, v, path)
+	}
+
+	if len(s.Type) > 0 && !typeMatches(s.Type, v) {
+		vr.fail(path, "expected %s, got %s", strings.Join(s.Type, " or "), jsonTypeName(v))
+		return
+	}
+	if len(s.Enum) > 0 && !slicesContainsJSON(s.Enum, v) {
+		vr.fail(path, "must be one of %s", formatValues(s.Enum))
+	}
+	if s.Const != nil && !jsonEqual(s.Const, v) {
+		vr.fail(path, "must be %s", formatValues([]any{s.Const}))
+	}
+
+	switch x := v.(type) {
+	case map[string]any:
+		vr.validateObject(s, x, path)
+	case []any:
+		vr.validateArray(s, x, path)
+	case string:
+		vr.validateString(s, x, path)
+	case bool, nil:
+	default:
+		if f, ok := toFloat(v); ok {
+			vr.validateNumber(s, f, path)
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		vr.validate(sub, v, path)
+	}
+	if len(s.AnyOf) > 0 {
+		vr.validateAnyOf(s.AnyOf, v, path)
+	}
+	if len(s.OneOf) > 0 {
+		vr.validateOneOf(s.OneOf, v, path)
+	}
+}
+
+func (vr *validator) resolve(ref string) (*JSONSchema, error) {
+	if ref == "#" {
+		return vr.root, nil
+	}
+	if name, ok := strings.CutPrefix(ref, "#/$defs/"); ok {
+		if d := vr.root.Defs[name]; d != nil {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("unresolvable $ref %q", ref)
+}
+
+func (vr *validator) validateObject(s *JSONSchema, obj map[string]any, path string) {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			vr.fail(joinPath(path, name), "required property is missing")
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if ps, ok := s.Properties[k]; ok {
+			vr.validate(ps, obj[k], joinPath(path, k))
+			continue
+		}
+		if ap := s.AdditionalProperties; ap != nil {
+			if ap.IsFalse() {
+				vr.fail(joinPath(path, k), "unexpected property")
+				continue
+			}
+			vr.validate(ap, obj[k], joinPath(path, k))
+		}
+	}
+}
+
+func (vr *validator) validateArray(s *JSONSchema, arr []any, path string) {
+	if s.MinItems != nil && len(arr) < *s.MinItems {
+		vr.fail(path, "must have at least %d items, got %d", *s.MinItems, len(arr))
+	}
+	if s.MaxItems != nil && len(arr) > *s.MaxItems {
+		vr.fail(path, "must have at most %d items, got %d", *s.MaxItems, len(arr))
+	}
+	if s.Items != nil {
+		for i, item := range arr {
+			vr.validate(s.Items, item, path+"["+strconv.Itoa(i)+"]")
+		}
+	}
+}
+
+func (vr *validator) validateString(s *JSONSchema, str string, path string) {
+	n := utf8.RuneCountInString(str)
+	if s.MinLength != nil && n < *s.MinLength {
+		vr.fail(path, "must be at least %d characters, got %d", *s.MinLength, n)
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		vr.fail(path, "must be at most %d characters, got %d", *s.MaxLength, n)
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			vr.fail(path, "invalid pattern %q in schema: %v", s.Pattern, err)
+		} else if !re.MatchString(str) {
+			vr.fail(path, "must match pattern %q", s.Pattern)
+		}
+	}
+}
+
+func (vr *validator) validateNumber(s *JSONSchema, f float64, path string) {
+	if s.Minimum != nil && f < *s.Minimum {
+		vr.fail(path, "must be >= %s", formatNumber(*s.Minimum))
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		vr.fail(path, "must be <= %s", formatNumber(*s.Maximum))
+	}
+	if s.ExclusiveMinimum != nil && f <= *s.ExclusiveMinimum {
+		vr.fail(path, "must be > %s", formatNumber(*s.ExclusiveMinimum))
+	}
+	if s.ExclusiveMaximum != nil && f >= *s.ExclusiveMaximum {
+		vr.fail(path, "must be < %s", formatNumber(*s.ExclusiveMaximum))
+	}
+}
+
+func (vr *validator) validateAnyOf(schemas []*JSONSchema, v any, path string) {
+	var firstErrs []FieldError
+	for i, sub := range schemas {
+		errs := vr.sub(sub, v, path)
+		if len(errs) == 0 {
+			return
+		}
+		if i == 0 {
+			firstErrs = errs
+		}
+	}
+	// 通常第一个分支是"主要"形态（如可为 null 的对象），给出其失败原因更有帮助
+	vr.fail(path, "does not match any of the allowed schemas")
+	vr.errs = append(vr.errs, firstErrs...)
+}
+
+func (vr *validator) validateOneOf(schemas []*JSONSchema, v any, path string) {
+	var (
+		matched   []int
+		firstErrs []FieldError
+	)
+	for i, sub := range schemas {
+		errs := vr.sub(sub, v, path)
+		if len(errs) == 0 {
+			matched = append(matched, i)
+		} else if firstErrs == nil {
+			firstErrs = errs
+		}
+	}
+	switch len(matched) {
+	case 1:
+	case 0:
+		vr.fail(path, "does not match any of the allowed schemas")
+		vr.errs = append(vr.errs, firstErrs...)
+	default:
+		vr.fail(path, "must match exactly one schema in oneOf, but matches %d (indexes %v)", len(matched), matched)
+	}
+}
+
+// sub 在独立的错误列表中校验，用于 anyOf / oneOf
+func (vr *validator) sub(s *JSONSchema, v any, path string) []FieldError {
+	child := validator{root: vr.root, depth: vr.depth}
+	child.validate(s, v, path)
+	return child.errs
+}
+
+func typeMatches(types Type, v any) bool {
+	for _, t := range types {
+		switch t {
+		case TypeNull:
+			if v == nil {
+				return true
+			}
+		case TypeBoolean:
+			if _, ok := v.(bool); ok {
+				return true
+			}
+		case TypeString:
+			if _, ok := v.(string); ok {
+				return true
+			}
+		case TypeObject:
+			if _, ok := v.(map[string]any); ok {
+				return true
+			}
+		case TypeArray:
+			if _, ok := v.([]any); ok {
+				return true
+			}
+		case TypeNumber:
+			if _, ok := toFloat(v); ok {
+				return true
+			}
+		case TypeInteger:
+			if f, ok := toFloat(v); ok && f == math.Trunc(f) && !math.IsInf(f, 0) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonTypeName(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	default:
+		if f, ok := toFloat(x); ok {
+			if f == math.Trunc(f) {
+				return "integer"
+			}
+			return "number"
+		}
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func toFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case json.Number:
+		f, err := x.Float64()
+		return f, err == nil
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case uint:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case uint32:
+		return float64(x), true
+	}
+	return 0, false
+}
+
+// jsonEqual 按 JSON 语义比较两个值（数字按数值比较）
+func jsonEqual(a, b any) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, xv := range x {
+			yv, ok := y[k]
+			if !ok || !jsonEqual(xv, yv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func slicesContainsJSON(values []any, v any) bool {
+	for _, e := range values {
+		if jsonEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValues(values []any) string {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Sprint(values)
+	}
+	if len(values) == 1 {
+		return string(b[1 : len(b)-1])
+	}
+	return string(b)
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// joinPath 追加属性名，非标识符形式的名称使用 ["..."] 表示
+func joinPath(path, name string) string {
+	if isIdentifier(name) {
+		return path + "." + name
+	}
+	return path + "[" + strconv.Quote(name) + "]"
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r == '_' || r == '$' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || (i > 0 && '0' <= r && r <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
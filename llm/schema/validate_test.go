@@ -0,0 +1,233 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestJSONSchema_ValidateJSON(t *testing.T) {
+	t.Parallel()
+
+	s, err := JSONSchemaFor[reflectPerson]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want []FieldError
+	}{
+		{
+			name: "valid",
+			in:   `{"name":"张三","age":30,"role":"admin","tags":["a"],"born":"2000-01-01T00:00:00Z","nick":"z","labels":{"x":1}}`,
+		},
+		{
+			name: "type, enum and bounds",
+			in:   `{"name":"","age":200.5,"role":"root","tags":["a","c","b","a"],"born":"x","nick":"z","address":{"zip":"12"}}`,
+			want: []FieldError{
+				{"$.address.city", "required property is missing"},
+				{"$.address.zip", `must match pattern "^[0-9]{6}$"`},
+				{"$.age", "expected integer, got number"},
+				{"$.name", "must be at least 1 characters, got 0"},
+				{"$.role", `must be one of ["admin","user"]`},
+				{"$.tags", "must have at most 3 items, got 4"},
+				{"$.tags[1]", `must be one of ["a","b"]`},
+			},
+		},
+		{
+			name: "missing and map values",
+			in:   `{"labels":{"a b":"x"}}`,
+			want: []FieldError{
+				{"$.name", "required property is missing"},
+				{"$.age", "required property is missing"},
+				{"$.role", "required property is missing"},
+				{"$.tags", "required property is missing"},
+				{"$.born", "required property is missing"},
+				{"$.nick", "required property is missing"},
+				{`$.labels["a b"]`, "expected integer, got string"},
+			},
+		},
+		{
+			name: "root type",
+			in:   `[1]`,
+			want: []FieldError{{"$", "expected object, got array"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := s.ValidateJSON([]byte(tt.in))
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("ValidateJSON() error = %v", err)
+				}
+				return
+			}
+			var ve *ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("ValidateJSON() error = %v, want *ValidationError", err)
+			}
+			if !reflect.DeepEqual(ve.Errors, tt.want) {
+				t.Errorf("errors =\n%v\nwant\n%v", ve.Errors, tt.want)
+			}
+		})
+	}
+
+	if err := s.ValidateJSON([]byte(`{"name":`)); err == nil {
+		t.Error("truncated JSON should fail")
+	}
+	if err := s.ValidateJSON([]byte(`{} {}`)); err == nil {
+		t.Error("trailing data should fail")
+	}
+}
+
+func TestJSONSchema_ValidateStrictAndRecursive(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		Title string  `json:"title"`
+		Note  *string `json:"note"`
+	}
+	type order struct {
+		Items []item `json:"items"`
+	}
+	s, err := JSONSchemaFor[order](WithStrictSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ValidateJSON([]byte(`{"items":[{"title":"a","note":null}]}`)); err != nil {
+		t.Errorf("valid strict value: %v", err)
+	}
+	err = s.ValidateJSON([]byte(`{"items":[{"title":"a","note":null,"x":1}]}`))
+	if err == nil || err.Error() != "schema validation failed: $.items[0].x: unexpected property" {
+		t.Errorf("additionalProperties error = %v", err)
+	}
+
+	tree, err := JSONSchemaFor[reflectTree]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tree.ValidateJSON([]byte(`{"root":{"value":1,"children":[{"value":"x"}]}}`))
+	if err == nil || err.Error() != "schema validation failed: $.root.children[0].value: expected integer, got string" {
+		t.Errorf("$ref error = %v", err)
+	}
+}
+
+func TestJSONSchema_ValidateAnyOf(t *testing.T) {
+	t.Parallel()
+
+	low := 1.0
+	s := &JSONSchema{AnyOf: []*JSONSchema{
+		{Type: Type{TypeInteger}, Minimum: &low},
+		{Type: Type{TypeNull}},
+	}}
+	for _, v := range []any{nil, 3.0} {
+		if err := s.Validate(v); err != nil {
+			t.Errorf("Validate(%v) = %v", v, err)
+		}
+	}
+	err := s.Validate(0.0)
+	if err == nil || err.Error() != "schema validation failed: $: does not match any of the allowed schemas; $: must be >= 1" {
+		t.Errorf("Validate(0) = %v", err)
+	}
+}
+
+func TestJSONSchema_ValidateOneOfAllOf(t *testing.T) {
+	t.Parallel()
+
+	var s JSONSchema
+	if err := json.Unmarshal([]byte(`{"oneOf":[{"type":"integer"},{"type":"number","maximum":10}],"allOf":[{"minimum":0}]}`), &s); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`20`, ""},
+		{`2.5`, ""},
+		{`-1.5`, "schema validation failed: $: must be >= 0"},
+		{`3`, "schema validation failed: $: must match exactly one schema in oneOf, but matches 2 (indexes [0 1])"},
+		{`"x"`, "schema validation failed: $: does not match any of the allowed schemas; $: expected integer, got string"},
+	}
+	for _, tt := range tests {
+		err := s.ValidateJSON([]byte(tt.in))
+		if got := errString(err); got != tt.want {
+			t.Errorf("ValidateJSON(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestToolCall_ValidateAgainst(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		City string `json:"city"`
+		Days int    `json:"days,omitempty" min:"1" max:"7"`
+	}
+	tool, err := NewFunctionToolFor[args]("get_weather", "", WithStrictSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(args string) ToolCall {
+		return ToolCall{ID: "c1", Type: ToolCallTypeFunction, Function: ToolFunction{Name: "get_weather", Arguments: args}}
+	}
+
+	if err := call(`{"city":"北京","days":3}`).ValidateAgainst(tool); err != nil {
+		t.Errorf("valid arguments: %v", err)
+	}
+
+	var ve *ValidationError
+	err = call(`{"days":9,"unit":"c"}`).ValidateAgainst(tool)
+	if !errors.As(err, &ve) {
+		t.Fatalf("err = %v, want *ValidationError", err)
+	}
+	want := `{"error":"arguments do not match the tool's parameters schema","errors":[` +
+		`{"path":"$.city","message":"required property is missing"},` +
+		`{"path":"$.days","message":"must be <= 7"},` +
+		`{"path":"$.unit","message":"unexpected property"}]}`
+	if got := ve.ToolResult(); got != want {
+		t.Errorf("ToolResult() =\n%s\nwant\n%s", got, want)
+	}
+
+	if err := call(`{"city":`).ValidateAgainst(tool); !errors.As(err, &ve) || ve.Errors[0].Path != "$" {
+		t.Errorf("malformed arguments err = %v", err)
+	}
+	if err := call(``).ValidateAgainst(tool); !errors.As(err, &ve) || ve.Errors[0].Path != "$.city" {
+		t.Errorf("empty arguments err = %v", err)
+	}
+
+	other := call(`{}`)
+	other.Function.Name = "other"
+	if err := other.ValidateAgainst(tool); err == nil || errors.As(err, &ve) {
+		t.Errorf("name mismatch err = %v", err)
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func TestJSONSchema_ValidateConstAndExclusive(t *testing.T) {
+	t.Parallel()
+
+	lo, hi := 0.0, 10.0
+	s := &JSONSchema{Type: Type{TypeObject}, Properties: map[string]*JSONSchema{
+		"kind":  {Const: "point"},
+		"ratio": {Type: Type{TypeNumber}, ExclusiveMinimum: &lo, ExclusiveMaximum: &hi},
+	}}
+	if err := s.Validate(map[string]any{"kind": "point", "ratio": 5}); err != nil {
+		t.Errorf("Validate(valid) = %v", err)
+	}
+	err := s.Validate(map[string]any{"kind": "line", "ratio": int64(10)})
+	want := `schema validation failed: $.kind: must be "point"; $.ratio: must be < 10`
+	if err == nil || err.Error() != want {
+		t.Errorf("Validate() = %v, want %s", err, want)
+	}
+}
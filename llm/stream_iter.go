@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"sync"
+
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// StreamResult EventsChan 传递的元素，Err 非 nil 时为最后一个元素
+type StreamResult struct {
+	Event schema.StreamEvent
+	Err   error
+}
+
+// Events 将 stream 适配为迭代器：
+//
+//	for ev, err := range llm.Events(ctx, stream) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Print(ev.Delta)
+//	}
+//
+// 正常结束（io.EOF）时迭代直接结束，不产生错误；出错时产生一次 (零值, err) 后结束。
+// 不携带任何内容的结束事件（如 OpenAI 的 [DONE]）会被跳过，带 FinishReason / Usage 的 Done 事件照常产生。
+//
+// 迭代结束、提前 break 或 ctx 取消时都会关闭 stream；ctx 取消时立即关闭 stream 以中断阻塞中的 Recv，
+// 随后产生一次 (零值, ctx.Err())。
+func Events(ctx context.Context, stream Stream) iter.Seq2[schema.StreamEvent, error] {
+	return func(yield func(schema.StreamEvent, error) bool) {
+		var once sync.Once
+		closeStream := func() { once.Do(func() { _ = stream.Close() }) }
+		defer closeStream()
+		stop := context.AfterFunc(ctx, closeStream)
+		defer stop()
+
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(schema.StreamEvent{}, err)
+				return
+			}
+			ev, err := stream.Recv()
+			if err != nil && ctx.Err() != nil {
+				// Recv 被关闭中断后返回的错误（或 io.EOF）统一报告为 ctx 的错误
+				err = ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(schema.StreamEvent{}, err)
+				return
+			}
+			if isEmptyDone(ev) {
+				continue
+			}
+			if !yield(ev, nil) {
+				return
+			}
+		}
+	}
+}
+
+// EventsChan 在新的 goroutine 中读取 stream，并通过容量为 buffer 的 channel 发送事件。
+//
+// 事件过滤规则与 Events 相同；出错时发送一个 Err 非 nil 的元素，随后关闭 channel。
+// ctx 取消时 goroutine 关闭 stream 后退出，此时消费者可能已停止读取，因此不保证能收到携带 ctx 错误的元素，
+// channel 关闭后应检查 ctx.Err() 区分正常结束与取消。调用方提前停止消费时应取消 ctx。
+func EventsChan(ctx context.Context, stream Stream, buffer int) <-chan StreamResult {
+	ch := make(chan StreamResult, max(buffer, 0))
+	go func() {
+		defer close(ch)
+		for ev, err := range Events(ctx, stream) {
+			select {
+			case ch <- StreamResult{Event: ev, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// isEmptyDone 判断是否为不携带任何内容、仅表示流结束的事件
+func isEmptyDone(ev schema.StreamEvent) bool {
+	return ev.Type == schema.StreamEventDone &&
+		ev.FinishReason == nil &&
+		ev.Usage == nil &&
+		ev.Delta == "" &&
+		ev.Reasoning == "" &&
+		len(ev.ToolCalls) == 0 &&
+		len(ev.ExtraFields) == 0
+}
@@ -0,0 +1,347 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// sliceStream 依次返回 events，之后返回 err（默认 io.EOF）
+type sliceStream struct {
+	mu     sync.Mutex
+	events []schema.StreamEvent
+	err    error
+	recvs  atomic.Int32
+	closed atomic.Bool
+}
+
+func newSliceStream(deltas ...string) *sliceStream {
+	s := &sliceStream{}
+	for _, d := range deltas {
+		s.events = append(s.events, schema.StreamEvent{Type: schema.StreamEventDelta, Delta: d})
+	}
+	return s
+}
+
+func (s *sliceStream) Recv() (schema.StreamEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recvs.Add(1)
+	if len(s.events) == 0 {
+		if s.err != nil {
+			return schema.StreamEvent{}, s.err
+		}
+		return schema.StreamEvent{}, io.EOF
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func (s *sliceStream) Close() error {
+	s.closed.Store(true)
+	return nil
+}
+
+// blockingStream 的 Recv 一直阻塞到 Close 被调用
+type blockingStream struct {
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newBlockingStream() *blockingStream {
+	return &blockingStream{closed: make(chan struct{})}
+}
+
+func (s *blockingStream) Recv() (schema.StreamEvent, error) {
+	<-s.closed
+	return schema.StreamEvent{}, io.EOF
+}
+
+func (s *blockingStream) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+func TestEvents(t *testing.T) {
+	t.Parallel()
+
+	stop := schema.FinishReasonStop
+	s := newSliceStream("a", "b")
+	s.events = append(s.events,
+		schema.StreamEvent{Type: schema.StreamEventDone, FinishReason: &stop},
+		schema.StreamEvent{Type: schema.StreamEventDone},
+	)
+
+	var got []schema.StreamEvent
+	for ev, err := range llm.Events(context.Background(), s) {
+		if err != nil {
+			t.Fatalf("err = %v", err)
+		}
+		got = append(got, ev)
+	}
+	if len(got) != 3 || got[0].Delta != "a" || got[1].Delta != "b" || got[2].FinishReason == nil {
+		t.Errorf("events = %+v", got)
+	}
+	if !s.closed.Load() {
+		t.Error("stream not closed")
+	}
+}
+
+func TestEvents_BreakAndError(t *testing.T) {
+	t.Parallel()
+
+	s := newSliceStream("a", "b", "c")
+	for range llm.Events(context.Background(), s) {
+		break
+	}
+	if !s.closed.Load() || s.recvs.Load() != 1 {
+		t.Errorf("closed = %v, recvs = %d after break", s.closed.Load(), s.recvs.Load())
+	}
+
+	wantErr := errors.New("boom")
+	s = newSliceStream("a")
+	s.err = wantErr
+	var errs []error
+	for _, err := range llm.Events(context.Background(), s) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 2 || errs[0] != nil || !errors.Is(errs[1], wantErr) {
+		t.Errorf("errs = %v", errs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s = newSliceStream("a")
+	for _, err := range llm.Events(ctx, s) {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	}
+	if s.recvs.Load() != 0 || !s.closed.Load() {
+		t.Errorf("canceled ctx: recvs = %d, closed = %v", s.recvs.Load(), s.closed.Load())
+	}
+}
+
+func TestEvents_CancelInterruptsRecv(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var errs []error
+	for _, err := range llm.Events(ctx, newBlockingStream()) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], context.DeadlineExceeded) {
+		t.Errorf("errs = %v, want [context.DeadlineExceeded]", errs)
+	}
+}
+
+func TestEventsChan(t *testing.T) {
+	t.Parallel()
+
+	s := newSliceStream("a", "b")
+	var text string
+	for r := range llm.EventsChan(context.Background(), s, 1) {
+		if r.Err != nil {
+			t.Fatalf("err = %v", r.Err)
+		}
+		text += r.Event.Delta
+	}
+	if text != "ab" || !s.closed.Load() {
+		t.Errorf("text = %q, closed = %v", text, s.closed.Load())
+	}
+
+	// 消费方取消 ctx 后 goroutine 退出并关闭 stream
+	ctx, cancel := context.WithCancel(context.Background())
+	s = newSliceStream("a", "b", "c", "d")
+	ch := llm.EventsChan(ctx, s, 0)
+	<-ch
+	cancel()
+	for range ch {
+	}
+	if !s.closed.Load() {
+		t.Error("stream not closed after cancel")
+	}
+}
+
+func TestEventsChan_Unbuffered(t *testing.T) {
+	t.Parallel()
+
+	// 非 ctx 错误总会作为最后一个元素发送
+	s := newSliceStream("a")
+	s.err = errors.New("upstream")
+	var got []llm.StreamResult
+	for r := range llm.EventsChan(context.Background(), s, 0) {
+		got = append(got, r)
+	}
+	if len(got) != 2 || got[0].Event.Delta != "a" || got[1].Err == nil || got[1].Err.Error() != "upstream" {
+		t.Errorf("results = %+v", got)
+	}
+
+	// ctx 取消后阻塞中的 Recv 被中断，channel 关闭；若收到元素，其 Err 为 ctx 的错误
+	ctx, cancel := context.WithCancel(context.Background())
+	bs := newBlockingStream()
+	ch := llm.EventsChan(ctx, bs, 0)
+	cancel()
+	for r := range ch {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("result after cancel = %+v", r)
+		}
+	}
+	if ctx.Err() == nil {
+		t.Error("ctx not canceled")
+	}
+}
+
+func TestTee(t *testing.T) {
+	t.Parallel()
+
+	s := newSliceStream("a", "b", "c")
+	s.err = errors.New("upstream")
+	branches := llm.Tee(context.Background(), s, 2, 1)
+
+	var wg sync.WaitGroup
+	results := make([]string, len(branches))
+	errs := make([]error, len(branches))
+	for i, b := range branches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer b.Close()
+			for {
+				ev, err := b.Recv()
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				results[i] += ev.Delta
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range branches {
+		if results[i] != "abc" || errs[i] == nil || errs[i].Error() != "upstream" {
+			t.Errorf("branch %d: text = %q, err = %v", i, results[i], errs[i])
+		}
+	}
+	if !s.closed.Load() {
+		t.Error("source not closed")
+	}
+}
+
+func TestTee_Backpressure(t *testing.T) {
+	t.Parallel()
+
+	s := newSliceStream("1", "2", "3", "4", "5", "6", "7", "8")
+	branches := llm.Tee(context.Background(), s, 2, 2)
+	fast, slow := branches[0], branches[1]
+
+	// slow 不读取：fast 最多读到 buffer 个事件后，源读取被阻塞
+	for range 2 {
+		if _, err := fast.Recv(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := s.recvs.Load(); n > 4 {
+		t.Errorf("source Recv called %d times while slow branch is full", n)
+	}
+
+	// 关闭慢分支后不再参与背压
+	slow.Close()
+	var text string
+	for {
+		ev, err := fast.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		text += ev.Delta
+	}
+	if len(text) != 6 {
+		t.Errorf("fast branch rest = %q", text)
+	}
+	if _, err := slow.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("closed branch Recv err = %v, want io.EOF", err)
+	}
+}
+
+func TestTee_ContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := newSliceStream("a", "b", "c")
+	branches := llm.Tee(ctx, s, 1, 0)
+	if ev, err := branches[0].Recv(); err != nil || ev.Delta != "a" {
+		t.Fatalf("Recv() = %+v, %v", ev, err)
+	}
+	cancel()
+
+	var err error
+	for err == nil {
+		_, err = branches[0].Recv()
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestTee_InvalidN(t *testing.T) {
+	t.Parallel()
+
+	s := newSliceStream("a")
+	for _, n := range []int{0, -1} {
+		if got := llm.Tee(context.Background(), s, n, 1); got != nil {
+			t.Errorf("Tee(n=%d) = %v, want nil", n, got)
+		}
+	}
+	if s.recvs.Load() != 0 || s.closed.Load() {
+		t.Errorf("source touched: recvs = %d, closed = %v", s.recvs.Load(), s.closed.Load())
+	}
+}
+
+func TestTee_CancelInterruptsRecv(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bs := newBlockingStream()
+	branches := llm.Tee(ctx, bs, 1, 0)
+	cancel()
+	if _, err := branches[0].Recv(); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+// TestTee_CloseAllBranchesClosesSource 全部分支关闭时立即关闭源 stream，不等待阻塞中的 Recv 返回
+func TestTee_CloseAllBranchesClosesSource(t *testing.T) {
+	t.Parallel()
+
+	bs := newBlockingStream()
+	branches := llm.Tee(context.Background(), bs, 2, 0)
+	_ = branches[0].Close()
+	select {
+	case <-bs.closed:
+		t.Fatal("source closed while a branch is still open")
+	default:
+	}
+	_ = branches[1].Close()
+	_ = branches[1].Close()
+	select {
+	case <-bs.closed:
+	case <-time.After(time.Second):
+		t.Fatal("source not closed after all branches closed")
+	}
+}
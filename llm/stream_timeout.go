@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStreamTimeout 流式响应超时（空闲超时或首 token 超时），可用 errors.Is 判断
+var ErrStreamTimeout = errors.New("llm: stream timeout")
+
+// StreamTimeoutError 流式响应因 WithStreamIdleTimeout 或 WithFirstTokenTimeout 被中止。
+//
+// 实现 net.Error 且 Timeout() 为 true，因此 IsRetryable 视为可重试；
+// 与调用方 context 的超时不同，errors.Is(err, context.DeadlineExceeded) 为 false
+type StreamTimeoutError struct {
+	Provider Provider
+
+	// FirstToken 为 true 表示首 token 超时，否则为空闲超时
+	FirstToken bool
+
+	// Duration 触发的超时时间
+	Duration time.Duration
+}
+
+func (e *StreamTimeoutError) Error() string {
+	if e.FirstToken {
+		return fmt.Sprintf("%s: no first token within %s", e.Provider, e.Duration)
+	}
+	return fmt.Sprintf("%s: stream idle for %s", e.Provider, e.Duration)
+}
+
+func (e *StreamTimeoutError) Is(target error) bool { return target == ErrStreamTimeout }
+
+// Timeout 实现 net.Error
+func (e *StreamTimeoutError) Timeout() bool { return true }
+
+// Temporary 实现 net.Error
+func (e *StreamTimeoutError) Temporary() bool { return true }
+
+// StreamStats 流式响应的时延统计。
+//
+// 内容事件指包含文本、推理内容或工具调用的事件，一个事件通常对应一个或几个 token
+type StreamStats struct {
+	// TTFT 从发出请求到收到首个内容事件的时间，尚未收到时为 0
+	TTFT time.Duration
+
+	// Tokens 已收到的内容事件数
+	Tokens int
+
+	// InterTokenMean 相邻内容事件的平均间隔
+	InterTokenMean time.Duration
+
+	// InterTokenMax 相邻内容事件的最大间隔
+	InterTokenMax time.Duration
+
+	// Elapsed 从发出请求到流结束的时间，流未结束时为到当前的时间
+	Elapsed time.Duration
+}
+
+// StatsOf 返回流的时延统计，s 不支持统计时 ok 为 false。
+//
+// 内置 provider 返回的流均支持统计；WrapStream、Retry 与 router 包装的流会转发到内层流
+func StatsOf(s Stream) (stats StreamStats, ok bool) {
+	for s != nil {
+		switch v := s.(type) {
+		case interface{ Stats() StreamStats }:
+			return v.Stats(), true
+		case interface{ Unwrap() Stream }:
+			s = v.Unwrap()
+		default:
+			return StreamStats{}, false
+		}
+	}
+	return StreamStats{}, false
+}
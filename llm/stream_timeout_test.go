@@ -0,0 +1,68 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// statsStream 支持 Stats 的 sliceStream
+type statsStream struct {
+	*sliceStream
+	stats llm.StreamStats
+}
+
+func (s *statsStream) Stats() llm.StreamStats { return s.stats }
+
+func TestStreamTimeoutError(t *testing.T) {
+	t.Parallel()
+
+	err := error(&llm.StreamTimeoutError{Provider: llm.ProviderOpenAI, Duration: time.Second})
+	if !errors.Is(err, llm.ErrStreamTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("errors.Is mismatch for %v", err)
+	}
+	if !llm.IsRetryable(err) {
+		t.Errorf("IsRetryable(%v) = false", err)
+	}
+	if got := err.Error(); got != "openai: stream idle for 1s" {
+		t.Errorf("Error() = %q", got)
+	}
+	first := &llm.StreamTimeoutError{Provider: llm.ProviderOpenAI, FirstToken: true, Duration: time.Second}
+	if got := first.Error(); got != "openai: no first token within 1s" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestStatsOf(t *testing.T) {
+	t.Parallel()
+
+	want := llm.StreamStats{TTFT: time.Second, Tokens: 2}
+	inner := &statsStream{sliceStream: newSliceStream("a", "b"), stats: want}
+
+	if _, ok := llm.StatsOf(newSliceStream("a")); ok {
+		t.Error("StatsOf(plain stream) ok = true")
+	}
+
+	wrapped := llm.WrapStream(inner, llm.StreamHooks{})
+	if got, ok := llm.StatsOf(wrapped); !ok || got != want {
+		t.Errorf("StatsOf(WrapStream) = %+v, %v", got, ok)
+	}
+
+	withStats := llm.ChatMiddlewareFuncs{
+		ChatStream: func(context.Context, llm.ChatModel, []schema.Message, ...llm.ChatOption) (llm.Stream, error) {
+			return inner, nil
+		},
+	}.Middleware()
+	model := llm.Chain(&streamModel{}, llm.Retry(llm.RetryConfig{Backoff: noBackoff{}}), withStats)
+	retried, err := model.ChatStream(context.Background(), []schema.Message{schema.UserMessage("hi")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := llm.StatsOf(retried); !ok || got != want {
+		t.Errorf("StatsOf(Retry) = %+v, %v", got, ok)
+	}
+}
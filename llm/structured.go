@@ -0,0 +1,276 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// StructuredStrategy ChatStructured 约束模型输出 JSON 的方式
+type StructuredStrategy string
+
+const (
+	// StructuredAuto 根据 provider 自动选择（默认）
+	StructuredAuto StructuredStrategy = ""
+	// StructuredJSONSchema 使用 response_format json_schema，由服务端按 schema 约束输出
+	StructuredJSONSchema StructuredStrategy = "json_schema"
+	// StructuredJSONObject 使用 response_format json_object，并在提示词中给出 schema
+	StructuredJSONObject StructuredStrategy = "json_object"
+	// StructuredPrompt 仅在提示词中给出 schema，适用于不支持 response_format 的 provider
+	StructuredPrompt StructuredStrategy = "prompt"
+)
+
+// DefaultStructuredRetries ChatStructured 默认的修复重试次数
+const DefaultStructuredRetries = 2
+
+// structuredOption 仅由 ChatStructured 识别的选项，不会传给模型
+type structuredOption func(*structuredConfig)
+
+func (structuredOption) applyChat(*ChatConfig) {}
+
+type structuredConfig struct {
+	strategy StructuredStrategy
+	retries  int
+	name     string
+}
+
+// WithStructuredStrategy 指定 ChatStructured 的输出约束方式，默认根据 provider 自动选择
+func WithStructuredStrategy(s StructuredStrategy) ChatOption {
+	return structuredOption(func(c *structuredConfig) { c.strategy = s })
+}
+
+// WithStructuredRetries 设置输出无法解析或不符合 schema 时的修复重试次数（不含首次请求），默认 DefaultStructuredRetries
+func WithStructuredRetries(n int) ChatOption {
+	return structuredOption(func(c *structuredConfig) { c.retries = max(n, 0) })
+}
+
+// WithStructuredName 设置 json_schema 的名称，默认取类型名
+func WithStructuredName(name string) ChatOption {
+	return structuredOption(func(c *structuredConfig) { c.name = name })
+}
+
+// StructuredError ChatStructured 在全部尝试后仍未得到符合 schema 的输出
+type StructuredError struct {
+	// Attempts 请求次数
+	Attempts int
+	// Output 最后一次模型输出（已去除代码块标记）
+	Output string
+	// Err 最后一次的失败原因：JSON 语法错误、*schema.ValidationError 或解码到 T 的错误
+	Err error
+}
+
+func (e *StructuredError) Error() string {
+	return fmt.Sprintf("llm: structured output invalid after %d attempt(s): %v", e.Attempts, e.Err)
+}
+
+func (e *StructuredError) Unwrap() error { return e.Err }
+
+// ChatStructured 请求模型输出符合 T 的 JSON 并解码。
+//
+// schema 由 schema.JSONSchemaFor[T] 生成；约束方式按 provider 选择：
+// OpenAI / Azure OpenAI 使用 strict json_schema，Gemini / Ollama 使用 json_schema，
+// DeepSeek / Kimi / Qwen 使用 json_object + 提示词，其余 provider 仅使用提示词，
+// 可通过 WithStructuredStrategy 覆盖。
+//
+// 输出会去除 Markdown 代码块标记后按 schema 校验；解码或校验失败时，将错误（含失败路径）反馈给模型重试，
+// 最多重试 WithStructuredRetries 次，仍失败时返回 *StructuredError。
+// 返回的 ChatResponse 为最后一次请求的响应，Usage 为全部请求之和。
+func ChatStructured[T any](ctx context.Context, model ChatModel, messages []schema.Message, opts ...ChatOption) (T, schema.ChatResponse, error) {
+	var zero T
+
+	sc := structuredConfig{retries: DefaultStructuredRetries}
+	chatOpts := make([]ChatOption, 0, len(opts)+1)
+	for _, o := range opts {
+		if so, ok := o.(structuredOption); ok {
+			so(&sc)
+			continue
+		}
+		chatOpts = append(chatOpts, o)
+	}
+
+	strategy := sc.strategy
+	if strategy == StructuredAuto {
+		strategy = structuredStrategyFor(ProviderOf(model))
+	}
+	name := sc.name
+	if name == "" {
+		name = structuredName(reflect.TypeFor[T]())
+	}
+
+	s, format, err := structuredFormat[T](strategy, name, supportsStrictSchema(ProviderOf(model)))
+	if err != nil {
+		return zero, schema.ChatResponse{}, err
+	}
+	if format != nil {
+		chatOpts = append(chatOpts, WithResponseFormat(*format))
+	}
+
+	msgs := slices.Clone(messages)
+	if strategy != StructuredJSONSchema {
+		b, err := json.Marshal(s)
+		if err != nil {
+			return zero, schema.ChatResponse{}, fmt.Errorf("llm: marshal schema: %w", err)
+		}
+		msgs = withSystemInstruction(msgs, structuredInstruction(b))
+	}
+
+	var (
+		resp  schema.ChatResponse
+		usage schema.Usage
+	)
+	for attempt := 1; ; attempt++ {
+		resp, err = model.Chat(ctx, msgs, chatOpts...)
+		if err != nil {
+			return zero, resp, err
+		}
+		usage = usage.Add(resp.Usage)
+		resp.Usage = usage
+
+		if len(resp.Choices) == 0 {
+			return zero, resp, fmt.Errorf("llm: structured output: empty response")
+		}
+		output := StripCodeFence(resp.Choices[0].Message.Text())
+
+		out, verr := decodeStructured[T](s, output)
+		if verr == nil {
+			return out, resp, nil
+		}
+		if attempt > sc.retries {
+			return zero, resp, &StructuredError{Attempts: attempt, Output: output, Err: verr}
+		}
+		msgs = append(msgs,
+			schema.AssistantMessage(output),
+			schema.UserMessage(repairPrompt(verr)),
+		)
+	}
+}
+
+// structuredStrategyFor 各 provider 默认的结构化输出方式
+func structuredStrategyFor(p Provider) StructuredStrategy {
+	switch p {
+	case ProviderOpenAI, ProviderAzureOpenAI, ProviderGemini, ProviderOllama:
+		return StructuredJSONSchema
+	case ProviderDeepSeek, ProviderKimi, ProviderQwen:
+		return StructuredJSONObject
+	default:
+		return StructuredPrompt
+	}
+}
+
+// supportsStrictSchema provider 是否支持 strict json_schema
+func supportsStrictSchema(p Provider) bool {
+	return p == ProviderOpenAI || p == ProviderAzureOpenAI
+}
+
+// structuredFormat 生成用于校验的 schema 与对应的 response_format，校验使用与请求相同的 schema。
+// strict 模式下类型不支持 strict（如含 map）时退回普通 schema。
+func structuredFormat[T any](strategy StructuredStrategy, name string, strict bool) (*schema.JSONSchema, *schema.ResponseFormat, error) {
+	switch strategy {
+	case StructuredJSONSchema:
+		var opts []schema.SchemaOption
+		if strict {
+			if _, err := schema.JSONSchemaFor[T](schema.WithStrictSchema()); err == nil {
+				opts = append(opts, schema.WithStrictSchema())
+			}
+		}
+		s, err := schema.JSONSchemaFor[T](opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("llm: %w", err)
+		}
+		rf, err := schema.ResponseFormatFor[T](name, opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("llm: %w", err)
+		}
+		return s, &rf, nil
+	case StructuredJSONObject, StructuredPrompt:
+		s, err := schema.JSONSchemaFor[T]()
+		if err != nil {
+			return nil, nil, fmt.Errorf("llm: %w", err)
+		}
+		if strategy == StructuredJSONObject {
+			return s, &schema.ResponseFormat{Type: "json_object"}, nil
+		}
+		return s, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("llm: unknown structured strategy %q", strategy)
+	}
+}
+
+func decodeStructured[T any](s *schema.JSONSchema, output string) (T, error) {
+	var out T
+	if err := s.ValidateJSON([]byte(output)); err != nil {
+		return out, err
+	}
+	if err := json.Unmarshal([]byte(output), &out); err != nil {
+		// 输出是合法 JSON 且符合 schema，只是无法解码到 T（如自定义 UnmarshalJSON 拒绝了取值）
+		return out, fmt.Errorf("decode into %T: %w", out, err)
+	}
+	return out, nil
+}
+
+// StripCodeFence 去除模型输出外层的 Markdown 代码块标记（如 ```json ... ```）及首尾空白
+func StripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	body := strings.TrimPrefix(s, "```")
+	// 去掉语言标记所在的首行
+	if i := strings.IndexByte(body, '\n'); i >= 0 {
+		body = body[i+1:]
+	} else {
+		body = ""
+	}
+	body = strings.TrimSpace(body)
+	body = strings.TrimSuffix(body, "```")
+	return strings.TrimSpace(body)
+}
+
+func structuredName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	name := t.Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" {
+		return "response"
+	}
+	return name
+}
+
+func structuredInstruction(schemaJSON []byte) string {
+	return "Respond with a single JSON value that conforms to the following JSON Schema. " +
+		"Output only the JSON, without any explanation or Markdown code fences.\n\nJSON Schema:\n" + string(schemaJSON)
+}
+
+func repairPrompt(err error) string {
+	var b strings.Builder
+	b.WriteString("Your previous reply was not valid for the required JSON Schema:\n")
+	var ve *schema.ValidationError
+	if errors.As(err, &ve) {
+		for _, fe := range ve.Errors {
+			b.WriteString("- " + fe.String() + "\n")
+		}
+	} else {
+		b.WriteString("- " + err.Error() + "\n")
+	}
+	b.WriteString("Reply again with only the corrected JSON.")
+	return b.String()
+}
+
+// withSystemInstruction 在开头的 system 消息之后插入一条 system 消息
+func withSystemInstruction(messages []schema.Message, instruction string) []schema.Message {
+	i := 0
+	for i < len(messages) && messages[i].Role == schema.RoleSystem {
+		i++
+	}
+	return slices.Insert(messages, i, schema.SystemMessage(instruction))
+}
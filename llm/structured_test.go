@@ -0,0 +1,194 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// replyModel 按顺序返回预设回复，并记录每次请求
+type replyModel struct {
+	provider llm.Provider
+	replies  []string
+	calls    [][]schema.Message
+	configs  []llm.ChatConfig
+}
+
+func (m *replyModel) Provider() llm.Provider { return m.provider }
+
+func (m *replyModel) Chat(_ context.Context, messages []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+	m.calls = append(m.calls, messages)
+	m.configs = append(m.configs, llm.ApplyChatOptions(opts...))
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+	return schema.ChatResponse{
+		Choices: []schema.Choice{{Message: schema.AssistantMessage(reply)}},
+		Usage:   schema.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}, nil
+}
+
+func (m *replyModel) ChatStream(context.Context, []schema.Message, ...llm.ChatOption) (llm.Stream, error) {
+	return nil, errors.New("not implemented")
+}
+
+type weather struct {
+	City  string   `json:"city"`
+	TempC float64  `json:"temp_c" min:"-90" max:"60"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+func TestChatStructured_Strategy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		provider  llm.Provider
+		reply     string
+		format    string
+		strict    bool
+		hasPrompt bool
+	}{
+		// strict 模式下可选字段以 null 返回
+		{llm.ProviderOpenAI, `{"city":"上海","temp_c":21.5,"tags":null}`, "json_schema", true, false},
+		{llm.ProviderOllama, `{"city":"上海","temp_c":21.5}`, "json_schema", false, false},
+		{llm.ProviderDeepSeek, `{"city":"上海","temp_c":21.5}`, "json_object", false, true},
+		{llm.ProviderAnthropic, `{"city":"上海","temp_c":21.5}`, "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.provider), func(t *testing.T) {
+			t.Parallel()
+
+			m := &replyModel{provider: tt.provider, replies: []string{tt.reply}}
+			msgs := []schema.Message{schema.SystemMessage("你是天气助手"), schema.UserMessage("上海天气")}
+			got, _, err := llm.ChatStructured[weather](context.Background(), m, msgs)
+			if err != nil {
+				t.Fatalf("ChatStructured() error = %v", err)
+			}
+			if got.City != "上海" || got.TempC != 21.5 {
+				t.Errorf("result = %+v", got)
+			}
+
+			rf := m.configs[0].ResponseFormat
+			switch {
+			case tt.format == "" && rf != nil:
+				t.Errorf("ResponseFormat = %+v, want none", rf)
+			case tt.format != "" && (rf == nil || rf.Type != tt.format):
+				t.Errorf("ResponseFormat = %+v, want %s", rf, tt.format)
+			case tt.format == "json_schema" && strings.Contains(string(rf.JSONSchema), `"strict":true`) != tt.strict:
+				t.Errorf("json_schema = %s, strict want %v", rf.JSONSchema, tt.strict)
+			}
+
+			sent := m.calls[0]
+			if tt.hasPrompt {
+				if len(sent) != 3 || sent[1].Role != schema.RoleSystem || !strings.Contains(sent[1].Text(), `"temp_c"`) {
+					t.Errorf("messages = %+v, want schema instruction after system message", sent)
+				}
+			} else if len(sent) != 2 {
+				t.Errorf("messages = %+v, want unchanged", sent)
+			}
+		})
+	}
+}
+
+func TestChatStructured_RepairRetry(t *testing.T) {
+	t.Parallel()
+
+	m := &replyModel{provider: llm.ProviderAnthropic, replies: []string{
+		"```json\n{\"city\":\"上海\",\"temp_c\":\"warm\"}\n```",
+		"```\n{\"city\":\"上海\",\"temp_c\":99}\n```",
+		`{"city":"上海","temp_c":22}`,
+	}}
+	got, resp, err := llm.ChatStructured[weather](context.Background(), m, []schema.Message{schema.UserMessage("上海天气")})
+	if err != nil {
+		t.Fatalf("ChatStructured() error = %v", err)
+	}
+	if got.TempC != 22 {
+		t.Errorf("result = %+v", got)
+	}
+	if len(m.calls) != 3 || resp.Usage.TotalTokens != 45 {
+		t.Errorf("calls = %d, total tokens = %d", len(m.calls), resp.Usage.TotalTokens)
+	}
+
+	// 第二次请求应带上模型的输出与失败路径
+	second := m.calls[1]
+	last := second[len(second)-1]
+	if second[len(second)-2].Role != schema.RoleAssistant || !strings.Contains(last.Text(), "$.temp_c: expected number, got string") {
+		t.Errorf("repair prompt = %q", last.Text())
+	}
+	if last := m.calls[2][len(m.calls[2])-1]; !strings.Contains(last.Text(), "$.temp_c: must be <= 60") {
+		t.Errorf("repair prompt = %q", last.Text())
+	}
+}
+
+func TestChatStructured_GivesUp(t *testing.T) {
+	t.Parallel()
+
+	m := &replyModel{provider: llm.ProviderOpenAI, replies: []string{`{"city":1}`, `not json`}}
+	_, _, err := llm.ChatStructured[weather](context.Background(), m, []schema.Message{schema.UserMessage("hi")},
+		llm.WithStructuredRetries(1), llm.WithStructuredStrategy(llm.StructuredPrompt))
+
+	var se *llm.StructuredError
+	if !errors.As(err, &se) || se.Attempts != 2 || se.Output != "not json" {
+		t.Fatalf("err = %v, want *StructuredError after 2 attempts", err)
+	}
+	if m.configs[0].ResponseFormat != nil {
+		t.Errorf("forced prompt strategy sent ResponseFormat %+v", m.configs[0].ResponseFormat)
+	}
+	if first := m.calls[1][len(m.calls[1])-1].Text(); !strings.Contains(first, "$.city: expected string, got integer") ||
+		!strings.Contains(first, "$.temp_c: required property is missing") {
+		t.Errorf("repair prompt = %q", first)
+	}
+}
+
+// level 只接受 low / high，schema 中只是 string
+type level string
+
+func (l *level) UnmarshalJSON(b []byte) error {
+	switch s := strings.Trim(string(b), `"`); s {
+	case "low", "high":
+		*l = level(s)
+		return nil
+	default:
+		return fmt.Errorf("unknown level %q", s)
+	}
+}
+
+// TestChatStructured_DecodeError 符合 schema 但无法解码到 T 时，错误不应描述为非法 JSON
+func TestChatStructured_DecodeError(t *testing.T) {
+	t.Parallel()
+
+	type alert struct {
+		Level level `json:"level"`
+	}
+	m := &replyModel{provider: llm.ProviderOpenAI, replies: []string{`{"level":"mid"}`}}
+	_, _, err := llm.ChatStructured[alert](context.Background(), m, []schema.Message{schema.UserMessage("hi")},
+		llm.WithStructuredRetries(0), llm.WithStructuredStrategy(llm.StructuredPrompt))
+
+	var se *llm.StructuredError
+	if !errors.As(err, &se) {
+		t.Fatalf("err = %v, want *StructuredError", err)
+	}
+	if msg := se.Err.Error(); !strings.Contains(msg, "decode into") || !strings.Contains(msg, `unknown level "mid"`) || strings.Contains(msg, "invalid JSON") {
+		t.Errorf("Err = %q", msg)
+	}
+}
+
+func TestStripCodeFence(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		`{"a":1}`:                     `{"a":1}`,
+		"  ```json\n{\"a\":1}\n```\n": `{"a":1}`,
+		"```\n[1]```":                 `[1]`,
+		"```":                         ``,
+	}
+	for in, want := range tests {
+		if got := llm.StripCodeFence(in); got != want {
+			t.Errorf("StripCodeFence(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// Tee 将一个 stream 扇出为 n 个独立的 Stream，每个分支按相同顺序收到全部事件，
+// 适用于同时写 websocket 与持久化等多个消费者的场景。
+//
+// 每个分支有容量为 buffer 的缓冲区。某个分支缓冲区已满时，读取源 stream 的 goroutine 会阻塞等待，
+// 即最慢的消费者决定整体速度（背压），内存占用不超过 n*buffer 个事件。
+// 不再消费的分支必须调用 Close，关闭后该分支不再参与背压；最后一个分支关闭时立即关闭源 stream，
+// 中断阻塞中的读取。
+//
+// 源 stream 结束或出错时，各分支在读完缓冲区后返回相同的错误（正常结束为 io.EOF）；
+// ctx 取消时各分支返回 ctx.Err()。源 stream 由 Tee 负责关闭。
+//
+// 各分支收到的是同一个事件值，其中的切片与 map（ToolCalls、ExtraFields 等）为共享数据，消费者不应修改。
+//
+// n <= 0 时返回 nil，且不读取也不关闭 stream。
+func Tee(ctx context.Context, stream Stream, n, buffer int) []Stream {
+	if n <= 0 {
+		return nil
+	}
+	t := &tee{
+		src:      stream,
+		branches: make([]*teeBranch, n),
+	}
+	t.open.Store(int32(n))
+	out := make([]Stream, n)
+	for i := range t.branches {
+		b := &teeBranch{
+			t:      t,
+			ch:     make(chan schema.StreamEvent, max(buffer, 0)),
+			closed: make(chan struct{}),
+		}
+		t.branches[i] = b
+		out[i] = b
+	}
+	go t.pump(ctx)
+	return out
+}
+
+type tee struct {
+	src      Stream
+	branches []*teeBranch
+
+	// open 未关闭的分支数，降为 0 时关闭源 stream
+	open      atomic.Int32
+	closeOnce sync.Once
+
+	// err 在关闭各分支 channel 之前写入，分支读到 channel 关闭后读取
+	err error
+}
+
+// closeSrc 关闭源 stream，可重复调用
+func (t *tee) closeSrc() {
+	t.closeOnce.Do(func() { _ = t.src.Close() })
+}
+
+func (t *tee) pump(ctx context.Context) {
+	// ctx 取消时关闭源 stream，中断阻塞中的 Recv
+	stop := context.AfterFunc(ctx, t.closeSrc)
+	err := t.run(ctx)
+	stop()
+	t.closeSrc()
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	t.err = err
+	for _, b := range t.branches {
+		close(b.ch)
+	}
+}
+
+func (t *tee) run(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ev, err := t.src.Recv()
+		if err != nil {
+			return err
+		}
+
+		active := 0
+		for _, b := range t.branches {
+			select {
+			case <-b.closed:
+				continue
+			default:
+			}
+			select {
+			case b.ch <- ev:
+				active++
+			case <-b.closed:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if active == 0 {
+			return io.EOF
+		}
+	}
+}
+
+type teeBranch struct {
+	t      *tee
+	ch     chan schema.StreamEvent
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (b *teeBranch) Recv() (schema.StreamEvent, error) {
+	select {
+	case <-b.closed:
+		return schema.StreamEvent{}, io.EOF
+	default:
+	}
+
+	select {
+	case ev, ok := <-b.ch:
+		if !ok {
+			return schema.StreamEvent{}, b.t.err
+		}
+		return ev, nil
+	case <-b.closed:
+		return schema.StreamEvent{}, io.EOF
+	}
+}
+
+func (b *teeBranch) Close() error {
+	b.once.Do(func() {
+		close(b.closed)
+		if b.t.open.Add(-1) == 0 {
+			b.t.closeSrc()
+		}
+	})
+	return nil
+}
@@ -0,0 +1,231 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 内置预切分规则的词表名称
+const (
+	CL100kBase = "cl100k_base"
+	O200kBase  = "o200k_base"
+)
+
+// ws 对应 tiktoken 正则中 Unicode 语义的 \s（Go 的 \s 仅含 ASCII 空白）
+const ws = `\s\x0B\x85\p{Z}`
+
+// 与 tiktoken 相同的预切分规则。Go regexp 不支持 \s+(?!\S)，由 split 对末尾的 \s+ 做等价处理
+var patterns = map[string]string{
+	CL100kBase: `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^` + ws + `\p{L}\p{N}]+[\r\n]*|[` + ws + `]*[\r\n]+|[` + ws + `]+`,
+	O200kBase: `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|\p{N}{1,3}| ?[^` + ws + `\p{L}\p{N}]+[\r\n/]*|[` + ws + `]*[\r\n]+|[` + ws + `]+`,
+}
+
+// BPE tiktoken 兼容的字节级 BPE 分词器，可并发使用
+type BPE struct {
+	name    string
+	ranks   map[string]int
+	decoder map[int]string
+	pattern *regexp.Regexp
+}
+
+// NewBPE 使用词表 ranks（token 字节 → rank）创建分词器。
+// pattern 为预切分正则，为空时按 name 使用内置规则（CL100kBase、O200kBase）
+func NewBPE(name string, ranks map[string]int, pattern string) (*BPE, error) {
+	if pattern == "" {
+		p, ok := patterns[name]
+		if !ok {
+			return nil, fmt.Errorf("tokenizer: no built-in pattern for encoding %q", name)
+		}
+		pattern = p
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("tokenizer: invalid pattern: %w", err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("tokenizer: empty ranks")
+	}
+
+	decoder := make(map[int]string, len(ranks))
+	for tok, rank := range ranks {
+		decoder[rank] = tok
+	}
+	return &BPE{name: name, ranks: ranks, decoder: decoder, pattern: re}, nil
+}
+
+// Load 从 tiktoken 格式的词表（每行 "base64(token) rank"）创建分词器，name 决定预切分规则
+func Load(name string, r io.Reader) (*BPE, error) {
+	ranks, err := ReadRanks(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewBPE(name, ranks, "")
+}
+
+// LoadFile 从磁盘上的词表文件创建分词器
+func LoadFile(name, path string) (*BPE, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("tokenizer: %w", err)
+	}
+	defer f.Close()
+	return Load(name, f)
+}
+
+// LoadFS 从 fs.FS（如 embed.FS）中的词表文件创建分词器
+func LoadFS(name string, fsys fs.FS, path string) (*BPE, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("tokenizer: %w", err)
+	}
+	defer f.Close()
+	return Load(name, f)
+}
+
+// ReadRanks 解析 tiktoken 格式的词表
+func ReadRanks(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := bytes.TrimSpace(sc.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		tok, rank, ok := bytes.Cut(text, []byte(" "))
+		if !ok {
+			return nil, fmt.Errorf("tokenizer: ranks line %d: want \"<base64> <rank>\"", line)
+		}
+		b, err := base64.StdEncoding.DecodeString(string(tok))
+		if err != nil {
+			return nil, fmt.Errorf("tokenizer: ranks line %d: %w", line, err)
+		}
+		n, err := strconv.Atoi(string(rank))
+		if err != nil {
+			return nil, fmt.Errorf("tokenizer: ranks line %d: %w", line, err)
+		}
+		ranks[string(b)] = n
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("tokenizer: read ranks: %w", err)
+	}
+	return ranks, nil
+}
+
+// Name 词表名称
+func (b *BPE) Name() string { return b.name }
+
+// Count 返回 text 的 token 数
+func (b *BPE) Count(text string) int {
+	n := 0
+	for _, piece := range b.split(text) {
+		if _, ok := b.ranks[piece]; ok {
+			n++
+			continue
+		}
+		n += len(b.merge(piece))
+	}
+	return n
+}
+
+// Encode 将 text 编码为 token，特殊 token（如 <|endoftext|>）按普通文本处理
+func (b *BPE) Encode(text string) []int {
+	var out []int
+	for _, piece := range b.split(text) {
+		if rank, ok := b.ranks[piece]; ok {
+			out = append(out, rank)
+			continue
+		}
+		for _, part := range b.merge(piece) {
+			out = append(out, b.ranks[part])
+		}
+	}
+	return out
+}
+
+// Decode 将 token 还原为文本，未知 token 被忽略
+func (b *BPE) Decode(tokens []int) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteString(b.decoder[t])
+	}
+	return sb.String()
+}
+
+// split 按预切分规则切分文本
+func (b *BPE) split(text string) []string {
+	var pieces []string
+	for pos := 0; pos < len(text); {
+		loc := b.pattern.FindStringIndex(text[pos:])
+		if loc == nil || loc[1] == 0 {
+			pieces = append(pieces, text[pos:])
+			break
+		}
+		if loc[0] > 0 {
+			pieces = append(pieces, text[pos:pos+loc[0]])
+		}
+		start, end := pos+loc[0], pos+loc[1]
+		end = trimTrailingSpace(text, start, end)
+		pieces = append(pieces, text[start:end])
+		pos = end
+	}
+	return pieces
+}
+
+// trimTrailingSpace 实现 \s+(?!\S)：不以换行结尾的纯空白片段后紧跟非空白字符时，
+// 最后一个空白字符留给下一个片段（如 "  hello" 切分为 " " 与 " hello"）
+func trimTrailingSpace(text string, start, end int) int {
+	if end >= len(text) {
+		return end
+	}
+	next, _ := utf8.DecodeRuneInString(text[end:])
+	if unicode.IsSpace(next) {
+		return end
+	}
+	piece := text[start:end]
+	if utf8.RuneCountInString(piece) < 2 || strings.HasSuffix(piece, "\n") || strings.HasSuffix(piece, "\r") {
+		return end
+	}
+	for _, r := range piece {
+		if !unicode.IsSpace(r) {
+			return end
+		}
+	}
+	_, size := utf8.DecodeLastRuneInString(piece)
+	return end - size
+}
+
+// merge 对单个片段做字节级 BPE 合并：反复合并 rank 最小的相邻对
+func (b *BPE) merge(piece string) []string {
+	parts := make([]string, len(piece))
+	for i := range len(piece) {
+		parts[i] = piece[i : i+1]
+	}
+	for len(parts) > 1 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i < len(parts)-1; i++ {
+			if r, ok := b.ranks[parts[i]+parts[i+1]]; ok && r < bestRank {
+				best, bestRank = i, r
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts[best] += parts[best+1]
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+	return parts
+}
@@ -0,0 +1,273 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testdata/mini.tiktoken：256 个单字节 token（rank 0-255）及少量合并：
+// he=256 ll=257 llo=258 hello=259 " w"=260 or=261 " wor"=262 ld=263 " world"=264 'm=265
+func loadMini(t *testing.T, name string) *BPE {
+	t.Helper()
+	b, err := LoadFile(name, "testdata/mini.tiktoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+var update = flag.Bool("update", false, "从 $TIKTOKEN_RANKS_DIR 中的真实词表重新裁剪 testdata/*.golden.tiktoken")
+
+// goldenPath 从真实词表裁剪出的小词表，只包含 golden 测试文本各预切分片段的全部字节子串中
+// 在真实词表里存在的 token（rank 不变）。BPE 合并只查询片段的子串，因此编码结果与完整词表一致
+func goldenPath(name string) string {
+	return filepath.Join("testdata", name+".golden.tiktoken")
+}
+
+// loadReal 加载与真实词表编码结果一致的分词器：优先使用 testdata 中裁剪的 golden 词表，
+// 否则从 $TIKTOKEN_RANKS_DIR（默认 testdata）加载完整词表 <name>.tiktoken，都不存在时跳过测试。
+// 完整词表可从 https://openaipublic.blob.core.windows.net/encodings/<name>.tiktoken 下载
+func loadReal(t *testing.T, name string) *BPE {
+	t.Helper()
+	if _, err := os.Stat(goldenPath(name)); err == nil {
+		b, err := LoadFile(name, goldenPath(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	return loadFull(t, name)
+}
+
+func loadFull(t *testing.T, name string) *BPE {
+	t.Helper()
+	dir := os.Getenv("TIKTOKEN_RANKS_DIR")
+	if dir == "" {
+		dir = "testdata"
+	}
+	path := filepath.Join(dir, name+".tiktoken")
+	if _, err := os.Stat(path); err != nil {
+		t.Skipf("%s and %s not found, run go test -run TestGoldenRanks -update with TIKTOKEN_RANKS_DIR set", goldenPath(name), path)
+	}
+	b, err := LoadFile(name, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// goldenTexts golden 测试会编码的全部文本
+func goldenTexts() []string {
+	var texts []string
+	for _, tt := range bpeGolden {
+		texts = append(texts, tt.in)
+	}
+	for _, m := range goldenMessages {
+		texts = append(texts, string(m.Role), m.Name, m.Text())
+	}
+	return texts
+}
+
+// TestGoldenRanks 检查裁剪的词表与完整词表的编码结果一致；-update 时重新生成
+func TestGoldenRanks(t *testing.T) {
+	for _, name := range []string{CL100kBase, O200kBase} {
+		t.Run(name, func(t *testing.T) {
+			full := loadFull(t, name)
+			if *update {
+				writeGoldenRanks(t, full, goldenTexts())
+			}
+			cut, err := LoadFile(name, goldenPath(name))
+			if err != nil {
+				t.Fatalf("%v (run with -update to generate)", err)
+			}
+			for _, text := range goldenTexts() {
+				if got, want := cut.Encode(text), full.Encode(text); !slices.Equal(got, want) {
+					t.Errorf("Encode(%q) = %v, want %v", text, got, want)
+				}
+			}
+		})
+	}
+}
+
+// writeGoldenRanks 将 texts 各片段的全部字节子串中在 full 里存在的 token 按 rank 顺序写入 golden 词表
+func writeGoldenRanks(t *testing.T, full *BPE, texts []string) {
+	t.Helper()
+	keep := make(map[string]int)
+	for _, text := range texts {
+		for _, piece := range full.split(text) {
+			for i := range len(piece) {
+				for j := i + 1; j <= len(piece); j++ {
+					if r, ok := full.ranks[piece[i:j]]; ok {
+						keep[piece[i:j]] = r
+					}
+				}
+			}
+		}
+	}
+	toks := slices.SortedFunc(maps.Keys(keep), func(a, b string) int { return keep[a] - keep[b] })
+	var buf strings.Builder
+	for _, tok := range toks {
+		fmt.Fprintf(&buf, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(tok)), keep[tok])
+	}
+	if err := os.WriteFile(goldenPath(full.Name()), []byte(buf.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// bpeGolden tiktoken 的编码结果，want 为 nil 时只比较 token 数
+var bpeGolden = []struct {
+	encoding string
+	in       string
+	want     []int
+	count    int
+}{
+	{CL100kBase, "hello world", []int{15339, 1917}, 2},
+	{CL100kBase, "tiktoken is great!", []int{83, 1609, 5963, 374, 2294, 0}, 6},
+	{O200kBase, "hello world", nil, 2},
+	{O200kBase, "tiktoken is great!", nil, 6},
+}
+
+// TestBPE_Golden 与 tiktoken 的编码结果对照
+func TestBPE_Golden(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range bpeGolden {
+		t.Run(tt.encoding, func(t *testing.T) {
+			b := loadReal(t, tt.encoding)
+			got := b.Encode(tt.in)
+			if tt.want != nil && !slices.Equal(got, tt.want) {
+				t.Errorf("Encode(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+			if len(got) != tt.count {
+				t.Errorf("Encode(%q) has %d tokens, want %d", tt.in, len(got), tt.count)
+			}
+			if dec := b.Decode(got); dec != tt.in {
+				t.Errorf("Decode() = %q, want %q", dec, tt.in)
+			}
+		})
+	}
+}
+
+func TestBPE_Split(t *testing.T) {
+	t.Parallel()
+
+	// 期望值为 tiktoken 对应规则的切分结果
+	tests := []struct {
+		encoding string
+		in       string
+		want     []string
+	}{
+		{CL100kBase, "hello world", []string{"hello", " world"}},
+		{CL100kBase, "  hello", []string{" ", " hello"}},
+		{CL100kBase, "a  1", []string{"a", " ", " ", "1"}},
+		{CL100kBase, "I'm HERE's", []string{"I", "'m", " HERE", "'s"}},
+		{CL100kBase, "1234567", []string{"123", "456", "7"}},
+		{CL100kBase, "foo\n\n  bar  ", []string{"foo", "\n\n", " ", " bar", "  "}},
+		{CL100kBase, "x = (y);\n", []string{"x", " =", " (", "y", ");\n"}},
+		{CL100kBase, "你好，世界", []string{"你好", "，世界"}},
+		{CL100kBase, "a  b", []string{"a", " ", " b"}},
+		{O200kBase, "HelloWorld I'm", []string{"Hello", "World", " I'm"}},
+		{O200kBase, "path/to/\nx", []string{"path", "/to", "/\n", "x"}},
+	}
+	for _, tt := range tests {
+		b := loadMini(t, tt.encoding)
+		if got := b.split(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("%s split(%q) = %q, want %q", tt.encoding, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBPE_EncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	b := loadMini(t, CL100kBase)
+	tests := []struct {
+		in   string
+		want []int
+	}{
+		{"hello world", []int{259, 264}},
+		{"hello  world!", []int{259, 32, 264, 33}},
+		{"I'm", []int{73, 265}},
+		{"world", []int{119, 261, 263}},
+		{"hellos", []int{259, 115}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := b.Encode(tt.in)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Encode(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+		if n := b.Count(tt.in); n != len(tt.want) {
+			t.Errorf("Count(%q) = %d, want %d", tt.in, n, len(tt.want))
+		}
+		if s := b.Decode(got); s != tt.in {
+			t.Errorf("Decode(Encode(%q)) = %q", tt.in, s)
+		}
+	}
+
+	// 多字节字符按 UTF-8 字节编码
+	if got := b.Encode("好"); !slices.Equal(got, []int{0xe5, 0xa5, 0xbd}) {
+		t.Errorf("Encode(好) = %v", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("testdata/mini.tiktoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadFS(O200kBase, fstest.MapFS{"enc/mini.tiktoken": {Data: data}}, "enc/mini.tiktoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Name() != O200kBase || b.Count("hello world") != 2 {
+		t.Errorf("LoadFS() = %s, Count = %d", b.Name(), b.Count("hello world"))
+	}
+
+	if _, err := Load("p50k_base", strings.NewReader("YQ== 0\n")); err == nil || !strings.Contains(err.Error(), "no built-in pattern") {
+		t.Errorf("unknown encoding err = %v", err)
+	}
+	if _, err := Load(CL100kBase, strings.NewReader("YQ== 0\n!!! 1\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("bad base64 err = %v", err)
+	}
+	if _, err := NewBPE("custom", map[string]int{"a": 0}, `\w+|\s+`); err != nil {
+		t.Errorf("custom pattern: %v", err)
+	}
+}
+
+func TestHeuristic(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]int{"": 0, "hello world!": 3, "你好，世界": 5, "Go 语言": 3} {
+		if got := (Heuristic{}).Count(in); got != want {
+			t.Errorf("Count(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestEncodingForModel(t *testing.T) {
+	t.Parallel()
+
+	for model, want := range map[string]string{
+		"gpt-4o-mini":            O200kBase,
+		"o3-mini":                O200kBase,
+		"gpt-4-turbo":            CL100kBase,
+		"gpt-3.5-turbo":          CL100kBase,
+		"text-embedding-3-small": CL100kBase,
+		"deepseek-chat":          "",
+	} {
+		if got := EncodingForModel(model); got != want {
+			t.Errorf("EncodingForModel(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
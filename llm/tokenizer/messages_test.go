@@ -0,0 +1,91 @@
+package tokenizer
+
+import (
+	"testing"
+
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+func TestCountMessages(t *testing.T) {
+	t.Parallel()
+
+	b := loadMini(t, CL100kBase)
+	user := schema.UserMessage("hello world")
+	user.Name = "world"
+	messages := []schema.Message{schema.SystemMessage("hello"), user}
+
+	// system: 3 + "system"(6) + "hello"(1) = 10
+	// user:   3 + "user"(4) + name 1 + "world"(3) + "hello world"(2) = 13
+	// 回复:   3
+	if got := CountMessages(b, messages, nil); got != 26 {
+		t.Errorf("CountMessages() = %d, want 26", got)
+	}
+
+	tool := schema.Tool{Type: schema.ToolTypeFunction, Function: schema.FunctionDefinition{
+		Name:        "hello",
+		Description: "world.",
+		Parameters: schema.MustJSON(map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"q": map[string]any{"type": "string", "description": "hello"},
+				"u": map[string]any{"type": "string", "enum": []string{"he", "ll"}},
+			},
+		}),
+	}}
+	// 12 + 7 + "hello:world"(5) + 3
+	// q: 3 + "q:string:hello"(10)
+	// u: 3 + (-3 + 3+"he"(1) + 3+"ll"(1)) + "u:string:"(9)
+	if got := CountTools(b, []schema.Tool{tool}); got != 57 {
+		t.Errorf("CountTools() = %d, want 57", got)
+	}
+	if got := CountMessages(b, messages, []schema.Tool{tool}); got != 26+57 {
+		t.Errorf("CountMessages() with tools = %d, want %d", got, 26+57)
+	}
+
+	call := schema.Message{Role: schema.RoleAssistant, ToolCalls: []schema.ToolCall{{
+		ID: "c", Function: schema.ToolFunction{Name: "hello", Arguments: "{}"},
+	}}}
+	img := schema.Message{Role: schema.RoleUser, Content: []schema.ContentPart{schema.ImageURLPart("https://example.com/a.png")}}
+	// 回复 3；3 + "assistant"(9) + 3 + "hello"(1) + "{}"(2) = 18；3 + "user"(4) + 85 = 92
+	if got := CountMessages(b, []schema.Message{call, img}, nil); got != 3+18+92 {
+		t.Errorf("CountMessages() = %d, want %d", got, 3+18+92)
+	}
+}
+
+func goldenMsg(role schema.Role, name, text string) schema.Message {
+	return schema.Message{Role: role, Name: name, Content: []schema.ContentPart{schema.TextContent{Text: text}}}
+}
+
+// goldenMessages openai-cookbook "How to count tokens with tiktoken" 的示例消息
+var goldenMessages = []schema.Message{
+	goldenMsg(schema.RoleSystem, "", "You are a helpful, pattern-following assistant that translates corporate jargon into plain English."),
+	goldenMsg(schema.RoleSystem, "example_user", "New synergies will help drive top-line growth."),
+	goldenMsg(schema.RoleSystem, "example_assistant", "Things working well together will increase revenue."),
+	goldenMsg(schema.RoleSystem, "example_user", "Let's circle back when we have more bandwidth to touch base on opportunities for increased leverage."),
+	goldenMsg(schema.RoleSystem, "example_assistant", "Let's talk later when we're less busy about how to do better."),
+	goldenMsg(schema.RoleUser, "", "This late pivot means we don't have time to boil the ocean for the client deliverable."),
+}
+
+// TestCountMessages_Golden 对 goldenMessages 计数，
+// 期望值为 OpenAI API 对同一请求返回的 prompt_tokens
+func TestCountMessages_Golden(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"gpt-4", 129},
+		{"gpt-3.5-turbo", 129},
+		{"gpt-4o", 124},
+		{"gpt-4o-mini", 124},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			b := loadReal(t, EncodingForModel(tt.model))
+			if got := CountMessages(b, goldenMessages, nil); got != tt.want {
+				t.Errorf("CountMessages() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Package tokenizer 离线估算文本与消息的 token 数，用于请求前的预算控制与历史裁剪。
+//
+// BPE 使用 tiktoken 格式的词表文件（cl100k_base、o200k_base 等），结果与 OpenAI 一致；
+// 其他模型可使用不依赖词表的 Heuristic 粗略估算。
+package tokenizer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Tokenizer 计算文本的 token 数
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// Heuristic 不依赖词表的粗略估算：ASCII 约 4 字节 1 个 token，其余字符（如中文）约 1 字符 1 个 token。
+// 对多数模型略微高估，适合做预算上限
+type Heuristic struct{}
+
+func (Heuristic) Count(text string) int {
+	var ascii, other int
+	for i := 0; i < len(text); {
+		if text[i] < utf8.RuneSelf {
+			ascii++
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		other++
+		i += size
+	}
+	return (ascii+3)/4 + other
+}
+
+// EncodingForModel 返回 OpenAI 模型使用的词表名称，未知模型返回空字符串
+func EncodingForModel(model string) string {
+	for _, p := range []string{"gpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "o1", "o3", "o4", "chatgpt-4o"} {
+		if strings.HasPrefix(model, p) {
+			return O200kBase
+		}
+	}
+	for _, p := range []string{"gpt-4", "gpt-3.5", "text-embedding-3", "text-embedding-ada-002"} {
+		if strings.HasPrefix(model, p) {
+			return CL100kBase
+		}
+	}
+	return ""
+}
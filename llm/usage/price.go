@@ -0,0 +1,81 @@
+package usage
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// Price 模型单价，单位为每百万 token 的价格（币种由使用方约定）
+type Price struct {
+	// Input 输入 token 单价
+	Input float64
+	// CachedInput 命中缓存的输入 token（Usage.PromptCacheHitTokens）单价，为 0 时按 Input 计价
+	CachedInput float64
+	// Output 输出 token（含推理 token）单价
+	Output float64
+}
+
+// Cost 计算一次调用的费用
+func (p Price) Cost(u schema.Usage) float64 {
+	cached := min(u.PromptCacheHitTokens, u.PromptTokens)
+	cachedPrice := p.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = p.Input
+	}
+	return (float64(u.PromptTokens-cached)*p.Input +
+		float64(cached)*cachedPrice +
+		float64(u.CompletionTokens)*p.Output) / 1e6
+}
+
+// PriceTable 按模型查找单价。
+//
+// 键为 "provider/model" 或 "model"，查找顺序：精确匹配 "provider/model"、精确匹配 "model"、
+// 最长前缀匹配（如 "gpt-4o" 匹配 "gpt-4o-2024-08-06"，"gpt-4o-mini" 优先于 "gpt-4o"）。
+//
+// 只有 "/" 之前是已知 provider（内置 provider、已注册的 provider 或查找时的 provider）时才视为 "provider/model"，
+// 否则整个键视为模型 ID（如 "deepseek-ai/DeepSeek-V3"）
+type PriceTable map[string]Price
+
+// Lookup 返回 provider 下 model 的单价
+func (t PriceTable) Lookup(provider llm.Provider, model string) (Price, bool) {
+	if model == "" {
+		return Price{}, false
+	}
+	if p, ok := t[string(provider)+"/"+model]; ok {
+		return p, true
+	}
+	if p, ok := t[model]; ok {
+		return p, true
+	}
+
+	var (
+		best    Price
+		bestLen int
+	)
+	for k, p := range t {
+		name := k
+		if prov, m, ok := strings.Cut(k, "/"); ok && isProvider(llm.Provider(prov), provider) {
+			if prov != string(provider) {
+				continue
+			}
+			name = m
+		}
+		if len(name) > bestLen && strings.HasPrefix(model, name) {
+			best, bestLen = p, len(name)
+		}
+	}
+	return best, bestLen > 0
+}
+
+// isProvider 判断 name 是否为 provider 名称，而不是模型 ID 中的组织名
+func isProvider(name, current llm.Provider) bool {
+	switch name {
+	case current, llm.ProviderOpenAI, llm.ProviderAzureOpenAI, llm.ProviderDeepSeek, llm.ProviderKimi,
+		llm.ProviderQwen, llm.ProviderOllama, llm.ProviderAnthropic, llm.ProviderGemini:
+		return true
+	}
+	return slices.Contains(llm.Providers(), name)
+}
@@ -0,0 +1,265 @@
+// Package usage 统计 ChatModel 调用的 token 用量与费用。
+//
+// Tracker 按 provider、模型、用户与标签分别汇总，可作为 ChatModel 包装器（Wrap）使用，
+// 也可以通过 Add 手动记录；设置预算后，超出预算的调用会被拒绝。
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// ErrBudgetExceeded 已用量达到预算，调用被拒绝
+var ErrBudgetExceeded = errors.New("usage: budget exceeded")
+
+// DefaultResolution 未设置 Config.Resolution 时按小时汇总
+const DefaultResolution = time.Hour
+
+// Key 用量的汇总维度
+type Key struct {
+	Provider llm.Provider
+	Model    string
+	User     string
+	Tag      string
+}
+
+// Total 一组调用的用量合计
+type Total struct {
+	Requests         int
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	// CachedTokens 命中缓存的输入 token（Usage.PromptCacheHitTokens）
+	CachedTokens    int
+	ReasoningTokens int
+	Cost            float64
+}
+
+func (t *Total) add(o Total) {
+	t.Requests += o.Requests
+	t.PromptTokens += o.PromptTokens
+	t.CompletionTokens += o.CompletionTokens
+	t.TotalTokens += o.TotalTokens
+	t.CachedTokens += o.CachedTokens
+	t.ReasoningTokens += o.ReasoningTokens
+	t.Cost += o.Cost
+}
+
+// Record 一次调用的用量
+type Record struct {
+	Time time.Time
+	Key
+	Usage schema.Usage
+	// Cost 为 0 时按 Config.Prices 计算
+	Cost float64
+}
+
+type Config struct {
+	// Prices 单价表，未命中的模型费用计为 0
+	Prices PriceTable
+
+	// OnUnpriced 设置了 Prices 但记录未给出 Cost、且单价表中找不到其模型（包括模型名为空）时调用，
+	// 此时费用计为 0，不计入 MaxCost 预算
+	OnUnpriced func(Record)
+
+	// MaxCost 费用预算，<= 0 表示不限制
+	MaxCost float64
+
+	// MaxTokens token（TotalTokens）预算，<= 0 表示不限制
+	MaxTokens int
+
+	// BudgetPeriod 预算周期（如 24 * time.Hour 表示按 UTC 自然日），为 0 时预算针对 Tracker 的全部用量
+	BudgetPeriod time.Duration
+
+	// Resolution 按时间段查询（Period）的精度，默认 DefaultResolution
+	Resolution time.Duration
+
+	// TagKey 未通过 WithTag 设置标签时，从请求 Metadata（llm.WithMetadata）中读取标签的键，默认 "tag"
+	TagKey string
+
+	// StreamUsage 为 Wrap 的流式请求自动添加 llm.WithStreamIncludeUsage()（未设置 StreamOptions 时），
+	// 否则多数 OpenAI 兼容服务在流式响应中不返回用量
+	StreamUsage bool
+
+	// Now 返回当前时间，默认 time.Now，用于测试
+	Now func() time.Time
+}
+
+// Tracker 用量统计，可并发使用
+type Tracker struct {
+	cfg Config
+
+	mu      sync.Mutex
+	buckets map[bucket]*Total
+}
+
+type bucket struct {
+	Key
+	start int64 // 时间段起点（Unix 秒）
+}
+
+func New(cfg Config) *Tracker {
+	if cfg.Resolution <= 0 {
+		cfg.Resolution = DefaultResolution
+	}
+	if cfg.TagKey == "" {
+		cfg.TagKey = "tag"
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Tracker{cfg: cfg, buckets: make(map[bucket]*Total)}
+}
+
+// Add 记录一次调用的用量，Time 为零值时使用当前时间
+func (t *Tracker) Add(r Record) {
+	if r.Time.IsZero() {
+		r.Time = t.cfg.Now()
+	}
+	if r.Cost == 0 && t.cfg.Prices != nil {
+		if p, ok := t.cfg.Prices.Lookup(r.Provider, r.Model); ok {
+			r.Cost = p.Cost(r.Usage)
+		} else if t.cfg.OnUnpriced != nil {
+			t.cfg.OnUnpriced(r)
+		}
+	}
+	tot := Total{
+		Requests:         1,
+		PromptTokens:     r.Usage.PromptTokens,
+		CompletionTokens: r.Usage.CompletionTokens,
+		TotalTokens:      r.Usage.TotalTokens,
+		CachedTokens:     r.Usage.PromptCacheHitTokens,
+		Cost:             r.Cost,
+	}
+	if d := r.Usage.CompletionTokensDetails; d != nil {
+		tot.ReasoningTokens = d.ReasoningTokens
+	}
+
+	b := bucket{Key: r.Key, start: r.Time.Truncate(t.cfg.Resolution).Unix()}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cur := t.buckets[b]
+	if cur == nil {
+		cur = &Total{}
+		t.buckets[b] = cur
+	}
+	cur.add(tot)
+}
+
+// Snapshot 全部用量
+type Snapshot struct {
+	Total Total
+	ByKey map[Key]Total
+}
+
+// GroupBy 按 key 函数的结果重新汇总，如按模型：s.GroupBy(func(k Key) string { return k.Model })
+func (s Snapshot) GroupBy(key func(Key) string) map[string]Total {
+	out := make(map[string]Total)
+	for k, v := range s.ByKey {
+		g := key(k)
+		cur := out[g]
+		cur.add(v)
+		out[g] = cur
+	}
+	return out
+}
+
+// Snapshot 返回全部用量
+func (t *Tracker) Snapshot() Snapshot {
+	return t.Period(time.Time{}, time.Time{})
+}
+
+// Period 返回 [from, to) 内的用量，零值表示不限制。时间按 Config.Resolution 对齐
+func (t *Tracker) Period(from, to time.Time) Snapshot {
+	lo, hi := int64(math.MinInt64), int64(math.MaxInt64)
+	if !from.IsZero() {
+		lo = from.Truncate(t.cfg.Resolution).Unix()
+	}
+	if !to.IsZero() {
+		hi = to.Unix()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := Snapshot{ByKey: make(map[Key]Total)}
+	for b, v := range t.buckets {
+		if b.start < lo || b.start >= hi {
+			continue
+		}
+		cur := s.ByKey[b.Key]
+		cur.add(*v)
+		s.ByKey[b.Key] = cur
+		s.Total.add(*v)
+	}
+	return s
+}
+
+// Reset 清空全部用量
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buckets = make(map[bucket]*Total)
+}
+
+// CheckBudget 已用量达到预算时返回包装了 ErrBudgetExceeded 的错误
+func (t *Tracker) CheckBudget() error {
+	if t.cfg.MaxCost <= 0 && t.cfg.MaxTokens <= 0 {
+		return nil
+	}
+	var from time.Time
+	if t.cfg.BudgetPeriod > 0 {
+		from = t.cfg.Now().Truncate(t.cfg.BudgetPeriod)
+	}
+	tot := t.Period(from, time.Time{}).Total
+	if t.cfg.MaxCost > 0 && tot.Cost >= t.cfg.MaxCost {
+		return fmt.Errorf("%w: cost %.6g reached limit %.6g", ErrBudgetExceeded, tot.Cost, t.cfg.MaxCost)
+	}
+	if t.cfg.MaxTokens > 0 && tot.TotalTokens >= t.cfg.MaxTokens {
+		return fmt.Errorf("%w: %d tokens reached limit %d", ErrBudgetExceeded, tot.TotalTokens, t.cfg.MaxTokens)
+	}
+	return nil
+}
+
+type ctxKey int
+
+const (
+	userKey ctxKey = iota
+	tagKey
+)
+
+// WithUser 在 ctx 中设置用户，优先于请求的 llm.WithUser
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// WithTag 在 ctx 中设置标签，优先于请求 Metadata 中的标签
+func WithTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, tagKey, tag)
+}
+
+// key 由 ctx 与请求选项确定汇总维度
+func (t *Tracker) key(ctx context.Context, provider llm.Provider, cfg llm.ChatConfig) Key {
+	k := Key{Provider: provider, Model: cfg.Model}
+	if u, ok := ctx.Value(userKey).(string); ok {
+		k.User = u
+	} else if cfg.User != nil {
+		k.User = *cfg.User
+	}
+	if tag, ok := ctx.Value(tagKey).(string); ok {
+		k.Tag = tag
+	} else {
+		k.Tag = cfg.Metadata[t.cfg.TagKey]
+	}
+	return k
+}
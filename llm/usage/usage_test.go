@@ -0,0 +1,239 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+var prices = PriceTable{
+	"gpt-4o":             {Input: 2.5, CachedInput: 1.25, Output: 10},
+	"gpt-4o-mini":        {Input: 0.15, Output: 0.6},
+	"deepseek/deepseek-": {Input: 0.27, CachedInput: 0.07, Output: 1.1},
+	// 组织名不是 provider，整个键为模型 ID
+	"deepseek-ai/DeepSeek-V3": {Input: 2, Output: 8},
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-12 }
+
+func TestPriceTable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		provider llm.Provider
+		model    string
+		want     Price
+		ok       bool
+	}{
+		{llm.ProviderOpenAI, "gpt-4o", prices["gpt-4o"], true},
+		{llm.ProviderOpenAI, "gpt-4o-2024-08-06", prices["gpt-4o"], true},
+		{llm.ProviderOpenAI, "gpt-4o-mini-2024-07-18", prices["gpt-4o-mini"], true},
+		{llm.ProviderDeepSeek, "deepseek-chat", prices["deepseek/deepseek-"], true},
+		{llm.ProviderOpenAI, "deepseek-chat", Price{}, false},
+		{llm.ProviderOpenAI, "", Price{}, false},
+		{llm.Provider("siliconflow"), "deepseek-ai/DeepSeek-V3", prices["deepseek-ai/DeepSeek-V3"], true},
+		{llm.Provider("siliconflow"), "deepseek-ai/DeepSeek-V3-0324", prices["deepseek-ai/DeepSeek-V3"], true},
+		{llm.ProviderOpenAI, "deepseek-ai/DeepSeek-V2", Price{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := prices.Lookup(tt.provider, tt.model)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Lookup(%s, %s) = %+v, %v", tt.provider, tt.model, got, ok)
+		}
+	}
+
+	// 1M 输入（其中 40 万命中缓存）+ 10 万输出
+	u := schema.Usage{PromptTokens: 1_000_000, PromptCacheHitTokens: 400_000, CompletionTokens: 100_000}
+	if got := prices["gpt-4o"].Cost(u); !near(got, 0.6*2.5+0.4*1.25+0.1*10) {
+		t.Errorf("Cost() = %v", got)
+	}
+	// 未设置缓存价时按输入价计
+	if got := prices["gpt-4o-mini"].Cost(u); !near(got, 0.15+0.1*0.6) {
+		t.Errorf("Cost() without cache price = %v", got)
+	}
+}
+
+// usageModel 返回固定用量，流式响应在最后一个事件携带用量
+type usageModel struct {
+	usage   schema.Usage
+	calls   int
+	streams []llm.ChatConfig
+	// streamModel 流式响应结束事件携带的模型名
+	streamModel string
+}
+
+func (m *usageModel) Provider() llm.Provider { return llm.ProviderOpenAI }
+
+func (m *usageModel) Chat(_ context.Context, _ []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+	m.calls++
+	return schema.ChatResponse{Model: "gpt-4o-2024-08-06", Usage: m.usage}, nil
+}
+
+func (m *usageModel) ChatStream(_ context.Context, _ []schema.Message, opts ...llm.ChatOption) (llm.Stream, error) {
+	m.calls++
+	m.streams = append(m.streams, llm.ApplyChatOptions(opts...))
+	u := m.usage
+	return &eventStream{events: []schema.StreamEvent{
+		{Type: schema.StreamEventDelta, Delta: "hi"},
+		{Type: schema.StreamEventDone, Usage: &u, Model: m.streamModel},
+	}}, nil
+}
+
+type eventStream struct{ events []schema.StreamEvent }
+
+func (s *eventStream) Recv() (schema.StreamEvent, error) {
+	if len(s.events) == 0 {
+		return schema.StreamEvent{}, io.EOF
+	}
+	ev := s.events[0]
+	s.events = s.events[1:]
+	return ev, nil
+}
+
+func (s *eventStream) Close() error { return nil }
+
+func TestTracker_Wrap(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
+	tr := New(Config{Prices: prices, StreamUsage: true, Now: func() time.Time { return now }})
+	inner := &usageModel{usage: schema.Usage{
+		PromptTokens: 1000, CompletionTokens: 200, TotalTokens: 1200, PromptCacheHitTokens: 400,
+		CompletionTokensDetails: &schema.CompletionTokensDetails{ReasoningTokens: 50},
+	}}
+	model := tr.Wrap(inner)
+	if llm.ProviderOf(model) != llm.ProviderOpenAI {
+		t.Errorf("ProviderOf(wrapped) = %s", llm.ProviderOf(model))
+	}
+
+	ctx := WithTag(context.Background(), "search")
+	if _, err := model.Chat(ctx, nil, llm.WithUser("alice")); err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := model.ChatStream(context.Background(), nil, llm.WithModel("gpt-4o"), llm.WithMetadata(map[string]string{"tag": "chat"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+	}
+	stream.Close()
+	if so := inner.streams[0].StreamOptions; so == nil || !so.IncludeUsage {
+		t.Errorf("stream options = %+v, want include_usage", so)
+	}
+
+	s := tr.Snapshot()
+	wantCost := (600*2.5 + 400*1.25 + 200*10) / 1e6
+	if s.Total.Requests != 2 || s.Total.TotalTokens != 2400 || s.Total.CachedTokens != 800 || s.Total.ReasoningTokens != 100 || !near(s.Total.Cost, 2*wantCost) {
+		t.Errorf("Total = %+v", s.Total)
+	}
+	chat := Key{Provider: llm.ProviderOpenAI, Model: "gpt-4o-2024-08-06", User: "alice", Tag: "search"}
+	streamed := Key{Provider: llm.ProviderOpenAI, Model: "gpt-4o", Tag: "chat"}
+	if s.ByKey[chat].Requests != 1 || s.ByKey[streamed].Requests != 1 {
+		t.Errorf("ByKey = %+v", s.ByKey)
+	}
+	byTag := s.GroupBy(func(k Key) string { return k.Tag })
+	if byTag["search"].TotalTokens != 1200 || byTag["chat"].TotalTokens != 1200 {
+		t.Errorf("GroupBy(tag) = %+v", byTag)
+	}
+}
+
+func TestTracker_StreamModel(t *testing.T) {
+	t.Parallel()
+
+	var unpriced []Record
+	tr := New(Config{Prices: prices, OnUnpriced: func(r Record) { unpriced = append(unpriced, r) }})
+	usage := schema.Usage{PromptTokens: 1000, CompletionTokens: 100, TotalTokens: 1100}
+
+	drain := func(inner *usageModel) {
+		t.Helper()
+		// 未设置 llm.WithModel，模型来自客户端的默认选项
+		stream, err := tr.Wrap(inner).ChatStream(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := llm.Accumulate(stream); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 流中给出模型名时按其计价
+	drain(&usageModel{usage: usage, streamModel: "gpt-4o-mini-2024-07-18"})
+	key := Key{Provider: llm.ProviderOpenAI, Model: "gpt-4o-mini-2024-07-18"}
+	if got := tr.Snapshot().ByKey[key]; got.Requests != 1 || !near(got.Cost, prices["gpt-4o-mini"].Cost(usage)) {
+		t.Errorf("ByKey[%+v] = %+v", key, got)
+	}
+	if len(unpriced) != 0 {
+		t.Errorf("unpriced = %+v", unpriced)
+	}
+
+	// 无法确定模型时通过 OnUnpriced 报告
+	drain(&usageModel{usage: usage})
+	if len(unpriced) != 1 || unpriced[0].Model != "" || unpriced[0].Usage.TotalTokens != 1100 {
+		t.Errorf("unpriced = %+v", unpriced)
+	}
+}
+
+func TestTracker_Period(t *testing.T) {
+	t.Parallel()
+
+	tr := New(Config{})
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for h := range 48 {
+		tr.Add(Record{Time: day.Add(time.Duration(h)*time.Hour + 30*time.Minute), Key: Key{Model: "m"}, Usage: schema.Usage{TotalTokens: 1}, Cost: 0.5})
+	}
+
+	if got := tr.Period(day, day.Add(24*time.Hour)).Total; got.Requests != 24 || got.TotalTokens != 24 || got.Cost != 12 {
+		t.Errorf("first day = %+v", got)
+	}
+	if got := tr.Period(day.Add(36*time.Hour+10*time.Minute), time.Time{}).Total; got.Requests != 12 {
+		t.Errorf("last 12 hours = %+v", got)
+	}
+	tr.Reset()
+	if got := tr.Snapshot().Total; got.Requests != 0 {
+		t.Errorf("after Reset = %+v", got)
+	}
+}
+
+func TestTracker_Budget(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	tr := New(Config{MaxTokens: 2000, BudgetPeriod: 24 * time.Hour, Now: func() time.Time { return now }})
+	inner := &usageModel{usage: schema.Usage{TotalTokens: 1200}}
+	model := tr.Wrap(inner)
+
+	for i, wantErr := range []bool{false, false, true} {
+		_, err := model.Chat(context.Background(), nil)
+		if got := errors.Is(err, ErrBudgetExceeded); got != wantErr {
+			t.Fatalf("call %d: err = %v", i, err)
+		}
+	}
+	if _, err := model.ChatStream(context.Background(), nil); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("ChatStream err = %v", err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("model called %d times after budget exceeded", inner.calls)
+	}
+
+	// 下一个预算周期重新计算
+	now = now.Add(2 * time.Hour)
+	if err := tr.CheckBudget(); err != nil {
+		t.Errorf("next period CheckBudget() = %v", err)
+	}
+
+	cost := New(Config{MaxCost: 1})
+	cost.Add(Record{Cost: 1})
+	if err := cost.CheckBudget(); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("cost budget err = %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package usage
+
+import (
+	"context"
+	"slices"
+
+	"github.com/lgc202/go-kit/llm"
+	"github.com/lgc202/go-kit/llm/schema"
+)
+
+// Wrap 返回记录 model 用量的 ChatModel，等价于 llm.Chain(model, t.Middleware())
+func (t *Tracker) Wrap(model llm.ChatModel) llm.ChatModel {
+	return llm.Chain(model, t.Middleware())
+}
+
+// Middleware 返回记录用量的中间件，超出预算时调用返回 ErrBudgetExceeded 而不请求模型。
+//
+// provider 取 llm.ProviderOfResponse（经 router 等转发时为实际响应的后端），
+// 模型名取响应中的 Model（流式调用取事件携带的 Model），为空时取 llm.WithModel；
+// 流式调用的用量取流中最后一个非空的 Usage，在流结束或关闭时记录。
+// 两者都为空（如模型来自客户端的 DefaultOptions 且 provider 未返回模型名）时无法计价，见 Config.OnUnpriced。
+func (t *Tracker) Middleware() llm.ChatMiddleware {
+	return llm.ChatMiddlewareFuncs{
+		Chat: func(ctx context.Context, next llm.ChatModel, messages []schema.Message, opts ...llm.ChatOption) (schema.ChatResponse, error) {
+			if err := t.CheckBudget(); err != nil {
+				return schema.ChatResponse{}, err
+			}
+			resp, err := next.Chat(ctx, messages, opts...)
+			if err != nil {
+				return resp, err
+			}
+
+			key := t.key(ctx, llm.ProviderOfResponse(next, resp.ExtraFields), llm.ApplyChatOptions(opts...))
+			if resp.Model != "" {
+				key.Model = resp.Model
+			}
+			t.Add(Record{Key: key, Usage: resp.Usage})
+			return resp, nil
+		},
+		ChatStream: func(ctx context.Context, next llm.ChatModel, messages []schema.Message, opts ...llm.ChatOption) (llm.Stream, error) {
+			if err := t.CheckBudget(); err != nil {
+				return nil, err
+			}
+			cfg := llm.ApplyChatOptions(opts...)
+			if t.cfg.StreamUsage && cfg.StreamOptions == nil {
+				opts = append(slices.Clone(opts), llm.WithStreamIncludeUsage())
+			}
+			stream, err := next.ChatStream(ctx, messages, opts...)
+			if err != nil {
+				return nil, err
+			}
+
+			// 提前关闭或出错的流也记录一次请求及已收到的用量
+			r := Record{Key: t.key(ctx, llm.ProviderOf(next), cfg)}
+			return llm.WrapStream(stream, llm.StreamHooks{
+				OnEvent: func(ev *schema.StreamEvent) error {
+					if ev.Usage != nil {
+						r.Usage = *ev.Usage
+					}
+					if ev.Model != "" {
+						r.Model = ev.Model
+					}
+					if _, ok := ev.ExtraFields[llm.ExtraProvider]; ok {
+						r.Provider = llm.ProviderOfResponse(next, ev.ExtraFields)
+					}
+					return nil
+				},
+				OnEnd: func(error) { t.Add(r) },
+			}), nil
+		},
+	}.Middleware()
+}